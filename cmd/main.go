@@ -5,8 +5,23 @@ import (
 	"aispace/internal/services"
 	"aispace/internal/config"
 	"aispace/internal/modules/disks"
+	"aispace/internal/modules/images"
+	"aispace/internal/modules/jobs"
+	"aispace/internal/modules/network"
+	"aispace/internal/modules/pods"
+	"aispace/internal/modules/events"
 	"aispace/internal/modules/projects"
+	"aispace/internal/modules/reconciler"
+	"aispace/internal/modules/storageclasses"
+	"aispace/internal/modules/culler"
+	"aispace/internal/modules/cronjobs"
+	"aispace/internal/modules/secrets"
+	"aispace/internal/modules/deployments"
+	"aispace/internal/modules/notifications"
+	"aispace/internal/modules/usage"
 	"aispace/internal/modules/users"
+	"aispace/internal/modules/workspaces"
+	"aispace/internal/outbox"
 	"aispace/internal/storage"
 	"context"
 	"fmt"
@@ -41,6 +56,7 @@ func main() {
 			storage.NewDB,
 			storage.NewUnitOfWork,
 			services.ProvideKuberService,
+			outbox.ProvideDispatcher,
 			// users
 			users.ProvidePostgresUserRepository,
 			users.ProvideAuthService,
@@ -53,13 +69,69 @@ func main() {
 			disks.ProvidePostgresDiskRepository,
 			disks.ProvideDiskService,
 			disks.ProvideDiskHandler,
+			// workspaces
+			workspaces.ProvidePostgresWorkspaceRepository,
+			workspaces.ProvideWorkspaceService,
+			workspaces.ProvideWorkspaceHandler,
+			// jobs
+			jobs.ProvidePostgresJobRepository,
+			jobs.ProvideJobService,
+			jobs.ProvideJobHandler,
+			// reconciler
+			reconciler.ProvidePostgresReconcilerRepository,
+			reconciler.ProvideReconcilerService,
+			reconciler.ProvideReconcilerHandler,
+			// storage classes
+			storageclasses.ProvidePostgresStorageClassRepository,
+			storageclasses.ProvideStorageClassService,
+			storageclasses.ProvideStorageClassHandler,
+			// network
+			network.ProvidePostgresNetworkRepository,
+			network.ProvideNetworkService,
+			network.ProvideNetworkHandler,
+			// images
+			images.ProvidePostgresImageRepository,
+			images.ProvideImageService,
+			images.ProvideImageHandler,
+			// pods
+			pods.ProvidePostgresPodRepository,
+			pods.ProvidePodService,
+			pods.ProvidePodHandler,
+			// events
+			events.ProvidePostgresEventRepository,
+			events.ProvideEventService,
+			events.ProvideEventHandler,
+			// usage
+			usage.ProvidePostgresUsageRepository,
+			usage.ProvideUsageService,
+			usage.ProvideUsageHandler,
+			// culler
+			culler.ProvidePostgresCullerRepository,
+			culler.ProvideCullerService,
+			culler.ProvideCullerHandler,
+			// notifications
+			notifications.ProvidePostgresNotificationRepository,
+			notifications.ProvideNotificationService,
+			notifications.ProvideNotificationHandler,
+			// cron jobs
+			cronjobs.ProvidePostgresCronJobRepository,
+			cronjobs.ProvideCronJobService,
+			cronjobs.ProvideCronJobHandler,
+			// secrets
+			secrets.ProvidePostgresSecretRepository,
+			secrets.ProvideSecretService,
+			secrets.ProvideSecretHandler,
+			// deployments
+			deployments.ProvidePostgresDeploymentRepository,
+			deployments.ProvideDeploymentService,
+			deployments.ProvideDeploymentHandler,
 			internal.NewHandlers,
 			ProvideServer,
 		),
 		fx.Invoke(func(h *internal.Handlers, r *chi.Mux, provider *oidc.Provider, config oauth2.Config) {
 			h.SetupRoutes(r, provider)
 		}),
-		fx.Invoke(func(srv *http.Server, lc fx.Lifecycle, k *services.KuberService) {
+		fx.Invoke(func(srv *http.Server, lc fx.Lifecycle, k *services.KuberService, d *outbox.Dispatcher, rec *reconciler.ReconcilerService, us *usage.UsageService, cs *culler.CullerService, ds *deployments.DeploymentService) {
 			lc.Append(fx.Hook{
 				OnStart: func(ctx context.Context) error {
 					go func() {
@@ -67,9 +139,19 @@ func main() {
 							log.Fatalf("Server failed to start: %v", err)
 						}
 					}()
+					d.Start()
+					rec.Start()
+					us.Start()
+					cs.Start()
+					ds.Start()
 					return nil
 				},
 				OnStop: func(ctx context.Context) error {
+					ds.Stop()
+					cs.Stop()
+					us.Stop()
+					rec.Stop()
+					d.Stop()
 					k.StopInformer()
 					return srv.Shutdown(ctx)
 				},
@@ -1,16 +1,30 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	Server ServerConfig
-	Auth   AuthConfig
-	DB     DBConfig
-	CORS   CORSConfig
-	Kuber  KuberConfig
+	Server      ServerConfig
+	Auth        AuthConfig
+	DB          DBConfig
+	CORS        CORSConfig
+	Kuber       KuberConfig
+	Workspace   WorkspaceConfig
+	Reconcile   ReconcileConfig
+	Usage       UsageConfig
+	Cull        CullConfig
+	Deployment  DeploymentConfig
+	TensorBoard TensorBoardConfig
+	DiskBrowser DiskBrowserConfig
+	Job         JobConfig
+	Proxy       ProxyConfig
 }
 
 type ServerConfig struct {
@@ -24,6 +38,7 @@ type AuthConfig struct {
 	ClientSecret string
 	RedirectURL  string
 	Realm        string
+	AdminEmails  []string
 }
 
 type DBConfig struct {
@@ -43,11 +58,78 @@ type CORSConfig struct {
 }
 
 type KuberConfig struct {
-	KubeConfigPath string
+	KubeConfigPath      string
+	ClusterDomain       string
+	VolumeSnapshotClass string
+	CrossNamespaceClone bool
+	OIDCUsernamePrefix  string
+	APIServerURL        string
+	OIDCClientID        string
+	IngressNamespaces   []string
+	Namespace           string
+}
+
+type WorkspaceConfig struct {
+	DefaultImage string
+}
+
+// DeploymentConfig adds an Ingress per model deployment when a domain is
+// set, deployments are otherwise only reached through mlspace.
+type DeploymentConfig struct {
+	IngressDomain string
+	IngressClass  string
+}
+
+type TensorBoardConfig struct {
+	Image string
+}
+
+// DiskBrowserConfig sets up the helper pods of the disk file browser, the
+// image needs sh, find, stat, head and cat.
+type DiskBrowserConfig struct {
+	Image         string
+	Lifetime      time.Duration
+	MaxUploadSize int64
+}
+
+// JobConfig decides how long finished Jobs and their pods stay in the
+// cluster, the final state is kept in the DB after that.
+type JobConfig struct {
+	TTL time.Duration
+}
+
+// ProxyConfig moves the proxied workloads (JupyterLab, TensorBoard, model
+// servers) to their own origin, their pages can't script mlspace from there.
+// The secret signs the logins handed to that origin and derives the tokens
+// of the workspaces.
+type ProxyConfig struct {
+	Origin string
+	Secret string
+}
+
+type ReconcileConfig struct {
+	Interval   time.Duration
+	AutoRepair bool
+}
+
+type UsageConfig struct {
+	SampleInterval time.Duration
+	Retention      time.Duration
+}
+
+// CullConfig drives the stopping of idle workspaces and the project shutdown
+// schedules. CPU usage decides for workspaces without a Jupyter server.
+// TensorBoards are idle when nobody opened them through mlspace.
+type CullConfig struct {
+	Interval               time.Duration
+	IdleTimeout            time.Duration
+	IdleCPUMillicores      int64
+	Warning                time.Duration
+	TensorBoardIdleTimeout time.Duration
 }
 
 func Load() Config {
-	return Config{
+	cfg := Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "3000"),
 			Host: getEnv("SERVER_HOST", "localhost"),
@@ -58,6 +140,7 @@ func Load() Config {
 			ClientSecret: getEnv("CLIENT_SECRET", ""),
 			RedirectURL:  getEnv("REDIRECT_URL", ""),
 			Realm:        getEnv("REALM", ""),
+			AdminEmails:  splitList(getEnv("ADMIN_EMAILS", "")),
 		},
 		DB: DBConfig{
 			Host:     getEnv("DB_HOST", ""),
@@ -75,8 +158,77 @@ func Load() Config {
 		},
 		Kuber: KuberConfig{
 			KubeConfigPath: getEnv("KUBE_CONFIG_PATH", ""),
+			ClusterDomain:  getEnv("KUBE_CLUSTER_DOMAIN", "cluster.local"),
+			// empty means the default VolumeSnapshotClass of the driver
+			VolumeSnapshotClass: getEnv("KUBE_VOLUME_SNAPSHOT_CLASS", ""),
+			// needs the CrossNamespaceVolumeDataSource feature gate and a CSI driver supporting it
+			CrossNamespaceClone: getEnv("KUBE_CROSS_NAMESPACE_CLONE", "false") == "true",
+			// must match --oidc-username-prefix of the API server, which maps the email claim to the user name
+			OIDCUsernamePrefix: getEnv("KUBE_OIDC_USERNAME_PREFIX", ""),
+			// written into the downloaded kubeconfigs, empty means the address mlspace itself uses
+			APIServerURL: getEnv("KUBE_API_SERVER_URL", ""),
+			// public client (no secret, redirect to http://localhost:8000) used by kubelogin, the API server
+			// has to accept its tokens, empty disables the kubeconfig download
+			OIDCClientID: getEnv("KUBE_OIDC_CLIENT_ID", ""),
+			// namespaces of the ingress controller and other callers allowed into every project
+			IngressNamespaces: splitList(getEnv("KUBE_INGRESS_NAMESPACES", "")),
+			// the namespace mlspace runs in, its proxy and culler are always allowed into projects
+			Namespace: getEnv("MLSPACE_NAMESPACE", inClusterNamespace()),
+		},
+		Workspace: WorkspaceConfig{
+			DefaultImage: getEnv("WORKSPACE_DEFAULT_IMAGE", "quay.io/jupyter/minimal-notebook:latest"),
+		},
+		Reconcile: ReconcileConfig{
+			Interval:   getDuration("RECONCILE_INTERVAL", 5*time.Minute),
+			AutoRepair: getEnv("RECONCILE_AUTO_REPAIR", "false") == "true",
+		},
+		Usage: UsageConfig{
+			SampleInterval: getDuration("USAGE_SAMPLE_INTERVAL", time.Minute),
+			// the longest chart range is 7 days
+			Retention: getDuration("USAGE_RETENTION", 8*24*time.Hour),
+		},
+		Cull: CullConfig{
+			Interval:          getDuration("CULL_INTERVAL", time.Minute),
+			IdleTimeout:       getDuration("CULL_IDLE_TIMEOUT", 4*time.Hour),
+			IdleCPUMillicores: getInt("CULL_IDLE_CPU_MILLICORES", 20),
+			// how long before a stop the owner is notified
+			Warning:                getDuration("CULL_WARNING", 15*time.Minute),
+			TensorBoardIdleTimeout: getDuration("CULL_TENSORBOARD_IDLE_TIMEOUT", 30*time.Minute),
+		},
+		Deployment: DeploymentConfig{
+			// hosts are <deployment>.<domain>, a wildcard DNS record has to point at the ingress controller
+			IngressDomain: getEnv("DEPLOYMENT_INGRESS_DOMAIN", ""),
+			// empty means the default IngressClass of the cluster
+			IngressClass: getEnv("DEPLOYMENT_INGRESS_CLASS", ""),
+		},
+		TensorBoard: TensorBoardConfig{
+			// needs the tensorboard executable on the PATH
+			Image: getEnv("TENSORBOARD_IMAGE", "tensorflow/tensorflow:latest"),
+		},
+		DiskBrowser: DiskBrowserConfig{
+			Image:    getEnv("DISK_BROWSER_IMAGE", "busybox:1.36"),
+			Lifetime: getDuration("DISK_BROWSER_LIFETIME", 15*time.Minute),
+			// in MiB, per upload request
+			MaxUploadSize: getInt("DISK_BROWSER_MAX_UPLOAD_MB", 1024),
+		},
+		Job: JobConfig{
+			// logs of a job can be read until it is removed
+			TTL: getDuration("JOB_TTL", 7*24*time.Hour),
 		},
+		Proxy: ProxyConfig{
+			// e.g. https://apps.mlspace.example, best on another site than mlspace itself
+			Origin: strings.TrimSuffix(getEnv("PROXY_ORIGIN", ""), "/"),
+			Secret: getEnv("PROXY_SECRET", ""),
+		},
+	}
+
+	// the same on every replica without extra setup
+	if cfg.Proxy.Secret == "" {
+		sum := sha256.Sum256([]byte("mlspace-proxy:" + cfg.Auth.ClientSecret))
+		cfg.Proxy.Secret = hex.EncodeToString(sum[:])
 	}
+
+	return cfg
 }
 
 func getEnv(key, fallback string) string {
@@ -86,6 +238,59 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// inClusterNamespace is the namespace of the pod's service account, empty
+// outside of a cluster.
+func inClusterNamespace() string {
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Origin is where mlspace itself is served, taken from the redirect URL.
+func (c AuthConfig) Origin() string {
+	redirect, err := url.Parse(c.RedirectURL)
+	if err != nil || redirect.Host == "" {
+		return ""
+	}
+	return redirect.Scheme + "://" + redirect.Host
+}
+
+// IsAdmin reports whether the email belongs to an mlspace administrator.
+func (c AuthConfig) IsAdmin(email string) bool {
+	for _, admin := range c.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
 func ProvideConfig() *Config {
 	cfg := Load()
 	return &cfg
@@ -5,3 +5,4 @@ type contextKey string
 var ContextEmail = contextKey("email")
 var ContextUsername = contextKey("username")
 var ContextProjectId = contextKey("projectId")
+var ContextIsAdmin = contextKey("isAdmin")
@@ -3,9 +3,23 @@ package internal
 import (
 	"aispace/internal/config"
 	"aispace/internal/middlewares"
+	"aispace/internal/modules/cronjobs"
+	"aispace/internal/modules/culler"
+	"aispace/internal/modules/deployments"
 	"aispace/internal/modules/disks"
+	"aispace/internal/modules/events"
+	"aispace/internal/modules/images"
+	"aispace/internal/modules/jobs"
+	"aispace/internal/modules/network"
+	"aispace/internal/modules/notifications"
+	"aispace/internal/modules/pods"
 	"aispace/internal/modules/projects"
+	"aispace/internal/modules/reconciler"
+	"aispace/internal/modules/secrets"
+	"aispace/internal/modules/storageclasses"
+	"aispace/internal/modules/usage"
 	"aispace/internal/modules/users"
+	"aispace/internal/modules/workspaces"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-chi/chi/v5"
@@ -13,11 +27,25 @@ import (
 )
 
 type Handlers struct {
-	cfg            *config.Config
-	oauth2Config   oauth2.Config
-	authHandler    *users.AuthHandler
-	projectHandler *projects.ProjectHandler
-	diskHandler    *disks.DiskHandler
+	cfg                 *config.Config
+	oauth2Config        oauth2.Config
+	authHandler         *users.AuthHandler
+	projectHandler      *projects.ProjectHandler
+	diskHandler         *disks.DiskHandler
+	workspaceHandler    *workspaces.WorkspaceHandler
+	jobHandler          *jobs.JobHandler
+	reconcilerHandler   *reconciler.ReconcilerHandler
+	storageClassHandler *storageclasses.StorageClassHandler
+	networkHandler      *network.NetworkHandler
+	imageHandler        *images.ImageHandler
+	podHandler          *pods.PodHandler
+	eventHandler        *events.EventHandler
+	usageHandler        *usage.UsageHandler
+	cullerHandler       *culler.CullerHandler
+	notificationHandler *notifications.NotificationHandler
+	cronJobHandler      *cronjobs.CronJobHandler
+	secretHandler       *secrets.SecretHandler
+	deploymentHandler   *deployments.DeploymentHandler
 }
 
 func NewHandlers(
@@ -26,21 +54,59 @@ func NewHandlers(
 	authHandler *users.AuthHandler,
 	projectHandler *projects.ProjectHandler,
 	diskHandler *disks.DiskHandler,
+	workspaceHandler *workspaces.WorkspaceHandler,
+	jobHandler *jobs.JobHandler,
+	reconcilerHandler *reconciler.ReconcilerHandler,
+	storageClassHandler *storageclasses.StorageClassHandler,
+	networkHandler *network.NetworkHandler,
+	imageHandler *images.ImageHandler,
+	podHandler *pods.PodHandler,
+	eventHandler *events.EventHandler,
+	usageHandler *usage.UsageHandler,
+	cullerHandler *culler.CullerHandler,
+	notificationHandler *notifications.NotificationHandler,
+	cronJobHandler *cronjobs.CronJobHandler,
+	secretHandler *secrets.SecretHandler,
+	deploymentHandler *deployments.DeploymentHandler,
 ) *Handlers {
 	return &Handlers{
-		cfg:            cfg,
-		oauth2Config:   oauth2Config,
-		authHandler:    authHandler,
-		projectHandler: projectHandler,
-		diskHandler:    diskHandler,
+		cfg:                 cfg,
+		oauth2Config:        oauth2Config,
+		authHandler:         authHandler,
+		projectHandler:      projectHandler,
+		diskHandler:         diskHandler,
+		workspaceHandler:    workspaceHandler,
+		jobHandler:          jobHandler,
+		reconcilerHandler:   reconcilerHandler,
+		storageClassHandler: storageClassHandler,
+		networkHandler:      networkHandler,
+		imageHandler:        imageHandler,
+		podHandler:          podHandler,
+		eventHandler:        eventHandler,
+		usageHandler:        usageHandler,
+		cullerHandler:       cullerHandler,
+		notificationHandler: notificationHandler,
+		cronJobHandler:      cronJobHandler,
+		secretHandler:       secretHandler,
+		deploymentHandler:   deploymentHandler,
 	}
 }
 
 func (h *Handlers) SetupRoutes(r *chi.Mux, provider *oidc.Provider) {
 	r.Get("/auth", h.authHandler.Login)
+	r.Get("/proxy-login", middlewares.ProxyLogin(h.cfg))
+
+	// workloads run user code, with PROXY_ORIGIN set they are served from there
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.WorkloadAuthMiddleware(h.cfg, provider, h.oauth2Config))
+		r.HandleFunc("/workspaces/{workspace_id}/lab/*", h.workspaceHandler.ProxyWorkspace)
+		r.HandleFunc("/disks/{disk_id}/tensorboard/*", h.diskHandler.ProxyTensorBoard)
+		r.HandleFunc("/deployments/{deployment_id}/api/*", h.deploymentHandler.ProxyDeployment)
+	})
 
 	r.Group(func(r chi.Router) {
 		r.Use(middlewares.AuthMiddleware(provider, h.oauth2Config))
+		r.Use(middlewares.AdminContextMiddleware(h.cfg))
 		r.Get("/", h.projectHandler.GetProjects)
 		r.Get("/auth/logout", h.authHandler.Logout)
 		// PROJECTS
@@ -51,12 +117,121 @@ func (h *Handlers) SetupRoutes(r *chi.Mux, provider *oidc.Provider) {
 		r.Post("/projects/{project_id}/add-users", h.projectHandler.AddParticipants)
 		r.Delete("/projects/{project_id}/participants/{participant_id}", h.projectHandler.DeleteParticipant)
 		r.Delete("/projects/{project_id}", h.projectHandler.DeleteProject)
+		r.Put("/projects/{project_id}/limits", h.projectHandler.UpdateProjectLimits)
+		r.Get("/projects/{project_id}/kubeconfig", h.projectHandler.DownloadKubeconfig)
+		r.Get("/projects/{project_id}/network", h.projectHandler.GetProjectNetwork)
+		r.Get("/projects/{project_id}/images", h.imageHandler.GetProjectImages)
+		r.Put("/projects/{project_id}/images/{image_id}", h.imageHandler.UpdateProjectImage)
 		// DISKS
 		r.Get("/disks", h.diskHandler.GetDisks)
 		r.Get("/disks/project-search", h.diskHandler.GetProjectsForDisk)
+		r.Get("/disks/storage-classes", h.diskHandler.GetStorageClassesForDisk)
+		r.Get("/disks/events", h.diskHandler.StreamDiskStatus)
+		r.Get("/disks/{disk_id}", h.diskHandler.GetDisk)
 		r.Get("/disks/{disk_id}/status", h.diskHandler.GetDiskStatus)
 		r.Post("/disks", h.diskHandler.CreateDisk)
 		r.Delete("/disks/{disk_id}", h.diskHandler.DeleteDisk)
+		r.Put("/disks/{disk_id}/size", h.diskHandler.ResizeDisk)
+		r.Get("/disks/{disk_id}/snapshots", h.diskHandler.GetDiskSnapshots)
+		r.Post("/disks/{disk_id}/snapshots", h.diskHandler.CreateSnapshot)
+		r.Get("/disks/{disk_id}/clone", h.diskHandler.GetCloneDiskForm)
+		r.Post("/disks/{disk_id}/clone", h.diskHandler.CloneDisk)
+		r.Get("/disks/{disk_id}/tensorboard", h.diskHandler.GetTensorBoard)
+		r.Post("/disks/{disk_id}/tensorboard", h.diskHandler.StartTensorBoard)
+		r.Delete("/disks/{disk_id}/tensorboard", h.diskHandler.StopTensorBoard)
+		r.Get("/disks/{disk_id}/files", h.diskHandler.GetDiskFiles)
+		r.Post("/disks/{disk_id}/files", h.diskHandler.UploadDiskFiles)
+		r.Delete("/disks/{disk_id}/files", h.diskHandler.DeleteDiskFile)
+		r.Get("/disks/{disk_id}/files/preview", h.diskHandler.PreviewDiskFile)
+		r.Get("/disks/{disk_id}/files/download", h.diskHandler.DownloadDiskFile)
+		r.Post("/snapshots/{snapshot_id}/restore", h.diskHandler.RestoreSnapshot)
+		r.Delete("/snapshots/{snapshot_id}", h.diskHandler.DeleteSnapshot)
+		// WORKSPACES
+		r.Get("/workspaces", h.workspaceHandler.GetWorkspaces)
+		r.Get("/workspaces/project-search", h.workspaceHandler.GetProjectsForWorkspace)
+		r.Get("/workspaces/project-disks", h.workspaceHandler.GetDisksForWorkspace)
+		r.Get("/workspaces/project-secrets", h.workspaceHandler.GetSecretsForWorkspace)
+		r.Get("/workspaces/project-images", h.workspaceHandler.GetImagesForWorkspace)
+		r.Post("/workspaces", h.workspaceHandler.CreateWorkspace)
+		r.Get("/workspaces/{workspace_id}/status", h.workspaceHandler.GetWorkspaceStatus)
+		r.Post("/workspaces/{workspace_id}/start", h.workspaceHandler.StartWorkspace)
+		r.Post("/workspaces/{workspace_id}/stop", h.workspaceHandler.StopWorkspace)
+		r.Post("/workspaces/{workspace_id}/keep-running", h.workspaceHandler.SetKeepRunning)
+		r.Delete("/workspaces/{workspace_id}", h.workspaceHandler.DeleteWorkspace)
+		// JOBS
+		r.Get("/jobs", h.jobHandler.GetJobs)
+		r.Get("/jobs/project-search", h.jobHandler.GetProjectsForJob)
+		r.Get("/jobs/project-disks", h.jobHandler.GetDisksForJob)
+		r.Get("/jobs/project-secrets", h.jobHandler.GetSecretsForJob)
+		r.Get("/jobs/project-images", h.jobHandler.GetImagesForJob)
+		r.Post("/jobs", h.jobHandler.CreateJob)
+		r.Get("/jobs/{job_id}/status", h.jobHandler.GetJobStatus)
+		r.Delete("/jobs/{job_id}", h.jobHandler.DeleteJob)
+		r.Get("/jobs/{job_id}/logs", h.jobHandler.GetJobLogs)
+		r.Get("/projects/{project_id}/jobs", h.jobHandler.GetProjectJobs)
+		// CRON JOBS
+		r.Get("/projects/{project_id}/cron-jobs", h.cronJobHandler.GetProjectCronJobs)
+		r.Post("/projects/{project_id}/cron-jobs", h.cronJobHandler.CreateCronJob)
+		r.Get("/cron-jobs/{cron_job_id}/runs", h.cronJobHandler.GetCronJobRuns)
+		r.Post("/cron-jobs/{cron_job_id}/run", h.cronJobHandler.RunCronJob)
+		r.Post("/cron-jobs/{cron_job_id}/suspended", h.cronJobHandler.SetSuspended)
+		r.Delete("/cron-jobs/{cron_job_id}", h.cronJobHandler.DeleteCronJob)
+		// SECRETS
+		r.Get("/projects/{project_id}/secrets", h.secretHandler.GetProjectSecrets)
+		r.Post("/projects/{project_id}/secrets", h.secretHandler.CreateSecret)
+		r.Post("/secrets/{secret_id}/keys", h.secretHandler.SetSecretKey)
+		r.Delete("/secrets/{secret_id}/keys/{key}", h.secretHandler.RemoveSecretKey)
+		r.Delete("/secrets/{secret_id}", h.secretHandler.DeleteSecret)
+		// DEPLOYMENTS
+		r.Get("/projects/{project_id}/deployments", h.deploymentHandler.GetProjectDeployments)
+		r.Post("/projects/{project_id}/deployments", h.deploymentHandler.CreateDeployment)
+		r.Get("/deployments/{deployment_id}/status", h.deploymentHandler.GetDeploymentStatus)
+		r.Get("/deployments/{deployment_id}/edit", h.deploymentHandler.GetUpdateDeploymentForm)
+		r.Put("/deployments/{deployment_id}", h.deploymentHandler.UpdateDeployment)
+		r.Put("/deployments/{deployment_id}/replicas", h.deploymentHandler.ScaleDeployment)
+		r.Get("/deployments/{deployment_id}/revisions", h.deploymentHandler.GetDeploymentRevisions)
+		r.Post("/deployments/{deployment_id}/revisions/{revision}/rollback", h.deploymentHandler.RollbackDeployment)
+		r.Delete("/deployments/{deployment_id}", h.deploymentHandler.DeleteDeployment)
+		// PODS
+		r.Get("/projects/{project_id}/pods", h.podHandler.GetProjectPods)
+		r.Get("/projects/{project_id}/pods/{pod_name}/logs", h.podHandler.GetPodLogs)
+		r.Get("/projects/{project_id}/pods/{pod_name}/logs/viewer", h.podHandler.GetPodLogViewer)
+		r.Get("/projects/{project_id}/pods/{pod_name}/logs/stream", h.podHandler.StreamPodLogs)
+		r.Get("/projects/{project_id}/pods/{pod_name}/logs/download", h.podHandler.DownloadPodLogs)
+		r.Get("/projects/{project_id}/pods/{pod_name}/terminal", h.podHandler.GetPodTerminal)
+		r.Get("/projects/{project_id}/pods/{pod_name}/terminal/ws", h.podHandler.ServePodTerminal)
+		// EVENTS
+		r.Get("/projects/{project_id}/events", h.eventHandler.GetProjectEvents)
+		// USAGE
+		r.Get("/projects/{project_id}/usage", h.usageHandler.GetProjectUsage)
+		// SHUTDOWN SCHEDULES
+		r.Get("/projects/{project_id}/shutdown-schedules", h.cullerHandler.GetProjectShutdownSchedules)
+		r.Post("/projects/{project_id}/shutdown-schedules", h.cullerHandler.CreateShutdownSchedule)
+		r.Delete("/projects/{project_id}/shutdown-schedules/{schedule_id}", h.cullerHandler.DeleteShutdownSchedule)
+		// NOTIFICATIONS
+		r.Get("/notifications", h.notificationHandler.GetNotifications)
+		r.Get("/notifications/badge", h.notificationHandler.GetUnreadBadge)
+		// ADMIN
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AdminOnlyMiddleware)
+			r.Get("/admin/reconciler", h.reconcilerHandler.GetReport)
+			r.Post("/admin/reconciler/run", h.reconcilerHandler.RunCheck)
+			r.Post("/admin/reconciler/repair", h.reconcilerHandler.RunRepair)
+			r.Get("/admin/retained-volumes", h.diskHandler.GetRetainedVolumes)
+			r.Get("/admin/storage-classes", h.storageClassHandler.GetStorageClasses)
+			r.Post("/admin/storage-classes", h.storageClassHandler.CreateStorageClass)
+			r.Delete("/admin/storage-classes/{storage_class_id}", h.storageClassHandler.DeleteStorageClass)
+			r.Get("/admin/egress-rules", h.networkHandler.GetEgressRules)
+			r.Post("/admin/egress-rules", h.networkHandler.CreateEgressRule)
+			r.Delete("/admin/egress-rules/{rule_id}", h.networkHandler.DeleteEgressRule)
+			r.Get("/admin/images", h.imageHandler.GetImages)
+			r.Post("/admin/images", h.imageHandler.CreateImage)
+			r.Get("/admin/images/{image_id}", h.imageHandler.GetImageRow)
+			r.Get("/admin/images/{image_id}/edit", h.imageHandler.GetImageEditRow)
+			r.Put("/admin/images/{image_id}", h.imageHandler.UpdateImage)
+			r.Delete("/admin/images/{image_id}", h.imageHandler.DeleteImage)
+			r.Get("/admin/terminal-sessions", h.podHandler.GetTerminalSessions)
+		})
 	})
 }
 
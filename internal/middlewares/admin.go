@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"context"
+	"net/http"
+)
+
+// AdminContextMiddleware marks the request context of administrators, it has
+// to run after AuthMiddleware so the email is already known.
+func AdminContextMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			email, _ := r.Context().Value(consts.ContextEmail).(string)
+			ctx := context.WithValue(r.Context(), consts.ContextIsAdmin, cfg.Auth.IsAdmin(email))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func AdminOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAdmin, _ := r.Context().Value(consts.ContextIsAdmin).(bool); !isAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
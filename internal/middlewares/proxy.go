@@ -0,0 +1,180 @@
+package middlewares
+
+import (
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	ProxyCookie = "mlspace_proxy"
+
+	proxyLoginPath     = "/proxy-login"
+	proxyTicketTTL     = time.Minute
+	proxySessionMaxAge = 12 * time.Hour
+)
+
+// proxyClaims are signed into the one time login ticket and into the session
+// cookie of the proxy origin. Path is only set in tickets.
+type proxyClaims struct {
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Expires int64  `json:"exp"`
+}
+
+func signProxyClaims(secret string, claims proxyClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifyProxyClaims(secret, value string) (proxyClaims, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(value, ".")
+	if !ok {
+		return proxyClaims{}, errors.New("malformed proxy token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return proxyClaims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return proxyClaims{}, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return proxyClaims{}, errors.New("invalid proxy token signature")
+	}
+
+	var claims proxyClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return proxyClaims{}, err
+	}
+	if time.Now().Unix() > claims.Expires {
+		return proxyClaims{}, errors.New("proxy token expired")
+	}
+
+	return claims, nil
+}
+
+func isProxyHost(cfg *config.Config, r *http.Request) bool {
+	origin, err := url.Parse(cfg.Proxy.Origin)
+	return err == nil && origin.Host == r.Host
+}
+
+// WorkloadAuthMiddleware guards the proxied workload routes. Without a proxy
+// origin it is AuthMiddleware. Otherwise requests on the mlspace origin are
+// logged in and handed over to the proxy origin with a short lived ticket,
+// requests on the proxy origin are authenticated by its own session cookie
+// since the mlspace cookies are not sent there.
+func WorkloadAuthMiddleware(cfg *config.Config, provider *oidc.Provider, oauth2Config oauth2.Config) func(http.Handler) http.Handler {
+	auth := AuthMiddleware(provider, oauth2Config)
+
+	return func(next http.Handler) http.Handler {
+		handover := auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Proxy.Origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			email, _ := r.Context().Value(consts.ContextEmail).(string)
+			name, _ := r.Context().Value(consts.ContextUsername).(string)
+			ticket, err := signProxyClaims(cfg.Proxy.Secret, proxyClaims{
+				Email:   email,
+				Name:    name,
+				Path:    r.URL.RequestURI(),
+				Expires: time.Now().Add(proxyTicketTTL).Unix(),
+			})
+			if err != nil {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, cfg.Proxy.Origin+proxyLoginPath+"?ticket="+url.QueryEscape(ticket), http.StatusTemporaryRedirect)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Proxy.Origin == "" || !isProxyHost(cfg, r) {
+				handover.ServeHTTP(w, r)
+				return
+			}
+
+			cookie, err := r.Cookie(ProxyCookie)
+			if err != nil {
+				http.Redirect(w, r, cfg.Auth.Origin()+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+			claims, err := verifyProxyClaims(cfg.Proxy.Secret, cookie.Value)
+			if err != nil || claims.Path != "" {
+				http.Redirect(w, r, cfg.Auth.Origin()+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), consts.ContextEmail, claims.Email)
+			ctx = context.WithValue(ctx, consts.ContextUsername, claims.Name)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ProxyLogin turns a ticket from the mlspace origin into the session cookie
+// of the proxy origin and continues to the workload.
+func ProxyLogin(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg.Proxy.Origin == "" || !isProxyHost(cfg, r) {
+			http.NotFound(w, r)
+			return
+		}
+
+		claims, err := verifyProxyClaims(cfg.Proxy.Secret, r.URL.Query().Get("ticket"))
+		if err != nil || !strings.HasPrefix(claims.Path, "/") || strings.HasPrefix(claims.Path, "//") {
+			http.Error(w, "Invalid login", http.StatusBadRequest)
+			return
+		}
+
+		session, err := signProxyClaims(cfg.Proxy.Secret, proxyClaims{
+			Email:   claims.Email,
+			Name:    claims.Name,
+			Expires: time.Now().Add(proxySessionMaxAge).Unix(),
+		})
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     ProxyCookie,
+			Value:    session,
+			Path:     "/",
+			MaxAge:   int(proxySessionMaxAge.Seconds()),
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(cfg.Proxy.Origin, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, claims.Path, http.StatusTemporaryRedirect)
+	}
+}
@@ -0,0 +1,45 @@
+package cronjobs
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// CreateCronJobCommand defines a recurring job. The schedule itself is
+// checked by Kubernetes when the CronJob is created.
+type CreateCronJobCommand struct {
+	Name              string   `validate:"required,min=3,max=100" form:"name"`
+	ImageID           string   `validate:"omitempty,uuid" form:"image_id"`
+	Image             string   `validate:"max=500" form:"image"`
+	Command           string   `validate:"required,max=4000" form:"command"`
+	CPU               int      `validate:"required,gte=1" form:"cpu"`
+	RAM               int      `validate:"required,gte=1" form:"ram"`
+	DiskIDs           []string `validate:"dive,uuid" form:"disk_ids"`
+	Secrets           []string `validate:"dive,max=50" form:"secrets"`
+	Schedule          string   `validate:"required,max=100" form:"schedule"`
+	TimeZone          string   `validate:"required,timezone" form:"time_zone"`
+	ConcurrencyPolicy string   `validate:"required,oneof=Allow Forbid Replace" form:"concurrency_policy"`
+	SuccessfulHistory int      `validate:"gte=0,lte=10" form:"successful_history"`
+	FailedHistory     int      `validate:"gte=0,lte=10" form:"failed_history"`
+}
+
+func (c *CreateCronJobCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// SetSuspendedCommand pauses or resumes the schedule, runs already started
+// keep going.
+type SetSuspendedCommand struct {
+	Suspended bool `form:"suspended"`
+}
+
+func (c *SetSuspendedCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,104 @@
+package cronjobs
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type CronJobHandler struct {
+	cronJobService *CronJobService
+}
+
+func NewCronJobHandler(cronJobService *CronJobService) *CronJobHandler {
+	return &CronJobHandler{cronJobService: cronJobService}
+}
+
+func (h *CronJobHandler) GetProjectCronJobs(w http.ResponseWriter, r *http.Request) {
+	handler := h.cronJobService.GetProjectCronJobs(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *CronJobHandler) CreateCronJob(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateCronJobCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.cronJobService.CreateCronJob(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *CronJobHandler) GetCronJobRuns(w http.ResponseWriter, r *http.Request) {
+	handler := h.cronJobService.GetCronJobRuns(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *CronJobHandler) RunCronJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.cronJobService.RunCronJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *CronJobHandler) SetSuspended(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := SetSuspendedCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.cronJobService.SetSuspended(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *CronJobHandler) DeleteCronJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.cronJobService.DeleteCronJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideCronJobHandler(cronJobService *CronJobService) *CronJobHandler {
+	return NewCronJobHandler(cronJobService)
+}
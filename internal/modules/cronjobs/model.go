@@ -0,0 +1,197 @@
+package cronjobs
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/cronjobsweb"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CronJob struct {
+	ID                uuid.UUID `db:"id"`
+	Name              string    `db:"name"`
+	ProjectID         uuid.UUID `db:"project_id"`
+	Owner             Owner
+	ImageID           uuid.NullUUID `db:"image_id"`
+	Image             string        `db:"image"`
+	Command           string        `db:"command"`
+	CPU               int           `db:"cpu"`
+	RAM               int           `db:"ram"`
+	Schedule          string        `db:"schedule"`
+	TimeZone          string        `db:"time_zone"`
+	ConcurrencyPolicy string        `db:"concurrency_policy"`
+	SuccessfulHistory int           `db:"successful_history"`
+	FailedHistory     int           `db:"failed_history"`
+	Suspended         bool          `db:"suspended"`
+	Disks             []CronJobDisk
+	Secrets           []CronJobSecret
+	CreatedAt         time.Time `db:"created_at"`
+}
+
+type CronJobDisk struct {
+	ID   uuid.UUID `db:"id"`
+	Name string    `db:"name"`
+}
+
+// CronJobSecret is a project secret imported by every run as environment
+// variables or, with AsFile, as files.
+type CronJobSecret struct {
+	ID     uuid.UUID `db:"id"`
+	Name   string    `db:"name"`
+	AsFile bool      `db:"as_file"`
+}
+
+// CronJobImage is a catalog entry the project offers.
+type CronJobImage struct {
+	ID             uuid.UUID `db:"id"`
+	Name           string    `db:"name"`
+	Image          string    `db:"image"`
+	Description    string    `db:"description"`
+	DefaultCommand string    `db:"default_command"`
+	Port           *int      `db:"port"`
+	CPU            int       `db:"cpu"`
+	RAM            int       `db:"ram"`
+	Pinned         bool      `db:"pinned"`
+}
+
+type Owner struct {
+	Username string `db:"name"`
+	Email    string `db:"email"`
+}
+
+func (c *CronJob) GetNamespace() string {
+	return fmt.Sprintf("project-%s", c.ProjectID.String())
+}
+
+// GetCronJobName stays below the 52 characters Kubernetes allows for
+// CronJob names.
+func (c *CronJob) GetCronJobName() string {
+	return fmt.Sprintf("cron-%s", c.ID.String())
+}
+
+func (d *CronJobDisk) GetPVCName() string {
+	return fmt.Sprintf("disk-%s", d.ID.String())
+}
+
+func (s *CronJobSecret) GetSecretName() string {
+	return fmt.Sprintf("secret-%s", s.ID.String())
+}
+
+func (c *CronJob) ToSpec() services.CronJobSpec {
+	var mounts []services.DiskMount
+	for _, disk := range c.Disks {
+		mounts = append(mounts, services.DiskMount{
+			PVCName:   disk.GetPVCName(),
+			MountPath: path.Join("/mnt/disks", services.MountDirName(disk.Name)),
+		})
+	}
+
+	var secretMounts []services.SecretMount
+	for _, secret := range c.Secrets {
+		secretMounts = append(secretMounts, services.SecretMount{
+			SecretName: secret.GetSecretName(),
+			MountPath:  path.Join("/mnt/secrets", services.MountDirName(secret.Name)),
+			AsFile:     secret.AsFile,
+		})
+	}
+
+	return services.CronJobSpec{
+		ID:                c.ID.String(),
+		Name:              c.GetCronJobName(),
+		Namespace:         c.GetNamespace(),
+		ProjectID:         c.ProjectID.String(),
+		OwnerEmail:        c.Owner.Email,
+		Image:             c.Image,
+		Command:           c.Command,
+		CPU:               c.CPU,
+		RAM:               c.RAM,
+		Disks:             mounts,
+		Secrets:           secretMounts,
+		Schedule:          c.Schedule,
+		TimeZone:          c.TimeZone,
+		ConcurrencyPolicy: c.ConcurrencyPolicy,
+		SuccessfulHistory: c.SuccessfulHistory,
+		FailedHistory:     c.FailedHistory,
+		Suspended:         c.Suspended,
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func (d *CronJobDisk) ToWebCronJobDisk(disk CronJobDisk) cronjobsweb.WebCronJobDisk {
+	return cronjobsweb.WebCronJobDisk{
+		ID:   disk.ID,
+		Name: disk.Name,
+	}
+}
+
+func (s *CronJobSecret) ToWebCronJobSecret(secret CronJobSecret) cronjobsweb.WebCronJobSecret {
+	return cronjobsweb.WebCronJobSecret{
+		ID:   secret.ID,
+		Name: secret.Name,
+	}
+}
+
+func (i *CronJobImage) ToWebCronJobImage(image CronJobImage) cronjobsweb.WebCronJobImage {
+	return cronjobsweb.WebCronJobImage{
+		ID:             image.ID,
+		Name:           image.Name,
+		Description:    image.Description,
+		DefaultCommand: image.DefaultCommand,
+		CPU:            image.CPU,
+		RAM:            image.RAM,
+		Pinned:         image.Pinned,
+	}
+}
+
+func (c *CronJob) ToWebCronJob() cronjobsweb.WebCronJob {
+	var webDisks []cronjobsweb.WebCronJobDisk
+	for _, disk := range c.Disks {
+		webDisks = append(webDisks, disk.ToWebCronJobDisk(disk))
+	}
+
+	return cronjobsweb.WebCronJob{
+		ID:                c.ID,
+		Name:              c.Name,
+		ProjectID:         c.ProjectID,
+		OwnerUsername:     c.Owner.Username,
+		OwnerEmail:        c.Owner.Email,
+		Image:             c.Image,
+		Command:           c.Command,
+		CPU:               c.CPU,
+		RAM:               c.RAM,
+		Schedule:          c.Schedule,
+		TimeZone:          c.TimeZone,
+		ConcurrencyPolicy: c.ConcurrencyPolicy,
+		History:           fmt.Sprintf("%d / %d", c.SuccessfulHistory, c.FailedHistory),
+		Suspended:         c.Suspended,
+		Disks:             webDisks,
+		CreatedAt:         c.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+func toWebCronJobRun(projectId uuid.UUID, run services.CronJobRun) cronjobsweb.WebCronJobRun {
+	exitCode := "-"
+	if run.State.ExitCode != nil {
+		exitCode = fmt.Sprint(*run.State.ExitCode)
+	}
+
+	return cronjobsweb.WebCronJobRun{
+		Name:       run.Name,
+		ProjectID:  projectId,
+		PodName:    run.PodName,
+		Manual:     run.Manual,
+		Status:     run.State.Status.String(),
+		StartedAt:  formatTime(run.State.StartedAt),
+		FinishedAt: formatTime(run.State.FinishedAt),
+		ExitCode:   exitCode,
+	}
+}
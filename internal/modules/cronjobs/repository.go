@@ -0,0 +1,428 @@
+package cronjobs
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type CronJobRepository interface {
+	GetProjectCronJobs(projectId uuid.UUID) ([]CronJob, error)
+	GetCronJobByID(id uuid.UUID) (CronJob, error)
+	CreateCronJob(ctx context.Context, cronJob CronJob) error
+	SetCronJobSuspended(id uuid.UUID, suspended bool) error
+	DeleteCronJob(id uuid.UUID) error
+	GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]CronJobDisk, error)
+	GetProjectSecrets(projectId uuid.UUID) ([]CronJobSecret, error)
+	GetProjectImages(projectId uuid.UUID) ([]CronJobImage, error)
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanManageCronJob(id uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresCronJobRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresCronJobRepository(uow storage.UnitOfWork) *PostgresCronJobRepository {
+	return &PostgresCronJobRepository{uow: uow}
+}
+
+const cronJobColumns = `
+	c.id, c.name, c.project_id, c.image_id, c.image, c.command, c.cpu, c.ram,
+	c.schedule, c.time_zone, c.concurrency_policy, c.successful_history, c.failed_history,
+	c.suspended, c.created_at, u.name, u.email
+`
+
+func scanCronJob(scanner interface{ Scan(...any) error }) (CronJob, error) {
+	var cronJob CronJob
+
+	err := scanner.Scan(
+		&cronJob.ID,
+		&cronJob.Name,
+		&cronJob.ProjectID,
+		&cronJob.ImageID,
+		&cronJob.Image,
+		&cronJob.Command,
+		&cronJob.CPU,
+		&cronJob.RAM,
+		&cronJob.Schedule,
+		&cronJob.TimeZone,
+		&cronJob.ConcurrencyPolicy,
+		&cronJob.SuccessfulHistory,
+		&cronJob.FailedHistory,
+		&cronJob.Suspended,
+		&cronJob.CreatedAt,
+		&cronJob.Owner.Username,
+		&cronJob.Owner.Email,
+	)
+
+	return cronJob, err
+}
+
+func (p *PostgresCronJobRepository) GetProjectCronJobs(projectId uuid.UUID) ([]CronJob, error) {
+	query := `
+		SELECT ` + cronJobColumns + `
+		FROM cron_jobs c
+		JOIN users u
+		ON u.id = c.owner_id
+		WHERE c.project_id = $1
+		ORDER BY c.created_at DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cronJobList []CronJob
+
+	for rows.Next() {
+		cronJob, err := scanCronJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		cronJobList = append(cronJobList, cronJob)
+	}
+
+	for i := range cronJobList {
+		disks, err := p.getCronJobDisks(cronJobList[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		cronJobList[i].Disks = disks
+
+		secrets, err := p.getCronJobSecrets(cronJobList[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		cronJobList[i].Secrets = secrets
+	}
+
+	return cronJobList, nil
+}
+
+func (p *PostgresCronJobRepository) GetCronJobByID(id uuid.UUID) (CronJob, error) {
+	query := `
+		SELECT ` + cronJobColumns + `
+		FROM cron_jobs c
+		JOIN users u
+		ON u.id = c.owner_id
+		WHERE c.id = $1
+	`
+
+	cronJob, err := scanCronJob(p.uow.DB().QueryRowx(query, id))
+	if err != nil {
+		return CronJob{}, err
+	}
+
+	cronJob.Disks, err = p.getCronJobDisks(id)
+	if err != nil {
+		return CronJob{}, err
+	}
+
+	cronJob.Secrets, err = p.getCronJobSecrets(id)
+	if err != nil {
+		return CronJob{}, err
+	}
+
+	return cronJob, nil
+}
+
+func (p *PostgresCronJobRepository) getCronJobDisks(id uuid.UUID) ([]CronJobDisk, error) {
+	query := `
+		SELECT d.id, d.name
+		FROM cron_job_disks cd
+		JOIN disks d
+		ON d.id = cd.disk_id
+		WHERE cd.cron_job_id = $1
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []CronJobDisk
+	for rows.Next() {
+		var disk CronJobDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresCronJobRepository) getCronJobSecrets(id uuid.UUID) ([]CronJobSecret, error) {
+	query := `
+		SELECT s.id, s.name, cs.as_file
+		FROM cron_job_secrets cs
+		JOIN project_secrets s
+		ON s.id = cs.secret_id
+		WHERE cs.cron_job_id = $1
+		ORDER BY s.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []CronJobSecret
+	for rows.Next() {
+		var secret CronJobSecret
+		if err := rows.StructScan(&secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+func (p *PostgresCronJobRepository) CreateCronJob(ctx context.Context, cronJob CronJob) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO cron_jobs (
+				id, name, project_id, owner_id, image_id, image, command, cpu, ram,
+				schedule, time_zone, concurrency_policy, successful_history, failed_history,
+				suspended, created_at
+			)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			cronJob.ID,
+			cronJob.Name,
+			cronJob.ProjectID,
+			cronJob.Owner.Email,
+			cronJob.ImageID,
+			cronJob.Image,
+			cronJob.Command,
+			cronJob.CPU,
+			cronJob.RAM,
+			cronJob.Schedule,
+			cronJob.TimeZone,
+			cronJob.ConcurrencyPolicy,
+			cronJob.SuccessfulHistory,
+			cronJob.FailedHistory,
+			cronJob.Suspended,
+			cronJob.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, disk := range cronJob.Disks {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO cron_job_disks (cron_job_id, disk_id) VALUES ($1, $2)`,
+				cronJob.ID,
+				disk.ID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, secret := range cronJob.Secrets {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO cron_job_secrets (cron_job_id, secret_id, as_file) VALUES ($1, $2, $3)`,
+				cronJob.ID,
+				secret.ID,
+				secret.AsFile,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (p *PostgresCronJobRepository) SetCronJobSuspended(id uuid.UUID, suspended bool) error {
+	query := `
+		UPDATE cron_jobs
+		SET suspended = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, suspended)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresCronJobRepository) DeleteCronJob(id uuid.UUID) error {
+	query := `
+		DELETE FROM cron_jobs WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresCronJobRepository) GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]CronJobDisk, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT d.id, d.name
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		WHERE d.project_id = $1
+		AND (u.email = $2 OR d.shared)
+		AND d.provision_status = 'provisioned'
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []CronJobDisk
+	for rows.Next() {
+		var disk CronJobDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresCronJobRepository) GetProjectSecrets(projectId uuid.UUID) ([]CronJobSecret, error) {
+	query := `
+		SELECT id, name, FALSE AS as_file
+		FROM project_secrets
+		WHERE project_id = $1
+		ORDER BY name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []CronJobSecret
+	for rows.Next() {
+		var secret CronJobSecret
+		if err := rows.StructScan(&secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// GetProjectImages returns the catalog entries the project did not hide,
+// pinned ones first.
+func (p *PostgresCronJobRepository) GetProjectImages(projectId uuid.UUID) ([]CronJobImage, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram,
+			COALESCE(pi.pinned, FALSE) AS pinned
+		FROM images i
+		LEFT JOIN project_images pi
+		ON pi.image_id = i.id AND pi.project_id = $1
+		WHERE NOT COALESCE(pi.hidden, FALSE)
+		ORDER BY pinned DESC, i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []CronJobImage
+	for rows.Next() {
+		var image CronJobImage
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresCronJobRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanManageCronJob allows the cron job owner and the project owner.
+func (p *PostgresCronJobRepository) CanManageCronJob(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM cron_jobs c
+		JOIN users cron_u
+		ON cron_u.id = c.owner_id
+		JOIN projects p
+		ON p.id = c.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE c.id = $1 AND (cron_u.email = $2 OR project_u.email = $2)
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresCronJobRepository(uow storage.UnitOfWork) CronJobRepository {
+	return NewPostgresCronJobRepository(uow)
+}
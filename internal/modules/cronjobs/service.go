@@ -0,0 +1,418 @@
+package cronjobs
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/web/pages/cronjobsweb"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type CronJobService struct {
+	cfg          *config.Config
+	repository   CronJobRepository
+	kuberService *services.KuberService
+}
+
+func NewCronJobService(cfg *config.Config, repository CronJobRepository, kuberService *services.KuberService) *CronJobService {
+	return &CronJobService{cfg: cfg, repository: repository, kuberService: kuberService}
+}
+
+func (s *CronJobService) toWebCronJobs(cronJobs []CronJob) []cronjobsweb.WebCronJob {
+	var webCronJobList []cronjobsweb.WebCronJob
+
+	for _, cronJob := range cronJobs {
+		webCronJobList = append(webCronJobList, cronJob.ToWebCronJob())
+	}
+
+	return webCronJobList
+}
+
+func (s *CronJobService) GetProjectCronJobs(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	cronJobs, err := s.repository.GetProjectCronJobs(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching cron jobs: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	secrets, err := s.repository.GetProjectSecrets(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webImageList []cronjobsweb.WebCronJobImage
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebCronJobImage(image))
+	}
+
+	var webDiskList []cronjobsweb.WebCronJobDisk
+	for _, disk := range disks {
+		webDiskList = append(webDiskList, disk.ToWebCronJobDisk(disk))
+	}
+
+	var webSecretList []cronjobsweb.WebCronJobSecret
+	for _, secret := range secrets {
+		webSecretList = append(webSecretList, secret.ToWebCronJobSecret(secret))
+	}
+
+	return base.Serve(cronjobsweb.CronJobs(projectId, s.toWebCronJobs(cronJobs), webImageList, webDiskList, webSecretList, s.cfg.Workspace.DefaultImage), w)
+}
+
+var (
+	errImageRequired      = errors.New("image is required")
+	errImageNotAvailable  = errors.New("image is not available")
+	errSecretNotAvailable = errors.New("secret is not available")
+)
+
+// secretsForCronJob resolves the "env:<id>" and "file:<id>" picks of the
+// form, empty picks import nothing.
+func (s *CronJobService) secretsForCronJob(projectId uuid.UUID, command CreateCronJobCommand) ([]CronJobSecret, error) {
+	projectSecrets, err := s.repository.GetProjectSecrets(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedSecrets := make(map[string]CronJobSecret)
+	for _, secret := range projectSecrets {
+		allowedSecrets[secret.ID.String()] = secret
+	}
+
+	var secrets []CronJobSecret
+	for _, pick := range command.Secrets {
+		if pick == "" {
+			continue
+		}
+		mode, secretId, _ := strings.Cut(pick, ":")
+		secret, ok := allowedSecrets[secretId]
+		if !ok || (mode != "env" && mode != "file") {
+			return nil, errSecretNotAvailable
+		}
+		secret.AsFile = mode == "file"
+		secrets = append(secrets, secret)
+		delete(allowedSecrets, secretId)
+	}
+
+	return secrets, nil
+}
+
+// imageForCronJob resolves the catalog entry picked in the form. A raw image
+// string is only accepted while the project has no catalog entries.
+func (s *CronJobService) imageForCronJob(projectId uuid.UUID, command CreateCronJobCommand) (*CronJobImage, error) {
+	images, err := s.repository.GetProjectImages(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.ImageID == "" {
+		if len(images) > 0 || command.Image == "" {
+			return nil, errImageRequired
+		}
+		return nil, nil
+	}
+
+	imageId := uuid.MustParse(command.ImageID)
+	for _, image := range images {
+		if image.ID == imageId {
+			return &image, nil
+		}
+	}
+
+	return nil, errImageNotAvailable
+}
+
+func (s *CronJobService) CreateCronJob(w http.ResponseWriter, r *http.Request, command CreateCronJobCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	ownerEmail := r.Context().Value(consts.ContextEmail).(string)
+	ownerUsername := r.Context().Value(consts.ContextUsername).(string)
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	projectDisks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	allowedDisks := make(map[uuid.UUID]CronJobDisk)
+	for _, disk := range projectDisks {
+		allowedDisks[disk.ID] = disk
+	}
+
+	var disks []CronJobDisk
+	for _, diskId := range command.DiskIDs {
+		disk, ok := allowedDisks[uuid.MustParse(diskId)]
+		if !ok {
+			return base.ErrorServe("Disk is not available", http.StatusBadRequest, w)
+		}
+		disks = append(disks, disk)
+	}
+
+	secrets, err := s.secretsForCronJob(projectId, command)
+
+	if errors.Is(err, errSecretNotAvailable) {
+		return base.ErrorServe("Secret is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	image, err := s.imageForCronJob(projectId, command)
+
+	if errors.Is(err, errImageRequired) {
+		return base.ErrorServe("Select an image", http.StatusBadRequest, w)
+	}
+	if errors.Is(err, errImageNotAvailable) {
+		return base.ErrorServe("Image is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	cronJob := CronJob{
+		ID:        uuid.New(),
+		Name:      command.Name,
+		ProjectID: projectId,
+		Owner: Owner{
+			Username: ownerUsername,
+			Email:    ownerEmail,
+		},
+		Image:             command.Image,
+		Command:           command.Command,
+		CPU:               command.CPU,
+		RAM:               command.RAM,
+		Schedule:          command.Schedule,
+		TimeZone:          command.TimeZone,
+		ConcurrencyPolicy: command.ConcurrencyPolicy,
+		SuccessfulHistory: command.SuccessfulHistory,
+		FailedHistory:     command.FailedHistory,
+		Disks:             disks,
+		Secrets:           secrets,
+		CreatedAt:         time.Now(),
+	}
+
+	if image != nil {
+		cronJob.ImageID = uuid.NullUUID{UUID: image.ID, Valid: true}
+		cronJob.Image = image.Image
+	}
+
+	err = s.repository.CreateCronJob(r.Context(), cronJob)
+
+	if err != nil {
+		log.Printf("Error while creating cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.CreateCronJob(r.Context(), cronJob.ToSpec())
+
+	if err != nil {
+		s.repository.DeleteCronJob(cronJob.ID)
+		log.Printf("Error while creating kubernetes cronjob: %s", err)
+		if apierrors.IsInvalid(err) {
+			return base.ErrorServe("Invalid schedule", http.StatusBadRequest, w)
+		}
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	cronJob, err = s.repository.GetCronJobByID(cronJob.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(cronjobsweb.CronJobCard(cronJob.ToWebCronJob()), w)
+}
+
+func (s *CronJobService) GetCronJobRuns(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	cronJobId, err := uuid.Parse(chi.URLParam(r, "cron_job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	cronJob, err := s.repository.GetCronJobByID(cronJobId)
+
+	if err != nil {
+		log.Printf("Error while fetching cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !s.repository.CanUseProject(cronJob.ProjectID, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	return base.Serve(cronjobsweb.CronJobRuns(cronJob.ID, s.runs(cronJob)), w)
+}
+
+func (s *CronJobService) runs(cronJob CronJob) []cronjobsweb.WebCronJobRun {
+	var webRunList []cronjobsweb.WebCronJobRun
+
+	for _, run := range s.kuberService.GetCronJobRuns(cronJob.GetNamespace(), cronJob.ID.String()) {
+		webRunList = append(webRunList, toWebCronJobRun(cronJob.ProjectID, run))
+	}
+
+	return webRunList
+}
+
+// RunCronJob starts a run right away. Manual runs bypass the CronJob
+// controller, so a Forbid policy is enforced here.
+func (s *CronJobService) RunCronJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	cronJobId, err := uuid.Parse(chi.URLParam(r, "cron_job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageCronJob(cronJobId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	cronJob, err := s.repository.GetCronJobByID(cronJobId)
+
+	if err != nil {
+		log.Printf("Error while fetching cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if cronJob.ConcurrencyPolicy == "Forbid" {
+		for _, run := range s.kuberService.GetCronJobRuns(cronJob.GetNamespace(), cronJob.ID.String()) {
+			if run.State.Status == services.WorkloadPending || run.State.Status == services.WorkloadRunning {
+				return base.ErrorServe("A run is still active", http.StatusBadRequest, w)
+			}
+		}
+	}
+
+	err = s.kuberService.RunCronJob(r.Context(), cronJob.GetNamespace(), cronJob.GetCronJobName())
+
+	if err != nil {
+		log.Printf("Error while running kubernetes cronjob: %s", err)
+		if apierrors.IsForbidden(err) {
+			return base.ErrorServe("Project quota exceeded", http.StatusBadRequest, w)
+		}
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(cronjobsweb.CronJobRuns(cronJob.ID, s.runs(cronJob)), w)
+}
+
+func (s *CronJobService) SetSuspended(w http.ResponseWriter, r *http.Request, command SetSuspendedCommand) http.HandlerFunc {
+	cronJobId, err := uuid.Parse(chi.URLParam(r, "cron_job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageCronJob(cronJobId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	cronJob, err := s.repository.GetCronJobByID(cronJobId)
+
+	if err != nil {
+		log.Printf("Error while fetching cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.SuspendCronJob(r.Context(), cronJob.GetNamespace(), cronJob.GetCronJobName(), command.Suspended)
+
+	if err != nil {
+		log.Printf("Error while suspending kubernetes cronjob: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.SetCronJobSuspended(cronJobId, command.Suspended)
+
+	if err != nil {
+		log.Printf("Error while updating cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	cronJob.Suspended = command.Suspended
+
+	return base.Serve(cronjobsweb.CronJobCard(cronJob.ToWebCronJob()), w)
+}
+
+func (s *CronJobService) DeleteCronJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	cronJobId, err := uuid.Parse(chi.URLParam(r, "cron_job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageCronJob(cronJobId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	cronJob, err := s.repository.GetCronJobByID(cronJobId)
+
+	if err != nil {
+		log.Printf("Error while fetching cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.DeleteCronJob(r.Context(), cronJob.GetNamespace(), cronJob.GetCronJobName())
+
+	if err != nil {
+		log.Printf("Error while deleting kubernetes cronjob: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteCronJob(cronJobId)
+
+	if err != nil {
+		log.Printf("Error while deleting cron job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func ProvideCronJobService(cfg *config.Config, repository CronJobRepository, kuberService *services.KuberService) *CronJobService {
+	return NewCronJobService(cfg, repository, kuberService)
+}
@@ -0,0 +1,24 @@
+package culler
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// CreateShutdownScheduleCommand stops the project workspaces at TimeOfDay in
+// TimeZone on the given weekdays, 0 is Sunday.
+type CreateShutdownScheduleCommand struct {
+	TimeOfDay string `validate:"required,datetime=15:04" form:"time_of_day"`
+	TimeZone  string `validate:"required,timezone" form:"time_zone"`
+	Weekdays  []int  `validate:"required,min=1,dive,gte=0,lte=6" form:"weekdays"`
+}
+
+func (c *CreateShutdownScheduleCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,66 @@
+package culler
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type CullerHandler struct {
+	cullerService *CullerService
+}
+
+func NewCullerHandler(cullerService *CullerService) *CullerHandler {
+	return &CullerHandler{cullerService: cullerService}
+}
+
+func (h *CullerHandler) GetProjectShutdownSchedules(w http.ResponseWriter, r *http.Request) {
+	handler := h.cullerService.GetProjectShutdownSchedules(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *CullerHandler) CreateShutdownSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateShutdownScheduleCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.cullerService.CreateShutdownSchedule(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *CullerHandler) DeleteShutdownSchedule(w http.ResponseWriter, r *http.Request) {
+	handler := h.cullerService.DeleteShutdownSchedule(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideCullerHandler(cullerService *CullerService) *CullerHandler {
+	return NewCullerHandler(cullerService)
+}
@@ -0,0 +1,129 @@
+package culler
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/projectsweb"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CullWorkspace is a running workspace as the culler sees it.
+type CullWorkspace struct {
+	ID             uuid.UUID  `db:"id"`
+	Name           string     `db:"name"`
+	ProjectID      uuid.UUID  `db:"project_id"`
+	ProjectName    string     `db:"project_name"`
+	OwnerID        uuid.UUID  `db:"owner_id"`
+	Command        string     `db:"command"`
+	Port           int        `db:"port"`
+	KeepRunning    bool       `db:"keep_running"`
+	LastActivityAt time.Time  `db:"last_activity_at"`
+	CullWarnedAt   *time.Time `db:"cull_warned_at"`
+}
+
+func (w *CullWorkspace) GetNamespace() string {
+	return services.ProjectNamespacePrefix + w.ProjectID.String()
+}
+
+func (w *CullWorkspace) GetPodName() string {
+	return fmt.Sprintf("workspace-%s", w.ID.String())
+}
+
+func (w *CullWorkspace) GetBaseURL() string {
+	return fmt.Sprintf("/workspaces/%s/lab/", w.ID.String())
+}
+
+// CullTensorBoard is a running disk TensorBoard, its activity is recorded
+// by the mlspace proxy.
+type CullTensorBoard struct {
+	DiskID         uuid.UUID `db:"disk_id"`
+	DiskName       string    `db:"disk_name"`
+	ProjectID      uuid.UUID `db:"project_id"`
+	ProjectName    string    `db:"project_name"`
+	OwnerID        uuid.UUID `db:"owner_id"`
+	LastActivityAt time.Time `db:"last_activity_at"`
+}
+
+func (t *CullTensorBoard) GetNamespace() string {
+	return services.ProjectNamespacePrefix + t.ProjectID.String()
+}
+
+func (t *CullTensorBoard) GetName() string {
+	return fmt.Sprintf("tensorboard-%s", t.DiskID.String())
+}
+
+// ShutdownSchedule stops the workspaces of a project once a day on the
+// weekdays set in the Weekdays bit mask, bit 0 is Sunday.
+type ShutdownSchedule struct {
+	ID           uuid.UUID  `db:"id"`
+	ProjectID    uuid.UUID  `db:"project_id"`
+	TimeOfDay    string     `db:"time_of_day"`
+	TimeZone     string     `db:"time_zone"`
+	Weekdays     int16      `db:"weekdays"`
+	LastWarnedAt *time.Time `db:"last_warned_at"`
+	LastRunAt    *time.Time `db:"last_run_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+func weekdaysMask(weekdays []int) int16 {
+	var mask int16
+	for _, day := range weekdays {
+		mask |= 1 << day
+	}
+	return mask
+}
+
+func (s *ShutdownSchedule) runsOn(day time.Weekday) bool {
+	return s.Weekdays&(1<<day) != 0
+}
+
+// occurrences returns the stop times of yesterday and today in the schedule
+// time zone, a stop shortly before midnight may only be seen after it.
+func (s *ShutdownSchedule) occurrences(now time.Time) ([]time.Time, error) {
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	clock, err := time.Parse("15:04", s.TimeOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	local := now.In(location)
+	var occurrences []time.Time
+	for _, offset := range []int{-1, 0} {
+		day := local.AddDate(0, 0, offset)
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		if s.runsOn(occurrence.Weekday()) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	return occurrences, nil
+}
+
+func (s *ShutdownSchedule) ToWebShutdownSchedule() projectsweb.WebShutdownSchedule {
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if s.runsOn(day) {
+			days = append(days, day.String()[:3])
+		}
+	}
+
+	lastRun := "never"
+	if s.LastRunAt != nil {
+		lastRun = s.LastRunAt.Format("2006-01-02 15:04")
+	}
+
+	return projectsweb.WebShutdownSchedule{
+		ID:        s.ID,
+		ProjectID: s.ProjectID,
+		TimeOfDay: s.TimeOfDay,
+		TimeZone:  s.TimeZone,
+		Weekdays:  strings.Join(days, ", "),
+		LastRun:   lastRun,
+	}
+}
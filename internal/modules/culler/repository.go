@@ -0,0 +1,227 @@
+package culler
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CullerRepository interface {
+	GetRunningWorkspaces() ([]CullWorkspace, error)
+	SetWorkspaceActivity(id uuid.UUID, at time.Time) error
+	SetWorkspaceWarned(id uuid.UUID, at time.Time) error
+	SetWorkspaceStopped(id uuid.UUID) error
+	CreateNotification(userId uuid.UUID, message string) error
+	GetTensorBoards() ([]CullTensorBoard, error)
+	DeleteTensorBoard(diskId uuid.UUID) error
+	GetShutdownSchedules() ([]ShutdownSchedule, error)
+	GetProjectShutdownSchedules(projectId uuid.UUID) ([]ShutdownSchedule, error)
+	CreateShutdownSchedule(schedule ShutdownSchedule) error
+	DeleteShutdownSchedule(projectId uuid.UUID, id uuid.UUID) error
+	SetScheduleWarned(id uuid.UUID, at time.Time) error
+	SetScheduleRun(id uuid.UUID, at time.Time) error
+	CanEditProject(projectId uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresCullerRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresCullerRepository(uow storage.UnitOfWork) *PostgresCullerRepository {
+	return &PostgresCullerRepository{uow: uow}
+}
+
+func (p *PostgresCullerRepository) GetRunningWorkspaces() ([]CullWorkspace, error) {
+	query := `
+		SELECT
+			w.id, w.name, w.project_id, p.name AS project_name, w.owner_id, w.command, w.port,
+			w.keep_running, w.last_activity_at, w.cull_warned_at
+		FROM workspaces w
+		JOIN projects p
+		ON p.id = w.project_id
+		WHERE w.state = 'running'
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []CullWorkspace
+	for rows.Next() {
+		var workspace CullWorkspace
+		if err := rows.StructScan(&workspace); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
+func (p *PostgresCullerRepository) SetWorkspaceActivity(id uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE workspaces
+		SET last_activity_at = GREATEST(last_activity_at, $2)
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, at)
+	return err
+}
+
+func (p *PostgresCullerRepository) SetWorkspaceWarned(id uuid.UUID, at time.Time) error {
+	_, err := p.uow.DB().Exec(`UPDATE workspaces SET cull_warned_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+// SetWorkspaceStopped only touches workspaces still running, the owner may
+// have stopped or deleted it meanwhile.
+func (p *PostgresCullerRepository) SetWorkspaceStopped(id uuid.UUID) error {
+	_, err := p.uow.DB().Exec(`UPDATE workspaces SET state = 'stopped' WHERE id = $1 AND state = 'running'`, id)
+	return err
+}
+
+func (p *PostgresCullerRepository) CreateNotification(userId uuid.UUID, message string) error {
+	_, err := p.uow.DB().Exec(`INSERT INTO notifications (user_id, message) VALUES ($1, $2)`, userId, message)
+	return err
+}
+
+func (p *PostgresCullerRepository) GetTensorBoards() ([]CullTensorBoard, error) {
+	query := `
+		SELECT
+			t.disk_id, d.name AS disk_name, t.project_id, p.name AS project_name, t.owner_id,
+			t.last_activity_at
+		FROM tensorboards t
+		JOIN disks d
+		ON d.id = t.disk_id
+		JOIN projects p
+		ON p.id = t.project_id
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tensorBoards []CullTensorBoard
+	for rows.Next() {
+		var tensorBoard CullTensorBoard
+		if err := rows.StructScan(&tensorBoard); err != nil {
+			return nil, err
+		}
+		tensorBoards = append(tensorBoards, tensorBoard)
+	}
+
+	return tensorBoards, nil
+}
+
+func (p *PostgresCullerRepository) DeleteTensorBoard(diskId uuid.UUID) error {
+	query := `
+		DELETE FROM tensorboards WHERE disk_id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, diskId)
+
+	return err
+}
+
+const shutdownScheduleColumns = `
+	id, project_id, time_of_day, time_zone, weekdays, last_warned_at, last_run_at, created_at
+`
+
+func (p *PostgresCullerRepository) getShutdownSchedules(query string, args ...any) ([]ShutdownSchedule, error) {
+	rows, err := p.uow.DB().Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []ShutdownSchedule
+	for rows.Next() {
+		var schedule ShutdownSchedule
+		if err := rows.StructScan(&schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+func (p *PostgresCullerRepository) GetShutdownSchedules() ([]ShutdownSchedule, error) {
+	return p.getShutdownSchedules(`SELECT ` + shutdownScheduleColumns + ` FROM shutdown_schedules`)
+}
+
+func (p *PostgresCullerRepository) GetProjectShutdownSchedules(projectId uuid.UUID) ([]ShutdownSchedule, error) {
+	query := `
+		SELECT ` + shutdownScheduleColumns + `
+		FROM shutdown_schedules
+		WHERE project_id = $1
+		ORDER BY time_of_day
+	`
+
+	return p.getShutdownSchedules(query, projectId)
+}
+
+func (p *PostgresCullerRepository) CreateShutdownSchedule(schedule ShutdownSchedule) error {
+	query := `
+		INSERT INTO shutdown_schedules (id, project_id, time_of_day, time_zone, weekdays, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := p.uow.DB().Exec(
+		query,
+		schedule.ID,
+		schedule.ProjectID,
+		schedule.TimeOfDay,
+		schedule.TimeZone,
+		schedule.Weekdays,
+		schedule.CreatedAt,
+	)
+	return err
+}
+
+func (p *PostgresCullerRepository) DeleteShutdownSchedule(projectId uuid.UUID, id uuid.UUID) error {
+	_, err := p.uow.DB().Exec(`DELETE FROM shutdown_schedules WHERE id = $1 AND project_id = $2`, id, projectId)
+	return err
+}
+
+func (p *PostgresCullerRepository) SetScheduleWarned(id uuid.UUID, at time.Time) error {
+	_, err := p.uow.DB().Exec(`UPDATE shutdown_schedules SET last_warned_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+func (p *PostgresCullerRepository) SetScheduleRun(id uuid.UUID, at time.Time) error {
+	_, err := p.uow.DB().Exec(`UPDATE shutdown_schedules SET last_run_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+func (p *PostgresCullerRepository) CanEditProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+
+	query := `
+		SELECT 1 FROM projects p
+		JOIN users u
+		ON u.id = p.owner_id
+		WHERE p.id = $1 and u.email = $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresCullerRepository(uow storage.UnitOfWork) CullerRepository {
+	return NewPostgresCullerRepository(uow)
+}
@@ -0,0 +1,358 @@
+package culler
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/services"
+	"aispace/internal/storage"
+	"aispace/web/pages/projectsweb"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// a schedule missed for longer, e.g. while mlspace was down, is skipped
+// rather than stopping workspaces hours later
+const scheduleGrace = time.Hour
+
+// CullerService stops workspaces that have been idle for too long and runs
+// the project shutdown schedules. Workspaces set to keep running are skipped
+// by the schedules, idle ones are still stopped. Idle disk TensorBoards are
+// stopped as well.
+type CullerService struct {
+	cfg          *config.Config
+	repository   CullerRepository
+	kuberService *services.KuberService
+	leader       *storage.Leader
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+func NewCullerService(cfg *config.Config, repository CullerRepository, kuberService *services.KuberService, uow storage.UnitOfWork) *CullerService {
+	return &CullerService{
+		cfg:          cfg,
+		repository:   repository,
+		kuberService: kuberService,
+		leader:       storage.NewLeader(uow, storage.LockCuller),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+func (s *CullerService) Start() {
+	go s.run()
+}
+
+func (s *CullerService) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+	fmt.Println("Culler: stopped.")
+}
+
+// run only works on the replica holding the lock, the others keep trying.
+func (s *CullerService) run() {
+	defer close(s.doneCh)
+	defer s.leader.Release()
+
+	ticker := time.NewTicker(s.cfg.Cull.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			if !s.leader.Lead(context.Background()) {
+				continue
+			}
+			if err := s.cull(context.Background(), time.Now()); err != nil {
+				log.Printf("Culler: %s", err)
+			}
+		}
+	}
+}
+
+func (s *CullerService) cull(ctx context.Context, now time.Time) error {
+	workspaces, err := s.repository.GetRunningWorkspaces()
+	if err != nil {
+		return fmt.Errorf("fetch running workspaces: %w", err)
+	}
+
+	s.cullIdle(ctx, now, workspaces)
+
+	var candidates []CullWorkspace
+	for _, workspace := range workspaces {
+		if !workspace.KeepRunning {
+			candidates = append(candidates, workspace)
+		}
+	}
+
+	tensorBoards, err := s.repository.GetTensorBoards()
+	if err != nil {
+		return fmt.Errorf("fetch tensorboards: %w", err)
+	}
+	s.cullTensorBoards(ctx, now, tensorBoards)
+
+	schedules, err := s.repository.GetShutdownSchedules()
+	if err != nil {
+		return fmt.Errorf("fetch shutdown schedules: %w", err)
+	}
+	for _, schedule := range schedules {
+		s.runSchedule(ctx, now, schedule, candidates)
+	}
+
+	return nil
+}
+
+// lastActivity asks Jupyter first. Custom commands, or a Jupyter that does
+// not answer, count as active while they use more CPU than the threshold.
+func (s *CullerService) lastActivity(ctx context.Context, now time.Time, workspace CullWorkspace, usage map[string][]services.PodUsage) (time.Time, bool) {
+	if workspace.Command == "" {
+		activity, err := s.kuberService.WorkspaceLastActivity(ctx, workspace.GetNamespace(), workspace.GetPodName(), workspace.Port, workspace.GetBaseURL(), s.kuberService.WorkspaceToken(workspace.ID.String()))
+		if err == nil {
+			return activity, true
+		}
+	}
+
+	namespace := workspace.GetNamespace()
+	if _, ok := usage[namespace]; !ok {
+		podUsage, err := s.kuberService.ListPodUsage(ctx, namespace)
+		if err != nil {
+			return time.Time{}, false
+		}
+		usage[namespace] = podUsage
+	}
+
+	for _, pod := range usage[namespace] {
+		if pod.Name == workspace.GetPodName() && pod.CPU >= s.cfg.Cull.IdleCPUMillicores {
+			return now, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (s *CullerService) cullIdle(ctx context.Context, now time.Time, workspaces []CullWorkspace) {
+	usage := make(map[string][]services.PodUsage)
+
+	for _, workspace := range workspaces {
+		// a pod still pulling its image has nothing to report yet
+		if s.kuberService.GetPodStatus(workspace.GetNamespace(), workspace.GetPodName()) != services.WorkloadRunning {
+			continue
+		}
+
+		if activity, ok := s.lastActivity(ctx, now, workspace, usage); ok && activity.After(workspace.LastActivityAt) {
+			if err := s.repository.SetWorkspaceActivity(workspace.ID, activity); err != nil {
+				log.Printf("Culler: update activity of workspace %s: %s", workspace.ID, err)
+			}
+			workspace.LastActivityAt = activity
+		}
+
+		idle := now.Sub(workspace.LastActivityAt)
+		stopAt := workspace.LastActivityAt.Add(s.cfg.Cull.IdleTimeout)
+
+		if idle >= s.cfg.Cull.IdleTimeout {
+			s.stopWorkspace(ctx, workspace, fmt.Sprintf(
+				"Workspace %s in %s was stopped after %s without activity.",
+				workspace.Name, workspace.ProjectName, idle.Round(time.Minute),
+			))
+			continue
+		}
+
+		warned := workspace.CullWarnedAt != nil && workspace.CullWarnedAt.After(workspace.LastActivityAt)
+		if idle >= s.cfg.Cull.IdleTimeout-s.cfg.Cull.Warning && !warned {
+			s.notify(workspace.OwnerID, fmt.Sprintf(
+				"Workspace %s in %s has been idle for %s and will be stopped at %s unless it is used.",
+				workspace.Name, workspace.ProjectName, idle.Round(time.Minute), stopAt.Format("15:04 MST"),
+			))
+			if err := s.repository.SetWorkspaceWarned(workspace.ID, now); err != nil {
+				log.Printf("Culler: mark workspace %s warned: %s", workspace.ID, err)
+			}
+		}
+	}
+}
+
+// cullTensorBoards deletes the row before the pod, a request arriving in
+// between finds no TensorBoard instead of a dead service.
+func (s *CullerService) cullTensorBoards(ctx context.Context, now time.Time, tensorBoards []CullTensorBoard) {
+	for _, tensorBoard := range tensorBoards {
+		idle := now.Sub(tensorBoard.LastActivityAt)
+		if idle < s.cfg.Cull.TensorBoardIdleTimeout {
+			continue
+		}
+
+		// the row stays until the pod is gone, a failed stop is retried on
+		// the next run instead of leaving a pod nobody knows about
+		if err := s.kuberService.StopTensorBoard(ctx, tensorBoard.GetNamespace(), tensorBoard.GetName()); err != nil {
+			log.Printf("Culler: stop tensorboard of disk %s: %s", tensorBoard.DiskID, err)
+			continue
+		}
+		if err := s.repository.DeleteTensorBoard(tensorBoard.DiskID); err != nil {
+			log.Printf("Culler: delete tensorboard of disk %s: %s", tensorBoard.DiskID, err)
+			continue
+		}
+		s.notify(tensorBoard.OwnerID, fmt.Sprintf(
+			"TensorBoard of disk %s in %s was stopped after %s without activity.",
+			tensorBoard.DiskName, tensorBoard.ProjectName, idle.Round(time.Minute),
+		))
+	}
+}
+
+func (s *CullerService) runSchedule(ctx context.Context, now time.Time, schedule ShutdownSchedule, workspaces []CullWorkspace) {
+	occurrences, err := schedule.occurrences(now)
+	if err != nil {
+		log.Printf("Culler: shutdown schedule %s: %s", schedule.ID, err)
+		return
+	}
+
+	var projectWorkspaces []CullWorkspace
+	for _, workspace := range workspaces {
+		if workspace.ProjectID == schedule.ProjectID {
+			projectWorkspaces = append(projectWorkspaces, workspace)
+		}
+	}
+
+	for _, occurrence := range occurrences {
+		warnFrom := occurrence.Add(-s.cfg.Cull.Warning)
+
+		if now.Before(occurrence) {
+			if now.Before(warnFrom) || (schedule.LastWarnedAt != nil && !schedule.LastWarnedAt.Before(warnFrom)) {
+				continue
+			}
+			for _, workspace := range projectWorkspaces {
+				s.notify(workspace.OwnerID, fmt.Sprintf(
+					"Workspace %s in %s will be stopped at %s by the project shutdown schedule.",
+					workspace.Name, workspace.ProjectName, occurrence.Format("15:04 MST"),
+				))
+			}
+			if err := s.repository.SetScheduleWarned(schedule.ID, now); err != nil {
+				log.Printf("Culler: mark shutdown schedule %s warned: %s", schedule.ID, err)
+			}
+			continue
+		}
+
+		if now.Sub(occurrence) > scheduleGrace || (schedule.LastRunAt != nil && !schedule.LastRunAt.Before(occurrence)) {
+			continue
+		}
+		for _, workspace := range projectWorkspaces {
+			s.stopWorkspace(ctx, workspace, fmt.Sprintf(
+				"Workspace %s in %s was stopped by the project shutdown schedule.",
+				workspace.Name, workspace.ProjectName,
+			))
+		}
+		if err := s.repository.SetScheduleRun(schedule.ID, now); err != nil {
+			log.Printf("Culler: mark shutdown schedule %s run: %s", schedule.ID, err)
+		}
+	}
+}
+
+func (s *CullerService) stopWorkspace(ctx context.Context, workspace CullWorkspace, message string) {
+	if err := s.kuberService.StopWorkspace(ctx, workspace.GetNamespace(), workspace.GetPodName()); err != nil {
+		log.Printf("Culler: stop workspace %s: %s", workspace.ID, err)
+		return
+	}
+	if err := s.repository.SetWorkspaceStopped(workspace.ID); err != nil {
+		log.Printf("Culler: update workspace %s: %s", workspace.ID, err)
+		return
+	}
+	s.notify(workspace.OwnerID, message)
+}
+
+func (s *CullerService) notify(userId uuid.UUID, message string) {
+	if err := s.repository.CreateNotification(userId, message); err != nil {
+		log.Printf("Culler: notify user %s: %s", userId, err)
+	}
+}
+
+func (s *CullerService) GetProjectShutdownSchedules(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	schedules, err := s.repository.GetProjectShutdownSchedules(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching shutdown schedules: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webScheduleList []projectsweb.WebShutdownSchedule
+
+	for _, schedule := range schedules {
+		webScheduleList = append(webScheduleList, schedule.ToWebShutdownSchedule())
+	}
+
+	return base.Serve(projectsweb.ShutdownSchedules(projectId, webScheduleList), w)
+}
+
+func (s *CullerService) CreateShutdownSchedule(w http.ResponseWriter, r *http.Request, command CreateShutdownScheduleCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	schedule := ShutdownSchedule{
+		ID:        uuid.New(),
+		ProjectID: projectId,
+		TimeOfDay: command.TimeOfDay,
+		TimeZone:  command.TimeZone,
+		Weekdays:  weekdaysMask(command.Weekdays),
+		CreatedAt: time.Now(),
+	}
+
+	err = s.repository.CreateShutdownSchedule(schedule)
+
+	if err != nil {
+		log.Printf("Error while creating shutdown schedule: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(projectsweb.ShutdownScheduleRow(schedule.ToWebShutdownSchedule()), w)
+}
+
+func (s *CullerService) DeleteShutdownSchedule(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	scheduleId, err := uuid.Parse(chi.URLParam(r, "schedule_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	err = s.repository.DeleteShutdownSchedule(projectId, scheduleId)
+
+	if err != nil {
+		log.Printf("Error while deleting shutdown schedule: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func ProvideCullerService(cfg *config.Config, repository CullerRepository, kuberService *services.KuberService, uow storage.UnitOfWork) *CullerService {
+	return NewCullerService(cfg, repository, kuberService, uow)
+}
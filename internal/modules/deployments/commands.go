@@ -0,0 +1,74 @@
+package deployments
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// CreateDeploymentCommand serves a model from a project disk. Without a
+// port the catalog port of the image is used, then DeploymentPort.
+type CreateDeploymentCommand struct {
+	Name      string `validate:"required,min=3,max=100" form:"name"`
+	ImageID   string `validate:"omitempty,uuid" form:"image_id"`
+	Image     string `validate:"max=500" form:"image"`
+	Command   string `validate:"max=4000" form:"command"`
+	Port      int    `validate:"omitempty,gte=1,lte=65535" form:"port"`
+	DiskID    string `validate:"omitempty,uuid" form:"disk_id"`
+	ModelPath string `validate:"max=500" form:"model_path"`
+	Replicas  int    `validate:"gte=1,lte=10" form:"replicas"`
+	CPU       int    `validate:"required,gte=1" form:"cpu"`
+	RAM       int    `validate:"required,gte=1" form:"ram"`
+}
+
+func (c *CreateDeploymentCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// revision picks the settings the first revision is made of.
+func (c *CreateDeploymentCommand) revision() UpdateDeploymentCommand {
+	return UpdateDeploymentCommand{
+		ImageID:   c.ImageID,
+		Image:     c.Image,
+		Command:   c.Command,
+		Port:      c.Port,
+		DiskID:    c.DiskID,
+		ModelPath: c.ModelPath,
+		CPU:       c.CPU,
+		RAM:       c.RAM,
+	}
+}
+
+// UpdateDeploymentCommand becomes a new revision and is rolled out pod by
+// pod.
+type UpdateDeploymentCommand struct {
+	ImageID   string `validate:"omitempty,uuid" form:"image_id"`
+	Image     string `validate:"max=500" form:"image"`
+	Command   string `validate:"max=4000" form:"command"`
+	Port      int    `validate:"omitempty,gte=1,lte=65535" form:"port"`
+	DiskID    string `validate:"omitempty,uuid" form:"disk_id"`
+	ModelPath string `validate:"max=500" form:"model_path"`
+	CPU       int    `validate:"required,gte=1" form:"cpu"`
+	RAM       int    `validate:"required,gte=1" form:"ram"`
+}
+
+func (c *UpdateDeploymentCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// ScaleDeploymentCommand changes the replica count, 0 stops serving but
+// keeps the deployment.
+type ScaleDeploymentCommand struct {
+	Replicas int `validate:"gte=0,lte=10" form:"replicas"`
+}
+
+func (c *ScaleDeploymentCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,149 @@
+package deployments
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type DeploymentHandler struct {
+	deploymentService *DeploymentService
+}
+
+func NewDeploymentHandler(deploymentService *DeploymentService) *DeploymentHandler {
+	return &DeploymentHandler{deploymentService: deploymentService}
+}
+
+func (h *DeploymentHandler) GetProjectDeployments(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.GetProjectDeployments(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) CreateDeployment(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateDeploymentCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.deploymentService.CreateDeployment(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DeploymentHandler) GetDeploymentStatus(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.GetDeploymentStatus(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) GetUpdateDeploymentForm(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.GetUpdateDeploymentForm(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) UpdateDeployment(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := UpdateDeploymentCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.deploymentService.UpdateDeployment(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) ScaleDeployment(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := ScaleDeploymentCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.deploymentService.ScaleDeployment(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) GetDeploymentRevisions(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.GetDeploymentRevisions(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) RollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.RollbackDeployment(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) DeleteDeployment(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.DeleteDeployment(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DeploymentHandler) ProxyDeployment(w http.ResponseWriter, r *http.Request) {
+	handler := h.deploymentService.ProxyDeployment(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideDeploymentHandler(deploymentService *DeploymentService) *DeploymentHandler {
+	return NewDeploymentHandler(deploymentService)
+}
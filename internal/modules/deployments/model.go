@@ -0,0 +1,208 @@
+package deployments
+
+import (
+	"aispace/internal/config"
+	"aispace/internal/services"
+	"aispace/web/pages/deploymentsweb"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Deployment struct {
+	ID                uuid.UUID `db:"id"`
+	Name              string    `db:"name"`
+	ProjectID         uuid.UUID `db:"project_id"`
+	Owner             Owner
+	ProjectOwnerEmail string
+	Replicas          int `db:"replicas"`
+	Requests          int64
+	Errors            int64
+	Revision          DeploymentRevision
+	CreatedAt         time.Time `db:"created_at"`
+}
+
+// DeploymentRevision is one set of serving settings. Updates and rollbacks
+// always add a revision, old ones are never changed.
+type DeploymentRevision struct {
+	Number     int           `db:"revision"`
+	ImageID    uuid.NullUUID `db:"image_id"`
+	Image      string        `db:"image"`
+	Command    string        `db:"command"`
+	Port       int           `db:"port"`
+	DiskID     uuid.NullUUID `db:"disk_id"`
+	DiskName   *string       `db:"disk_name"`
+	DiskShared bool          `db:"disk_shared"`
+	ModelPath  string        `db:"model_path"`
+	CPU        int           `db:"cpu"`
+	RAM        int           `db:"ram"`
+	CreatedBy  Owner
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type DeploymentDisk struct {
+	ID     uuid.UUID `db:"id"`
+	Name   string    `db:"name"`
+	Shared bool      `db:"shared"`
+}
+
+// DeploymentImage is a catalog entry the project offers.
+type DeploymentImage struct {
+	ID             uuid.UUID `db:"id"`
+	Name           string    `db:"name"`
+	Image          string    `db:"image"`
+	Description    string    `db:"description"`
+	DefaultCommand string    `db:"default_command"`
+	Port           *int      `db:"port"`
+	CPU            int       `db:"cpu"`
+	RAM            int       `db:"ram"`
+	Pinned         bool      `db:"pinned"`
+}
+
+type Owner struct {
+	Username string `db:"name"`
+	Email    string `db:"email"`
+}
+
+func (d *Deployment) GetNamespace() string {
+	return fmt.Sprintf("project-%s", d.ProjectID.String())
+}
+
+func (d *Deployment) GetDeploymentName() string {
+	return fmt.Sprintf("deploy-%s", d.ID.String())
+}
+
+// GetProxyPath is the mlspace route in front of the deployment service, the
+// prefix is stripped before the request reaches the model server.
+func (d *Deployment) GetProxyPath() string {
+	return fmt.Sprintf("/deployments/%s/api/", d.ID.String())
+}
+
+func (d *Deployment) GetIngressHost(cfg config.DeploymentConfig) string {
+	if cfg.IngressDomain == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", d.GetDeploymentName(), cfg.IngressDomain)
+}
+
+func (r *DeploymentRevision) GetPVCName() string {
+	if !r.DiskID.Valid {
+		return ""
+	}
+	return fmt.Sprintf("disk-%s", r.DiskID.UUID.String())
+}
+
+// cleanModelPath keeps the model path inside the mounted disk.
+func cleanModelPath(modelPath string) string {
+	return path.Clean("/" + modelPath)[1:]
+}
+
+func (d *Deployment) ToSpec(cfg config.DeploymentConfig) services.DeploymentSpec {
+	return services.DeploymentSpec{
+		ID:           d.ID.String(),
+		Name:         d.GetDeploymentName(),
+		Namespace:    d.GetNamespace(),
+		ProjectID:    d.ProjectID.String(),
+		OwnerEmail:   d.Owner.Email,
+		Image:        d.Revision.Image,
+		Command:      d.Revision.Command,
+		Port:         d.Revision.Port,
+		CPU:          d.Revision.CPU,
+		RAM:          d.Revision.RAM,
+		Replicas:     d.Replicas,
+		Revision:     d.Revision.Number,
+		ModelPVCName: d.Revision.GetPVCName(),
+		ModelPath:    d.Revision.ModelPath,
+		IngressHost:  d.GetIngressHost(cfg),
+		IngressClass: cfg.IngressClass,
+	}
+}
+
+func (r *DeploymentRevision) diskLabel() string {
+	if r.DiskName == nil {
+		return "-"
+	}
+	return *r.DiskName
+}
+
+func (d *DeploymentDisk) ToWebDeploymentDisk(disk DeploymentDisk) deploymentsweb.WebDeploymentDisk {
+	return deploymentsweb.WebDeploymentDisk{
+		ID:     disk.ID,
+		Name:   disk.Name,
+		Shared: disk.Shared,
+	}
+}
+
+func (i *DeploymentImage) ToWebDeploymentImage(image DeploymentImage) deploymentsweb.WebDeploymentImage {
+	port := ""
+	if image.Port != nil {
+		port = fmt.Sprint(*image.Port)
+	}
+
+	return deploymentsweb.WebDeploymentImage{
+		ID:             image.ID,
+		Name:           image.Name,
+		Description:    image.Description,
+		DefaultCommand: image.DefaultCommand,
+		Port:           port,
+		CPU:            image.CPU,
+		RAM:            image.RAM,
+		Pinned:         image.Pinned,
+	}
+}
+
+func toWebDeploymentStatus(id uuid.UUID, state services.DeploymentState, counts requestCounts) deploymentsweb.WebDeploymentStatus {
+	return deploymentsweb.WebDeploymentStatus{
+		ID:       id,
+		Status:   state.Status,
+		Reason:   state.Reason,
+		Ready:    state.Ready,
+		Updated:  state.Updated,
+		Replicas: state.Replicas,
+		Requests: counts.Requests,
+		Errors:   counts.Errors,
+	}
+}
+
+// ToWebDeployment marks the deployment manageable for its owner and the
+// project owner, the same rule CanManageDeployment applies.
+func (d *Deployment) ToWebDeployment(email string, ingressURL string, status deploymentsweb.WebDeploymentStatus) deploymentsweb.WebDeployment {
+	return deploymentsweb.WebDeployment{
+		ID:            d.ID,
+		Name:          d.Name,
+		ProjectID:     d.ProjectID,
+		OwnerUsername: d.Owner.Username,
+		CanManage:     email == d.Owner.Email || email == d.ProjectOwnerEmail,
+		Replicas:      d.Replicas,
+		Revision:      d.Revision.Number,
+		Image:         d.Revision.Image,
+		Command:       d.Revision.Command,
+		Port:          d.Revision.Port,
+		Disk:          d.Revision.diskLabel(),
+		ModelPath:     d.Revision.ModelPath,
+		CPU:           d.Revision.CPU,
+		RAM:           d.Revision.RAM,
+		ProxyPath:     d.GetProxyPath(),
+		IngressURL:    ingressURL,
+		CreatedAt:     d.CreatedAt.Format("2006-01-02"),
+		Status:        status,
+	}
+}
+
+func (r *DeploymentRevision) ToWebDeploymentRevision(deploymentId uuid.UUID, current int) deploymentsweb.WebDeploymentRevision {
+	return deploymentsweb.WebDeploymentRevision{
+		DeploymentID: deploymentId,
+		Number:       r.Number,
+		Image:        r.Image,
+		Port:         r.Port,
+		Disk:         r.diskLabel(),
+		ModelPath:    r.ModelPath,
+		CPU:          r.CPU,
+		RAM:          r.RAM,
+		CreatedBy:    r.CreatedBy.Username,
+		CreatedAt:    r.CreatedAt.Format("2006-01-02 15:04"),
+		Current:      r.Number == current,
+	}
+}
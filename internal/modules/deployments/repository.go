@@ -0,0 +1,412 @@
+package deployments
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type DeploymentRepository interface {
+	GetProjectDeployments(projectId uuid.UUID) ([]Deployment, error)
+	GetDeploymentByID(id uuid.UUID) (Deployment, error)
+	CreateDeployment(ctx context.Context, deployment Deployment) error
+	AddDeploymentRevision(ctx context.Context, id uuid.UUID, revision DeploymentRevision) error
+	GetDeploymentRevisions(id uuid.UUID) ([]DeploymentRevision, error)
+	GetDeploymentRevision(id uuid.UUID, number int) (DeploymentRevision, error)
+	SetDeploymentReplicas(id uuid.UUID, replicas int) error
+	AddRequestCounts(id uuid.UUID, requests int64, errors int64) error
+	DeleteDeployment(id uuid.UUID) error
+	GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]DeploymentDisk, error)
+	GetProjectImages(projectId uuid.UUID) ([]DeploymentImage, error)
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanManageDeployment(id uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresDeploymentRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresDeploymentRepository(uow storage.UnitOfWork) *PostgresDeploymentRepository {
+	return &PostgresDeploymentRepository{uow: uow}
+}
+
+const revisionColumns = `
+	r.revision, r.image_id, r.image, r.command, r.port, r.disk_id, disk.name,
+	COALESCE(disk.shared, FALSE), r.model_path, r.cpu, r.ram, r.created_at, rev_u.name, rev_u.email
+`
+
+const revisionJoins = `
+	JOIN users rev_u
+	ON rev_u.id = r.created_by
+	LEFT JOIN disks disk
+	ON disk.id = r.disk_id
+`
+
+func revisionFields(revision *DeploymentRevision) []any {
+	return []any{
+		&revision.Number,
+		&revision.ImageID,
+		&revision.Image,
+		&revision.Command,
+		&revision.Port,
+		&revision.DiskID,
+		&revision.DiskName,
+		&revision.DiskShared,
+		&revision.ModelPath,
+		&revision.CPU,
+		&revision.RAM,
+		&revision.CreatedAt,
+		&revision.CreatedBy.Username,
+		&revision.CreatedBy.Email,
+	}
+}
+
+// deploymentQuery joins the current revision of every deployment.
+const deploymentQuery = `
+	SELECT d.id, d.name, d.project_id, d.replicas, d.requests, d.errors, d.created_at, u.name, u.email, project_u.email,
+	` + revisionColumns + `
+	FROM deployments d
+	JOIN users u
+	ON u.id = d.owner_id
+	JOIN projects p
+	ON p.id = d.project_id
+	JOIN users project_u
+	ON project_u.id = p.owner_id
+	JOIN deployment_revisions r
+	ON r.deployment_id = d.id AND r.revision = d.revision
+	` + revisionJoins
+
+func scanDeployment(scanner interface{ Scan(...any) error }) (Deployment, error) {
+	var deployment Deployment
+
+	fields := []any{
+		&deployment.ID,
+		&deployment.Name,
+		&deployment.ProjectID,
+		&deployment.Replicas,
+		&deployment.Requests,
+		&deployment.Errors,
+		&deployment.CreatedAt,
+		&deployment.Owner.Username,
+		&deployment.Owner.Email,
+		&deployment.ProjectOwnerEmail,
+	}
+
+	err := scanner.Scan(append(fields, revisionFields(&deployment.Revision)...)...)
+
+	return deployment, err
+}
+
+func (p *PostgresDeploymentRepository) GetProjectDeployments(projectId uuid.UUID) ([]Deployment, error) {
+	query := deploymentQuery + `
+		WHERE d.project_id = $1
+		ORDER BY d.created_at DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deploymentList []Deployment
+
+	for rows.Next() {
+		deployment, err := scanDeployment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deploymentList = append(deploymentList, deployment)
+	}
+
+	return deploymentList, nil
+}
+
+func (p *PostgresDeploymentRepository) GetDeploymentByID(id uuid.UUID) (Deployment, error) {
+	query := deploymentQuery + `
+		WHERE d.id = $1
+	`
+
+	return scanDeployment(p.uow.DB().QueryRowx(query, id))
+}
+
+func insertRevision(ctx context.Context, tx *sql.Tx, id uuid.UUID, revision DeploymentRevision) error {
+	query := `
+		INSERT INTO deployment_revisions (
+			deployment_id, revision, image_id, image, command, port, disk_id, model_path, cpu, ram,
+			created_by, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, (SELECT id FROM users WHERE email = $11), $12)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		id,
+		revision.Number,
+		revision.ImageID,
+		revision.Image,
+		revision.Command,
+		revision.Port,
+		revision.DiskID,
+		revision.ModelPath,
+		revision.CPU,
+		revision.RAM,
+		revision.CreatedBy.Email,
+		revision.CreatedAt,
+	)
+
+	return err
+}
+
+func (p *PostgresDeploymentRepository) CreateDeployment(ctx context.Context, deployment Deployment) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO deployments (id, name, project_id, owner_id, replicas, revision, created_at, updated_at)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $6, $7, $7)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			deployment.ID,
+			deployment.Name,
+			deployment.ProjectID,
+			deployment.Owner.Email,
+			deployment.Replicas,
+			deployment.Revision.Number,
+			deployment.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return insertRevision(ctx, tx, deployment.ID, deployment.Revision)
+	})
+}
+
+// AddDeploymentRevision stores the revision and makes it the current one.
+func (p *PostgresDeploymentRepository) AddDeploymentRevision(ctx context.Context, id uuid.UUID, revision DeploymentRevision) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		err := insertRevision(ctx, tx, id, revision)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE deployments SET revision = $2, updated_at = NOW() WHERE id = $1`,
+			id,
+			revision.Number,
+		)
+
+		return err
+	})
+}
+
+func (p *PostgresDeploymentRepository) GetDeploymentRevisions(id uuid.UUID) ([]DeploymentRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM deployment_revisions r
+		` + revisionJoins + `
+		WHERE r.deployment_id = $1
+		ORDER BY r.revision DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []DeploymentRevision
+	for rows.Next() {
+		var revision DeploymentRevision
+		if err := rows.Scan(revisionFields(&revision)...); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (p *PostgresDeploymentRepository) GetDeploymentRevision(id uuid.UUID, number int) (DeploymentRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM deployment_revisions r
+		` + revisionJoins + `
+		WHERE r.deployment_id = $1 AND r.revision = $2
+	`
+
+	var revision DeploymentRevision
+
+	err := p.uow.DB().QueryRowx(query, id, number).Scan(revisionFields(&revision)...)
+
+	return revision, err
+}
+
+// AddRequestCounts adds what a replica counted since its last flush.
+func (p *PostgresDeploymentRepository) AddRequestCounts(id uuid.UUID, requests int64, errors int64) error {
+	query := `
+		UPDATE deployments
+		SET requests = requests + $2, errors = errors + $3
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, requests, errors)
+
+	return err
+}
+
+func (p *PostgresDeploymentRepository) SetDeploymentReplicas(id uuid.UUID, replicas int) error {
+	query := `
+		UPDATE deployments
+		SET replicas = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, replicas)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresDeploymentRepository) DeleteDeployment(id uuid.UUID) error {
+	query := `
+		DELETE FROM deployments WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresDeploymentRepository) GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]DeploymentDisk, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT d.id, d.name, d.shared
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		WHERE d.project_id = $1
+		AND (u.email = $2 OR d.shared)
+		AND d.provision_status = 'provisioned'
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []DeploymentDisk
+	for rows.Next() {
+		var disk DeploymentDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+// GetProjectImages returns the catalog entries the project did not hide,
+// pinned ones first.
+func (p *PostgresDeploymentRepository) GetProjectImages(projectId uuid.UUID) ([]DeploymentImage, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram,
+			COALESCE(pi.pinned, FALSE) AS pinned
+		FROM images i
+		LEFT JOIN project_images pi
+		ON pi.image_id = i.id AND pi.project_id = $1
+		WHERE NOT COALESCE(pi.hidden, FALSE)
+		ORDER BY pinned DESC, i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []DeploymentImage
+	for rows.Next() {
+		var image DeploymentImage
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresDeploymentRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanManageDeployment allows the deployment owner and the project owner.
+func (p *PostgresDeploymentRepository) CanManageDeployment(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM deployments d
+		JOIN users deployment_u
+		ON deployment_u.id = d.owner_id
+		JOIN projects p
+		ON p.id = d.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE d.id = $1 AND (deployment_u.email = $2 OR project_u.email = $2)
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresDeploymentRepository(uow storage.UnitOfWork) DeploymentRepository {
+	return NewPostgresDeploymentRepository(uow)
+}
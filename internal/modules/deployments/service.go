@@ -0,0 +1,715 @@
+package deployments
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/web/pages/deploymentsweb"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type DeploymentService struct {
+	cfg          *config.Config
+	repository   DeploymentRepository
+	kuberService *services.KuberService
+	mu           sync.Mutex
+	counts       map[uuid.UUID]*requestCounts
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+// requestCounts are the requests that went through the mlspace route, 5xx
+// answers count as errors. Every replica counts on its own and adds its
+// counts to the deployment row every requestCountFlush.
+type requestCounts struct {
+	Requests int64
+	Errors   int64
+}
+
+const requestCountFlush = 10 * time.Second
+
+func NewDeploymentService(cfg *config.Config, repository DeploymentRepository, kuberService *services.KuberService) *DeploymentService {
+	return &DeploymentService{
+		cfg:          cfg,
+		repository:   repository,
+		kuberService: kuberService,
+		counts:       make(map[uuid.UUID]*requestCounts),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+func (s *DeploymentService) Start() {
+	go s.run()
+}
+
+func (s *DeploymentService) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+	fmt.Println("Deployment request counts: stopped.")
+}
+
+func (s *DeploymentService) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(requestCountFlush)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			s.flushRequestCounts()
+			return
+		case <-ticker.C:
+			s.flushRequestCounts()
+		}
+	}
+}
+
+func (s *DeploymentService) countRequest(id uuid.UUID, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts, ok := s.counts[id]
+	if !ok {
+		counts = &requestCounts{}
+		s.counts[id] = counts
+	}
+
+	counts.Requests++
+	if status >= http.StatusInternalServerError {
+		counts.Errors++
+	}
+}
+
+// flushRequestCounts stores the counts of this replica, counts that could
+// not be stored are kept for the next flush.
+func (s *DeploymentService) flushRequestCounts() {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = make(map[uuid.UUID]*requestCounts)
+	s.mu.Unlock()
+
+	for id, count := range counts {
+		if err := s.repository.AddRequestCounts(id, count.Requests, count.Errors); err != nil {
+			log.Printf("Error while storing deployment request counts: %s", err)
+			s.mu.Lock()
+			if current, ok := s.counts[id]; ok {
+				current.Requests += count.Requests
+				current.Errors += count.Errors
+			} else {
+				s.counts[id] = count
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// requestCounts are the stored counts plus what this replica has not flushed
+// yet.
+func (s *DeploymentService) requestCounts(deployment Deployment) requestCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := requestCounts{Requests: deployment.Requests, Errors: deployment.Errors}
+	if pending, ok := s.counts[deployment.ID]; ok {
+		counts.Requests += pending.Requests
+		counts.Errors += pending.Errors
+	}
+	return counts
+}
+
+func (s *DeploymentService) status(deployment Deployment) deploymentsweb.WebDeploymentStatus {
+	state := s.kuberService.GetDeploymentState(deployment.GetNamespace(), deployment.GetDeploymentName())
+	return toWebDeploymentStatus(deployment.ID, state, s.requestCounts(deployment))
+}
+
+func (s *DeploymentService) toWebDeployment(r *http.Request, deployment Deployment) deploymentsweb.WebDeployment {
+	email := r.Context().Value(consts.ContextEmail).(string)
+
+	ingressURL := ""
+	if host := deployment.GetIngressHost(s.cfg.Deployment); host != "" {
+		ingressURL = fmt.Sprintf("http://%s/", host)
+	}
+
+	return deployment.ToWebDeployment(email, ingressURL, s.status(deployment))
+}
+
+func (s *DeploymentService) GetProjectDeployments(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployments, err := s.repository.GetProjectDeployments(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployments: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webDeploymentList []deploymentsweb.WebDeployment
+	for _, deployment := range deployments {
+		webDeploymentList = append(webDeploymentList, s.toWebDeployment(r, deployment))
+	}
+
+	return base.Serve(
+		deploymentsweb.Deployments(projectId, webDeploymentList, s.toWebImages(images), s.toWebDisks(disks), s.cfg.Workspace.DefaultImage),
+		w,
+	)
+}
+
+func (s *DeploymentService) toWebImages(images []DeploymentImage) []deploymentsweb.WebDeploymentImage {
+	var webImageList []deploymentsweb.WebDeploymentImage
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebDeploymentImage(image))
+	}
+	return webImageList
+}
+
+func (s *DeploymentService) toWebDisks(disks []DeploymentDisk) []deploymentsweb.WebDeploymentDisk {
+	var webDiskList []deploymentsweb.WebDeploymentDisk
+	for _, disk := range disks {
+		webDiskList = append(webDiskList, disk.ToWebDeploymentDisk(disk))
+	}
+	return webDiskList
+}
+
+var (
+	errImageRequired     = errors.New("image is required")
+	errImageNotAvailable = errors.New("image is not available")
+	errDiskNotAvailable  = errors.New("disk is not available")
+)
+
+// revisionFor resolves the image and disk picked in the form into a new
+// revision. A raw image string is only accepted while the project has no
+// catalog entries.
+func (s *DeploymentService) revisionFor(r *http.Request, projectId uuid.UUID, command UpdateDeploymentCommand) (DeploymentRevision, error) {
+	revision := DeploymentRevision{
+		Image:     command.Image,
+		Command:   command.Command,
+		Port:      command.Port,
+		ModelPath: cleanModelPath(command.ModelPath),
+		CPU:       command.CPU,
+		RAM:       command.RAM,
+		CreatedBy: Owner{
+			Username: r.Context().Value(consts.ContextUsername).(string),
+			Email:    r.Context().Value(consts.ContextEmail).(string),
+		},
+		CreatedAt: time.Now(),
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+	if err != nil {
+		return DeploymentRevision{}, err
+	}
+
+	if command.ImageID == "" {
+		if len(images) > 0 || command.Image == "" {
+			return DeploymentRevision{}, errImageRequired
+		}
+	} else {
+		imageId := uuid.MustParse(command.ImageID)
+		found := false
+		for _, image := range images {
+			if image.ID != imageId {
+				continue
+			}
+			found = true
+			revision.ImageID = uuid.NullUUID{UUID: image.ID, Valid: true}
+			revision.Image = image.Image
+			if revision.Port == 0 && image.Port != nil {
+				revision.Port = *image.Port
+			}
+		}
+		if !found {
+			return DeploymentRevision{}, errImageNotAvailable
+		}
+	}
+
+	if revision.Port == 0 {
+		revision.Port = services.DeploymentPort
+	}
+
+	if command.DiskID != "" {
+		disks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+		if err != nil {
+			return DeploymentRevision{}, err
+		}
+
+		diskId := uuid.MustParse(command.DiskID)
+		for _, disk := range disks {
+			if disk.ID == diskId {
+				revision.DiskID = uuid.NullUUID{UUID: disk.ID, Valid: true}
+				revision.DiskName = &disk.Name
+				revision.DiskShared = disk.Shared
+			}
+		}
+		if !revision.DiskID.Valid {
+			return DeploymentRevision{}, errDiskNotAvailable
+		}
+	}
+
+	return revision, nil
+}
+
+func revisionError(err error, w http.ResponseWriter) http.HandlerFunc {
+	switch {
+	case errors.Is(err, errImageRequired):
+		return base.ErrorServe("Select an image", http.StatusBadRequest, w)
+	case errors.Is(err, errImageNotAvailable):
+		return base.ErrorServe("Image is not available", http.StatusBadRequest, w)
+	case errors.Is(err, errDiskNotAvailable):
+		return base.ErrorServe("Disk is not available", http.StatusBadRequest, w)
+	}
+
+	log.Printf("Error while preparing deployment revision: %s", err)
+	return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+}
+
+// sharedDiskRequired guards against replicas stuck in Pending, a private
+// disk can only be attached to one node.
+func sharedDiskRequired(replicas int, revision DeploymentRevision) bool {
+	return replicas > 1 && revision.DiskID.Valid && !revision.DiskShared
+}
+
+func kuberError(err error, w http.ResponseWriter) http.HandlerFunc {
+	log.Printf("Error while applying kubernetes deployment: %s", err)
+	if apierrors.IsForbidden(err) {
+		return base.ErrorServe("Project quota exceeded", http.StatusBadRequest, w)
+	}
+	return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+}
+
+func (s *DeploymentService) CreateDeployment(w http.ResponseWriter, r *http.Request, command CreateDeploymentCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	revision, err := s.revisionFor(r, projectId, command.revision())
+
+	if err != nil {
+		return revisionError(err, w)
+	}
+
+	if sharedDiskRequired(command.Replicas, revision) {
+		return base.ErrorServe("Replicas above 1 need a shared disk", http.StatusBadRequest, w)
+	}
+
+	revision.Number = 1
+
+	deployment := Deployment{
+		ID:        uuid.New(),
+		Name:      command.Name,
+		ProjectID: projectId,
+		Owner:     revision.CreatedBy,
+		Replicas:  command.Replicas,
+		Revision:  revision,
+		CreatedAt: time.Now(),
+	}
+
+	err = s.repository.CreateDeployment(r.Context(), deployment)
+
+	if err != nil {
+		log.Printf("Error while creating deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.CreateDeployment(r.Context(), deployment.ToSpec(s.cfg.Deployment))
+
+	if err != nil {
+		s.kuberService.DeleteDeployment(r.Context(), deployment.GetNamespace(), deployment.GetDeploymentName())
+		s.repository.DeleteDeployment(deployment.ID)
+		return kuberError(err, w)
+	}
+
+	deployment, err = s.repository.GetDeploymentByID(deployment.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(deploymentsweb.DeploymentCard(s.toWebDeployment(r, deployment)), w)
+}
+
+func (s *DeploymentService) GetDeploymentStatus(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !s.repository.CanUseProject(deployment.ProjectID, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	return base.Serve(deploymentsweb.DeploymentStatus(s.status(deployment)), w)
+}
+
+func (s *DeploymentService) GetUpdateDeploymentForm(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDeployment(deploymentId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	images, err := s.repository.GetProjectImages(deployment.ProjectID)
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disks, err := s.repository.GetProjectDisks(r.Context(), deployment.ProjectID)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(
+		deploymentsweb.UpdateDeploymentForm(
+			s.toWebDeployment(r, deployment),
+			deployment.Revision.ImageID,
+			deployment.Revision.DiskID,
+			s.toWebImages(images),
+			s.toWebDisks(disks),
+		),
+		w,
+	)
+}
+
+// rollOut applies the revision to the cluster first, a rejected update
+// leaves no revision behind.
+func (s *DeploymentService) rollOut(w http.ResponseWriter, r *http.Request, deployment Deployment, revision DeploymentRevision) http.HandlerFunc {
+	if sharedDiskRequired(deployment.Replicas, revision) {
+		return base.ErrorServe("Replicas above 1 need a shared disk", http.StatusBadRequest, w)
+	}
+
+	revision.Number = deployment.Revision.Number + 1
+	deployment.Revision = revision
+
+	err := s.kuberService.UpdateDeployment(r.Context(), deployment.ToSpec(s.cfg.Deployment))
+
+	if err != nil {
+		return kuberError(err, w)
+	}
+
+	err = s.repository.AddDeploymentRevision(r.Context(), deployment.ID, revision)
+
+	if err != nil {
+		log.Printf("Error while storing deployment revision: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	deployment, err = s.repository.GetDeploymentByID(deployment.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(deploymentsweb.DeploymentCard(s.toWebDeployment(r, deployment)), w)
+}
+
+func (s *DeploymentService) UpdateDeployment(w http.ResponseWriter, r *http.Request, command UpdateDeploymentCommand) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDeployment(deploymentId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	revision, err := s.revisionFor(r, deployment.ProjectID, command)
+
+	if err != nil {
+		return revisionError(err, w)
+	}
+
+	return s.rollOut(w, r, deployment, revision)
+}
+
+// RollbackDeployment rolls out a copy of an older revision as the newest
+// one, so the history only ever grows.
+func (s *DeploymentService) RollbackDeployment(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDeployment(deploymentId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if number == deployment.Revision.Number {
+		return base.ErrorServe("Revision is already live", http.StatusBadRequest, w)
+	}
+
+	revision, err := s.repository.GetDeploymentRevision(deploymentId, number)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment revision: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	revision.CreatedBy = Owner{
+		Username: r.Context().Value(consts.ContextUsername).(string),
+		Email:    r.Context().Value(consts.ContextEmail).(string),
+	}
+	revision.CreatedAt = time.Now()
+
+	return s.rollOut(w, r, deployment, revision)
+}
+
+func (s *DeploymentService) GetDeploymentRevisions(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !s.repository.CanUseProject(deployment.ProjectID, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	revisions, err := s.repository.GetDeploymentRevisions(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment revisions: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webRevisionList []deploymentsweb.WebDeploymentRevision
+	for _, revision := range revisions {
+		webRevisionList = append(webRevisionList, revision.ToWebDeploymentRevision(deploymentId, deployment.Revision.Number))
+	}
+
+	canManage := s.toWebDeployment(r, deployment).CanManage
+
+	return base.Serve(deploymentsweb.DeploymentRevisions(webRevisionList, canManage), w)
+}
+
+func (s *DeploymentService) ScaleDeployment(w http.ResponseWriter, r *http.Request, command ScaleDeploymentCommand) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDeployment(deploymentId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if sharedDiskRequired(command.Replicas, deployment.Revision) {
+		return base.ErrorServe("Replicas above 1 need a shared disk", http.StatusBadRequest, w)
+	}
+
+	err = s.kuberService.ScaleDeployment(r.Context(), deployment.GetNamespace(), deployment.GetDeploymentName(), command.Replicas)
+
+	if err != nil {
+		return kuberError(err, w)
+	}
+
+	err = s.repository.SetDeploymentReplicas(deploymentId, command.Replicas)
+
+	if err != nil {
+		log.Printf("Error while updating deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	deployment.Replicas = command.Replicas
+
+	return base.Serve(deploymentsweb.DeploymentCard(s.toWebDeployment(r, deployment)), w)
+}
+
+func (s *DeploymentService) DeleteDeployment(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDeployment(deploymentId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while fetching deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.DeleteDeployment(r.Context(), deployment.GetNamespace(), deployment.GetDeploymentName())
+
+	if err != nil {
+		log.Printf("Error while deleting kubernetes deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteDeployment(deploymentId)
+
+	if err != nil {
+		log.Printf("Error while deleting deployment: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	s.mu.Lock()
+	delete(s.counts, deploymentId)
+	s.mu.Unlock()
+
+	return base.ServeNoSwap(w)
+}
+
+// statusRecorder keeps the status code for the request counts, Unwrap lets
+// the reverse proxy still flush streamed answers.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ProxyDeployment forwards everything under the deployment route to its
+// service with the route prefix stripped. Every project member gets through.
+func (s *DeploymentService) ProxyDeployment(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	deploymentId, err := uuid.Parse(chi.URLParam(r, "deployment_id"))
+
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+		}
+	}
+
+	deployment, err := s.repository.GetDeploymentByID(deploymentId)
+
+	if err != nil || !s.repository.CanUseProject(deployment.ProjectID, r.Context()) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+
+	state := s.kuberService.GetDeploymentState(deployment.GetNamespace(), deployment.GetDeploymentName())
+
+	if state.Ready == 0 {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Deployment has no ready replicas", http.StatusServiceUnavailable)
+		}
+	}
+
+	proxy := http.StripPrefix(
+		strings.TrimSuffix(deployment.GetProxyPath(), "/"),
+		s.kuberService.ServiceProxy(deployment.GetNamespace(), deployment.GetDeploymentName(), deployment.Revision.Port, ""),
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		proxy.ServeHTTP(recorder, r)
+		s.countRequest(deployment.ID, recorder.status)
+	}
+}
+
+func ProvideDeploymentService(cfg *config.Config, repository DeploymentRepository, kuberService *services.KuberService) *DeploymentService {
+	return NewDeploymentService(cfg, repository, kuberService)
+}
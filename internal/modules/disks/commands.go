@@ -0,0 +1,72 @@
+package disks
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+type ResizeDiskCommand struct {
+	Size int `validate:"required,gte=1" form:"disk_size"`
+}
+
+func (c *ResizeDiskCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+type CreateSnapshotCommand struct {
+	Name string `validate:"required,min=3,max=100" form:"snapshot_name"`
+}
+
+func (c *CreateSnapshotCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+type RestoreSnapshotCommand struct {
+	DiskName string `validate:"required,min=3,max=100" form:"disk_name"`
+}
+
+func (c *RestoreSnapshotCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+type CloneDiskCommand struct {
+	DiskName  string `validate:"required,min=3,max=100" form:"disk_name"`
+	ProjectID string `validate:"required,uuid" form:"project_id"`
+	Size      int    `validate:"required,gte=1" form:"disk_size"`
+}
+
+func (c *CloneDiskCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// StartTensorBoardCommand points TensorBoard at a directory of the disk,
+// empty means the disk root.
+type StartTensorBoardCommand struct {
+	LogDir string `validate:"max=500" form:"log_dir"`
+}
+
+func (c *StartTensorBoardCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// DiskFileQuery is read from the query string of the file routes, Path is
+// relative to the disk root.
+type DiskFileQuery struct {
+	Path   string `validate:"max=4096" form:"path"`
+	Inline bool   `form:"inline"`
+}
+
+func (c *DiskFileQuery) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
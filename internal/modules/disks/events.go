@@ -0,0 +1,256 @@
+package disks
+
+import (
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	EventDiskProvision  = "disk.provision"
+	EventDiskDelete     = "disk.delete"
+	EventDiskResize     = "disk.resize"
+	EventSnapshotCreate = "snapshot.create"
+)
+
+type ProvisionDiskPayload struct {
+	Namespace  string `json:"namespace"`
+	PVCName    string `json:"pvcName"`
+	Size       string `json:"size"`
+	OwnerEmail string `json:"ownerEmail"`
+	// empty means the cluster default StorageClass
+	StorageClassName string `json:"storageClassName,omitempty"`
+	Shared           bool   `json:"shared,omitempty"`
+	// set when the disk is restored from a VolumeSnapshot
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// set when the disk is cloned from another disk
+	SourceNamespace string `json:"sourceNamespace,omitempty"`
+	SourcePVCName   string `json:"sourcePvcName,omitempty"`
+}
+
+type DeleteDiskPayload struct {
+	Namespace  string    `json:"namespace"`
+	PVCName    string    `json:"pvcName"`
+	Retain     bool      `json:"retain"`
+	Name       string    `json:"name"`
+	Size       int       `json:"size"`
+	ProjectID  uuid.UUID `json:"projectId"`
+	OwnerEmail string    `json:"ownerEmail"`
+}
+
+type ResizeDiskPayload struct {
+	Namespace string `json:"namespace"`
+	PVCName   string `json:"pvcName"`
+	Size      string `json:"size"`
+	// sizes of the disk row in Gi, the previous one is restored when the
+	// claim can't be expanded
+	NewSize      int `json:"newSize"`
+	PreviousSize int `json:"previousSize"`
+}
+
+type CreateSnapshotPayload struct {
+	Namespace    string    `json:"namespace"`
+	SnapshotName string    `json:"snapshotName"`
+	PVCName      string    `json:"pvcName"`
+	ProjectID    uuid.UUID `json:"projectId"`
+	DiskID       uuid.UUID `json:"diskId"`
+	OwnerEmail   string    `json:"ownerEmail"`
+}
+
+func (s *DiskService) registerOutboxHandlers(dispatcher *outbox.Dispatcher) {
+	dispatcher.Register(EventDiskProvision, outbox.Handler{
+		Handle:    s.provisionDisk,
+		OnFailure: s.failDiskProvisioning,
+	})
+	dispatcher.Register(EventDiskDelete, outbox.Handler{
+		Handle:    s.deleteDisk,
+		OnFailure: s.failDiskDeletion,
+	})
+	dispatcher.Register(EventDiskResize, outbox.Handler{
+		Handle:    s.resizeDisk,
+		OnFailure: s.failDiskResize,
+	})
+	dispatcher.Register(EventSnapshotCreate, outbox.Handler{
+		Handle:    s.createSnapshot,
+		OnFailure: s.failSnapshotCreation,
+	})
+}
+
+// provisionDisk does nothing for a disk that is gone or being deleted by the
+// time the message is handled, the claim would be left behind.
+func (s *DiskService) provisionDisk(ctx context.Context, msg outbox.Message) error {
+	var payload ProvisionDiskPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	status, err := s.repository.GetProvisionStatus(msg.AggregateID)
+	if errors.Is(err, sql.ErrNoRows) || status == outbox.ProvisionDeleting {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fetch provision status: %w", err)
+	}
+
+	storage := services.PVCStorage{
+		ClassName: payload.StorageClassName,
+		Shared:    payload.Shared,
+	}
+
+	switch {
+	case payload.SnapshotName != "":
+		_, err = s.kuberService.CreatePVCFromSnapshot(ctx, payload.Namespace, payload.PVCName, payload.Size, payload.OwnerEmail, storage, payload.SnapshotName)
+	case payload.SourcePVCName != "":
+		_, err = s.kuberService.CreatePVCFromPVC(ctx, payload.Namespace, payload.PVCName, payload.Size, payload.OwnerEmail, storage, payload.SourceNamespace, payload.SourcePVCName)
+	default:
+		_, err = s.kuberService.CreatePVC(ctx, payload.Namespace, payload.PVCName, payload.Size, payload.OwnerEmail, storage)
+	}
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return s.repository.SetProvisionStatus(msg.AggregateID, outbox.ProvisionProvisioned)
+}
+
+func (s *DiskService) failDiskProvisioning(ctx context.Context, msg outbox.Message, err error) error {
+	log.Printf("Disk %s provisioning failed: %s", msg.AggregateID, err)
+
+	var payload ProvisionDiskPayload
+	if err := msg.Decode(&payload); err == nil && payload.SourceNamespace != "" && payload.SourceNamespace != payload.Namespace {
+		if err := s.kuberService.ReleaseCloneReferenceGrant(ctx, payload.Namespace, payload.SourceNamespace, payload.SourcePVCName); err != nil {
+			log.Printf("Error while deleting reference grant: %s", err)
+		}
+	}
+
+	return s.repository.SetProvisionStatus(msg.AggregateID, outbox.ProvisionFailed)
+}
+
+// deleteDisk is retried by the dispatcher until the claim is really gone, a
+// terminating claim keeps the disk row in the deleting state.
+func (s *DiskService) deleteDisk(ctx context.Context, msg outbox.Message) error {
+	var payload DeleteDiskPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	exists, err := s.kuberService.PVCExists(ctx, payload.Namespace, payload.PVCName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if payload.Retain {
+			pvName, err := s.kuberService.RetainPVCVolume(ctx, payload.Namespace, payload.PVCName)
+			if err != nil {
+				return err
+			}
+			if pvName != "" {
+				disk := Disk{
+					ID:      msg.AggregateID,
+					Name:    payload.Name,
+					Size:    payload.Size,
+					Owner:   Owner{Email: payload.OwnerEmail},
+					Project: DiskProject{ID: payload.ProjectID},
+				}
+				if err := s.repository.RecordRetainedVolume(disk, pvName); err != nil {
+					return fmt.Errorf("record retained volume: %w", err)
+				}
+			}
+		}
+
+		if err := s.kuberService.DeletePVC(ctx, payload.Namespace, payload.PVCName); err != nil {
+			return err
+		}
+
+		exists, err = s.kuberService.PVCExists(ctx, payload.Namespace, payload.PVCName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("pvc %s/%s is still terminating", payload.Namespace, payload.PVCName)
+		}
+	}
+
+	return s.repository.DeleteDisk(msg.AggregateID)
+}
+
+// failDiskDeletion keeps a disk whose claim is already terminating in the
+// deleting state and starts waiting for it again, only a claim that could not
+// be deleted at all marks the disk as failed.
+func (s *DiskService) failDiskDeletion(ctx context.Context, msg outbox.Message, err error) error {
+	var payload DeleteDiskPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	terminating, checkErr := s.kuberService.PVCTerminating(ctx, payload.Namespace, payload.PVCName)
+	if checkErr == nil && terminating {
+		log.Printf("Disk %s claim is still terminating, waiting again", msg.AggregateID)
+
+		// the volume was retained before the claim was deleted
+		payload.Retain = false
+		retry, err := outbox.NewMessage(EventDiskDelete, msg.AggregateID, payload)
+		if err != nil {
+			return err
+		}
+		return s.repository.MarkDiskDeleting(ctx, msg.AggregateID, retry)
+	}
+
+	log.Printf("Disk %s deletion failed: %s", msg.AggregateID, err)
+	return s.repository.SetProvisionStatus(msg.AggregateID, outbox.ProvisionFailed)
+}
+
+func (s *DiskService) resizeDisk(ctx context.Context, msg outbox.Message) error {
+	var payload ResizeDiskPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	return s.kuberService.ExpandPVC(ctx, payload.Namespace, payload.PVCName, payload.Size)
+}
+
+// failDiskResize restores the size of the row, the claim kept the old one.
+// Messages enqueued before the sizes were part of the payload are only logged.
+func (s *DiskService) failDiskResize(ctx context.Context, msg outbox.Message, err error) error {
+	log.Printf("Disk %s resize failed: %s", msg.AggregateID, err)
+
+	var payload ResizeDiskPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	if payload.PreviousSize == 0 {
+		return nil
+	}
+
+	return s.repository.RevertDiskSize(msg.AggregateID, payload.NewSize, payload.PreviousSize)
+}
+
+func (s *DiskService) createSnapshot(ctx context.Context, msg outbox.Message) error {
+	var payload CreateSnapshotPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	return s.kuberService.CreateVolumeSnapshot(ctx, services.SnapshotSpec{
+		ID:         msg.AggregateID.String(),
+		Name:       payload.SnapshotName,
+		Namespace:  payload.Namespace,
+		ProjectID:  payload.ProjectID.String(),
+		DiskID:     payload.DiskID.String(),
+		PVCName:    payload.PVCName,
+		OwnerEmail: payload.OwnerEmail,
+	})
+}
+
+func (s *DiskService) failSnapshotCreation(ctx context.Context, msg outbox.Message, err error) error {
+	log.Printf("Snapshot %s creation failed: %s", msg.AggregateID, err)
+	return s.repository.SetSnapshotStatus(msg.AggregateID, SnapshotFailed)
+}
@@ -0,0 +1,333 @@
+package disks
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/consts"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/web/pages/disksweb"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// text and CSV previews read at most this much of a file
+	previewTextLimit = 64 << 10
+	previewImageSize = 10 << 20
+	previewCSVRows   = 100
+)
+
+// previewImageTypes are served inline, anything else is downloaded as an
+// attachment so no uploaded HTML or SVG runs under the mlspace origin.
+var previewImageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// uploadReader keeps the error of the request body. The exec stream only
+// sees the end of its input, a cut off upload would otherwise look complete.
+type uploadReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		u.err = err
+	}
+	return n, err
+}
+
+func (s *DiskService) GetDisk(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	disk, handler := s.usableDisk(w, r)
+	if handler != nil {
+		return handler
+	}
+
+	status, err := s.diskStatus(r.Context(), disk)
+	if err != nil {
+		log.Printf("Error while fetching disk status: %s", err)
+	}
+	disk.Status = status
+
+	ready := disk.ProvisionStatus == outbox.ProvisionProvisioned
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(disksweb.DiskPagePartial(disk.ToWebDisk(), ready), w)
+	}
+	return base.Serve(disksweb.DiskPageFull(disk.ToWebDisk(), ready), w)
+}
+
+// diskBrowser returns the disk with a running helper pod mounting it. While
+// the pod is starting the request is answered with starting, or a 503 when
+// starting is nil.
+func (s *DiskService) diskBrowser(w http.ResponseWriter, r *http.Request, starting func(disk Disk) http.HandlerFunc) (Disk, string, http.HandlerFunc) {
+	disk, handler := s.usableDisk(w, r)
+	if handler != nil {
+		return Disk{}, "", handler
+	}
+
+	if disk.ProvisionStatus != outbox.ProvisionProvisioned {
+		return Disk{}, "", base.ErrorServe("Disk is not ready", http.StatusBadRequest, w)
+	}
+
+	email := r.Context().Value(consts.ContextEmail).(string)
+	podName, err := s.kuberService.EnsureDiskBrowser(r.Context(), disk.GetDiskBrowserSpec(s.cfg, email))
+
+	switch {
+	case errors.Is(err, services.ErrDiskBrowserStarting) && starting != nil:
+		return Disk{}, "", starting(disk)
+	case errors.Is(err, services.ErrDiskBrowserStarting):
+		return Disk{}, "", base.ErrorServe("File browser is starting, try again in a moment", http.StatusServiceUnavailable, w)
+	case errors.Is(err, services.ErrDiskAttachedElsewhere):
+		return Disk{}, "", base.ErrorServe("Disk is attached to another node, stop the workloads using it there to browse its files", http.StatusConflict, w)
+	case err != nil:
+		log.Printf("Error while starting disk browser: %s", err)
+		return Disk{}, "", base.ErrorServe("File browser could not be started", http.StatusInternalServerError, w)
+	}
+
+	return disk, podName, nil
+}
+
+func (s *DiskService) serveDiskFiles(w http.ResponseWriter, r *http.Request, disk Disk, podName, dir string) http.HandlerFunc {
+	files, err := s.kuberService.ListDiskFiles(r.Context(), disk.GetNamespace(), podName, dir)
+
+	if err != nil {
+		log.Printf("Error while listing disk files: %s", err)
+		return base.ErrorServe("Directory can't be read", http.StatusBadRequest, w)
+	}
+
+	return base.Serve(disksweb.DiskFiles(toWebDiskFiles(disk, dir, files)), w)
+}
+
+func (s *DiskService) GetDiskFiles(w http.ResponseWriter, r *http.Request, query DiskFileQuery) http.HandlerFunc {
+	dir := cleanDiskPath(query.Path)
+
+	disk, podName, handler := s.diskBrowser(w, r, func(disk Disk) http.HandlerFunc {
+		return base.Serve(disksweb.DiskFilesStarting(disk.ID, dir), w)
+	})
+	if handler != nil {
+		return handler
+	}
+
+	return s.serveDiskFiles(w, r, disk, podName, dir)
+}
+
+// PreviewDiskFile shows images inline, text as is and CSV as a table. Only
+// the start of text files is read.
+func (s *DiskService) PreviewDiskFile(w http.ResponseWriter, r *http.Request, query DiskFileQuery) http.HandlerFunc {
+	disk, podName, handler := s.diskBrowser(w, r, nil)
+	if handler != nil {
+		return handler
+	}
+
+	file, err := s.kuberService.StatDiskFile(r.Context(), disk.GetNamespace(), podName, cleanDiskPath(query.Path))
+
+	if err != nil || file.Dir || file.Path == "" {
+		return base.ErrorServe("File can't be read", http.StatusBadRequest, w)
+	}
+
+	preview := disksweb.WebDiskFilePreview{
+		DiskID: disk.ID,
+		File:   toWebDiskFile(file),
+	}
+
+	extension := strings.ToLower(path.Ext(file.Name))
+
+	if _, ok := previewImageTypes[extension]; ok {
+		preview.Kind = "image"
+		if file.Size > previewImageSize {
+			preview.Kind = "large"
+		}
+		return base.Serve(disksweb.DiskFilePreview(preview), w)
+	}
+
+	var content bytes.Buffer
+	err = s.kuberService.ReadDiskFile(r.Context(), disk.GetNamespace(), podName, file.Path, previewTextLimit, &content)
+
+	if err != nil {
+		log.Printf("Error while reading disk file: %s", err)
+		return base.ErrorServe("File can't be read", http.StatusBadRequest, w)
+	}
+
+	preview.Truncated = file.Size > int64(content.Len())
+	text := content.Bytes()
+	if preview.Truncated {
+		// the limit may have cut a multi byte character
+		for i := 0; i < utf8.UTFMax && len(text) > 0 && !utf8.Valid(text); i++ {
+			text = text[:len(text)-1]
+		}
+	}
+
+	switch {
+	case bytes.IndexByte(text, 0) >= 0 || !utf8.Valid(text):
+		preview.Kind = "binary"
+		preview.Truncated = false
+	case extension == ".csv" || extension == ".tsv":
+		preview.Kind = "csv"
+		preview.Rows = previewCSV(text, extension == ".tsv", preview.Truncated)
+	default:
+		preview.Kind = "text"
+		preview.Text = string(text)
+	}
+
+	return base.Serve(disksweb.DiskFilePreview(preview), w)
+}
+
+// previewCSV parses what was read, a truncated last line is dropped. Rows
+// that don't parse end the preview.
+func previewCSV(text []byte, tabs bool, truncated bool) [][]string {
+	if truncated {
+		if i := bytes.LastIndexByte(text, '\n'); i >= 0 {
+			text = text[:i]
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if tabs {
+		reader.Comma = '\t'
+	}
+
+	var rows [][]string
+	for len(rows) < previewCSVRows {
+		row, err := reader.Read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func (s *DiskService) DownloadDiskFile(w http.ResponseWriter, r *http.Request, query DiskFileQuery) http.HandlerFunc {
+	disk, podName, handler := s.diskBrowser(w, r, nil)
+	if handler != nil {
+		return handler
+	}
+
+	file, err := s.kuberService.StatDiskFile(r.Context(), disk.GetNamespace(), podName, cleanDiskPath(query.Path))
+
+	if err != nil || file.Dir || file.Path == "" {
+		return base.ErrorServe("File can't be read", http.StatusBadRequest, w)
+	}
+
+	contentType, image := previewImageTypes[strings.ToLower(path.Ext(file.Name))]
+	disposition := "attachment"
+	if query.Inline && image {
+		disposition = "inline"
+	} else {
+		contentType = "application/octet-stream"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}))
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		err := s.kuberService.ReadDiskFile(r.Context(), disk.GetNamespace(), podName, file.Path, 0, w)
+		if err != nil {
+			log.Printf("Error while downloading disk file: %s", err)
+		}
+	}
+}
+
+// UploadDiskFiles streams every file of the multipart body into the
+// directory, existing files are replaced. The body is not buffered, files
+// only land on the disk.
+func (s *DiskService) UploadDiskFiles(w http.ResponseWriter, r *http.Request, query DiskFileQuery) http.HandlerFunc {
+	disk, podName, handler := s.diskBrowser(w, r, nil)
+	if handler != nil {
+		return handler
+	}
+
+	dir := cleanDiskPath(query.Path)
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.DiskBrowser.MaxUploadSize<<20)
+
+	reader, err := r.MultipartReader()
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return base.ErrorServe("Upload is too large", http.StatusRequestEntityTooLarge, w)
+		}
+		if err != nil {
+			return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+		}
+
+		// browsers send the bare name, anything else is cut down to it
+		name := path.Base(cleanDiskPath(part.FileName()))
+		if name == "." {
+			continue
+		}
+
+		file := path.Join(dir, name)
+		upload := &uploadReader{reader: part}
+		err = s.kuberService.WriteDiskFile(r.Context(), disk.GetNamespace(), podName, file, upload)
+
+		if upload.err != nil {
+			if err := s.kuberService.DeleteDiskFile(r.Context(), disk.GetNamespace(), podName, file); err != nil {
+				log.Printf("Error while deleting partial upload: %s", err)
+			}
+			if errors.As(upload.err, &maxBytesErr) {
+				return base.ErrorServe("Upload is too large", http.StatusRequestEntityTooLarge, w)
+			}
+			return base.ErrorServe("Upload was interrupted", http.StatusBadRequest, w)
+		}
+		if err != nil {
+			log.Printf("Error while uploading disk file: %s", err)
+			return base.ErrorServe("File could not be written", http.StatusInternalServerError, w)
+		}
+	}
+
+	return s.serveDiskFiles(w, r, disk, podName, dir)
+}
+
+// DeleteDiskFile removes a file or a directory with everything in it, the
+// disk root can't be deleted.
+func (s *DiskService) DeleteDiskFile(w http.ResponseWriter, r *http.Request, query DiskFileQuery) http.HandlerFunc {
+	file := cleanDiskPath(query.Path)
+
+	if file == "" {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	disk, podName, handler := s.diskBrowser(w, r, nil)
+	if handler != nil {
+		return handler
+	}
+
+	err := s.kuberService.DeleteDiskFile(r.Context(), disk.GetNamespace(), podName, file)
+
+	if err != nil {
+		log.Printf("Error while deleting disk file: %s", err)
+		return base.ErrorServe("File could not be deleted", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
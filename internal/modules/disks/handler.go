@@ -1,6 +1,32 @@
 package disks
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+func decodeDiskFileQuery(w http.ResponseWriter, r *http.Request) (DiskFileQuery, bool) {
+	query := DiskFileQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return query, false
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return query, false
+	}
+
+	return query, true
+}
 
 type DiskHandler struct {
 	diskService *DiskService
@@ -24,6 +50,20 @@ func (h *DiskHandler) GetProjectsForDisk(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (h *DiskHandler) StreamDiskStatus(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.StreamDiskStatus(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) GetStorageClassesForDisk(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetStorageClassesForDisk(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
 func (h *DiskHandler) CreateDisk(w http.ResponseWriter, r *http.Request) {
 	handler := h.diskService.CreateDisk(w, r)
 	if handler != nil {
@@ -45,6 +85,257 @@ func (h *DiskHandler) GetDiskStatus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *DiskHandler) ResizeDisk(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := ResizeDiskCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.diskService.ResizeDisk(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DiskHandler) GetDiskSnapshots(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetDiskSnapshots(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateSnapshotCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.diskService.CreateSnapshot(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DiskHandler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := RestoreSnapshotCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.diskService.RestoreSnapshot(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DiskHandler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.DeleteSnapshot(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) GetCloneDiskForm(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetCloneDiskForm(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) CloneDisk(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CloneDiskCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.diskService.CloneDisk(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DiskHandler) GetRetainedVolumes(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetRetainedVolumes(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) GetTensorBoard(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetTensorBoard(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) StartTensorBoard(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := StartTensorBoardCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.diskService.StartTensorBoard(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *DiskHandler) StopTensorBoard(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.StopTensorBoard(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) ProxyTensorBoard(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.ProxyTensorBoard(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) GetDisk(w http.ResponseWriter, r *http.Request) {
+	handler := h.diskService.GetDisk(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) GetDiskFiles(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodeDiskFileQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.diskService.GetDiskFiles(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) PreviewDiskFile(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodeDiskFileQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.diskService.PreviewDiskFile(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) DownloadDiskFile(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodeDiskFileQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.diskService.DownloadDiskFile(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) UploadDiskFiles(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodeDiskFileQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.diskService.UploadDiskFiles(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *DiskHandler) DeleteDiskFile(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodeDiskFileQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.diskService.DeleteDiskFile(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
 func ProvideDiskHandler(diskService *DiskService) *DiskHandler {
 	return NewDiskHandler(diskService)
 }
@@ -1,24 +1,85 @@
 package disks
 
 import (
+	"aispace/internal/config"
 	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
 	"aispace/web/pages/disksweb"
 	"fmt"
-	"strconv"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Disk struct {
-	ID        uuid.UUID `db:"id"`
-	Name      string    `db:"name"`
-	Status    services.PVCStatus
-	Owner     Owner
-	Size      int  `db:"size"`
-	Shared    bool `db:"shared"`
-	Project   DiskProject
-	CreatedAt time.Time `db:"created_at"`
+	ID              uuid.UUID `db:"id"`
+	Name            string    `db:"name"`
+	Status          services.PVCStatus
+	Conditions      []string
+	Reason          string
+	Owner           Owner
+	Size            int  `db:"size"`
+	Shared          bool `db:"shared"`
+	Project         DiskProject
+	StorageClass    DiskStorageClass
+	ProvisionStatus string    `db:"provision_status"`
+	CreatedAt       time.Time `db:"created_at"`
+	// the snapshot a restored disk was provisioned from
+	SourceSnapshotID uuid.NullUUID `db:"source_snapshot_id"`
+}
+
+// DiskStorageClass is the catalog entry a disk was created with. ID is null
+// for disks that live on the cluster default StorageClass.
+type DiskStorageClass struct {
+	ID          uuid.NullUUID `db:"id"`
+	Name        string        `db:"name"`
+	ClassName   string        `db:"class_name"`
+	AllowShared bool          `db:"allow_shared"`
+}
+
+// RetainedVolume is a PV kept after its disk was deleted with the retain
+// option, the data can be recovered by binding a new claim to it.
+type RetainedVolume struct {
+	ID          uuid.UUID `db:"id"`
+	DiskID      uuid.UUID `db:"disk_id"`
+	DiskName    string    `db:"disk_name"`
+	ProjectName *string   `db:"project_name"`
+	OwnerEmail  string    `db:"email"`
+	PVName      string    `db:"pv_name"`
+	Size        int       `db:"size"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+const (
+	SnapshotPending = "pending"
+	SnapshotReady   = "ready"
+	SnapshotFailed  = "failed"
+)
+
+type Snapshot struct {
+	ID           uuid.UUID     `db:"id"`
+	Name         string        `db:"name"`
+	DiskID       uuid.NullUUID `db:"disk_id"`
+	DiskName     string        `db:"disk_name"`
+	ProjectID    uuid.UUID     `db:"project_id"`
+	Owner        Owner
+	StorageClass DiskStorageClass
+	Size         int       `db:"size"`
+	Shared       bool      `db:"shared"`
+	Status       string    `db:"status"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// TensorBoard is the TensorBoard pod running for a disk, there is at most
+// one per disk.
+type TensorBoard struct {
+	DiskID         uuid.UUID `db:"disk_id"`
+	LogDir         string    `db:"log_dir"`
+	Owner          Owner
+	StartedAt      time.Time `db:"started_at"`
+	LastActivityAt time.Time `db:"last_activity_at"`
 }
 
 type DiskProject struct {
@@ -41,8 +102,70 @@ func (d *Disk) GetPVCName() string {
 	return fmt.Sprintf("disk-%s", d.ID.String())
 }
 
+func (d *Disk) GetTensorBoardName() string {
+	return fmt.Sprintf("tensorboard-%s", d.ID.String())
+}
+
+func (d *Disk) GetTensorBoardBaseURL() string {
+	return fmt.Sprintf("/disks/%s/tensorboard/", d.ID.String())
+}
+
+func (d *Disk) GetTensorBoardSpec(image string, tensorBoard TensorBoard) services.TensorBoardSpec {
+	return services.TensorBoardSpec{
+		DiskID:     d.ID.String(),
+		Name:       d.GetTensorBoardName(),
+		Namespace:  d.GetNamespace(),
+		ProjectID:  d.Project.ID.String(),
+		OwnerEmail: tensorBoard.Owner.Email,
+		Image:      image,
+		PVCName:    d.GetPVCName(),
+		LogDir:     tensorBoard.LogDir,
+		BaseURL:    d.GetTensorBoardBaseURL(),
+	}
+}
+
+func (d *Disk) GetDiskBrowserSpec(cfg *config.Config, email string) services.DiskBrowserSpec {
+	return services.DiskBrowserSpec{
+		DiskID:     d.ID.String(),
+		Namespace:  d.GetNamespace(),
+		ProjectID:  d.Project.ID.String(),
+		OwnerEmail: email,
+		Image:      cfg.DiskBrowser.Image,
+		PVCName:    d.GetPVCName(),
+		Lifetime:   cfg.DiskBrowser.Lifetime,
+	}
+}
+
+// cleanDiskPath makes a user supplied path relative to the disk root, it
+// can't point above it. The root itself is the empty string.
+func cleanDiskPath(file string) string {
+	return strings.TrimPrefix(path.Clean("/"+file), "/")
+}
+
 func (d *Disk) GetPVCSize() string {
-	return strconv.Itoa(d.Size)
+	return fmt.Sprintf("%dGi", d.Size)
+}
+
+func (d *Disk) GetPVCStorage() services.PVCStorage {
+	return services.PVCStorage{
+		ClassName: d.StorageClass.ClassName,
+		Shared:    d.Shared,
+	}
+}
+
+func (c *DiskStorageClass) DisplayName() string {
+	if !c.ID.Valid {
+		return "Cluster default"
+	}
+	return c.Name
+}
+
+func (c *DiskStorageClass) ToWebDiskStorageClass() disksweb.WebDiskStorageClass {
+	return disksweb.WebDiskStorageClass{
+		ID:          c.ID.UUID,
+		Name:        c.Name,
+		AllowShared: c.AllowShared,
+	}
 }
 
 type Owner struct {
@@ -55,12 +178,112 @@ func (d *Disk) ToWebDisk() disksweb.WebDisk {
 		ID:            d.ID,
 		Name:          d.Name,
 		Status:        d.Status.String(),
+		Conditions:    d.Conditions,
+		Reason:        d.Reason,
 		OwnerUsername: d.Owner.Username,
 		OwnerEmail:    d.Owner.Email,
 		Size:          d.Size,
 		Shared:        d.Shared,
+		StorageClass:  d.StorageClass.DisplayName(),
 		Project:       d.Project.ToWebDiskProject(d.Project),
 		CreatedAt:     d.CreatedAt.Format("2006-01-02"),
 	}
 
 }
+
+func (v *RetainedVolume) ToWebRetainedVolume() adminweb.WebRetainedVolume {
+	projectName := "deleted project"
+	if v.ProjectName != nil {
+		projectName = *v.ProjectName
+	}
+
+	return adminweb.WebRetainedVolume{
+		DiskName:    v.DiskName,
+		ProjectName: projectName,
+		OwnerEmail:  v.OwnerEmail,
+		PVName:      v.PVName,
+		Size:        v.Size,
+		CreatedAt:   v.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func (s *Snapshot) GetNamespace() string {
+	return fmt.Sprintf("project-%s", s.ProjectID.String())
+}
+
+func (s *Snapshot) GetSnapshotName() string {
+	return fmt.Sprintf("snapshot-%s", s.ID.String())
+}
+
+func (s *Snapshot) ToWebSnapshot() disksweb.WebSnapshot {
+	return disksweb.WebSnapshot{
+		ID:            s.ID,
+		Name:          s.Name,
+		DiskName:      s.DiskName,
+		OwnerUsername: s.Owner.Username,
+		OwnerEmail:    s.Owner.Email,
+		Size:          s.Size,
+		Status:        s.Status,
+		CreatedAt:     s.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func (t *TensorBoard) ToWebTensorBoard(d Disk, status string) disksweb.WebTensorBoard {
+	logDir := "/" + t.LogDir
+
+	return disksweb.WebTensorBoard{
+		DiskID:        d.ID,
+		LogDir:        logDir,
+		URL:           d.GetTensorBoardBaseURL(),
+		OwnerUsername: t.Owner.Username,
+		Status:        status,
+		StartedAt:     t.StartedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func formatFileSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func toWebDiskFile(file services.DiskFile) disksweb.WebDiskFile {
+	return disksweb.WebDiskFile{
+		Name:       file.Name,
+		Path:       file.Path,
+		Dir:        file.Dir,
+		Size:       formatFileSize(file.Size),
+		ModifiedAt: file.ModifiedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+// toWebDiskFiles adds the breadcrumbs from the disk root down to dir.
+func toWebDiskFiles(d Disk, dir string, files []services.DiskFile) disksweb.WebDiskFiles {
+	webFiles := disksweb.WebDiskFiles{
+		DiskID:   d.ID,
+		DiskName: d.Name,
+		Path:     dir,
+	}
+
+	if dir != "" {
+		var parent string
+		for _, part := range strings.Split(dir, "/") {
+			parent = path.Join(parent, part)
+			webFiles.Crumbs = append(webFiles.Crumbs, disksweb.WebDiskFile{Name: part, Path: parent, Dir: true})
+		}
+	}
+
+	for _, file := range files {
+		webFiles.Files = append(webFiles.Files, toWebDiskFile(file))
+	}
+
+	return webFiles
+}
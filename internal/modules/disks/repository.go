@@ -2,19 +2,50 @@ package disks
 
 import (
 	"aispace/internal/consts"
+	"aispace/internal/outbox"
 	"aispace/internal/storage"
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/google/uuid"
 )
 
+// ErrStorageLimitExceeded is returned when a new or grown disk would take the
+// project above its storage limit.
+var ErrStorageLimitExceeded = errors.New("project storage limit exceeded")
+
 type DiskRepository interface {
-	CreateDisk(disk Disk) error
+	CreateDisk(ctx context.Context, disk Disk, msg outbox.Message) error
+	SetProvisionStatus(id uuid.UUID, status string) error
+	GetProvisionStatus(id uuid.UUID) (string, error)
+	MarkDiskDeleting(ctx context.Context, id uuid.UUID, msg outbox.Message) error
+	RecordRetainedVolume(disk Disk, pvName string) error
+	GetRetainedVolumes() ([]RetainedVolume, error)
+	ResizeDisk(ctx context.Context, id uuid.UUID, size int, msg outbox.Message) error
+	RevertDiskSize(id uuid.UUID, size int, previousSize int) error
+	CreateSnapshot(ctx context.Context, snapshot Snapshot, msg outbox.Message) error
+	GetDiskSnapshots(diskId uuid.UUID) ([]Snapshot, error)
+	GetSnapshotByID(id uuid.UUID) (Snapshot, error)
+	SetSnapshotStatus(id uuid.UUID, status string) error
+	FailMissingSnapshot(id uuid.UUID) error
+	GetSnapshotRestores(snapshotId uuid.UUID) ([]Disk, error)
+	DeleteSnapshot(id uuid.UUID) error
+	CanManageSnapshot(id uuid.UUID, ctx context.Context) bool
+	CanUseDisk(id uuid.UUID, ctx context.Context) bool
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
 	DeleteDisk(id uuid.UUID) error
+	CanManageDisk(id uuid.UUID, ctx context.Context) bool
 	GetDisks(ctx context.Context) ([]Disk, error)
 	GetDiskByID(id uuid.UUID) (Disk, error)
 	GetProjectsByName(ctx context.Context, name string) ([]DiskProject, error)
 	GetProjectNameByID(id uuid.UUID) (string, error)
+	GetStorageClasses() ([]DiskStorageClass, error)
+	GetStorageClassByID(id uuid.UUID) (DiskStorageClass, error)
+	GetTensorBoard(diskId uuid.UUID) (TensorBoard, error)
+	CreateTensorBoard(ctx context.Context, tensorBoard TensorBoard) error
+	TouchTensorBoard(diskId uuid.UUID) error
+	DeleteTensorBoard(diskId uuid.UUID) error
 }
 
 type PostgresDiskRepository struct {
@@ -28,12 +59,15 @@ func NewPostgresDiskRepository(uow storage.UnitOfWork) *PostgresDiskRepository {
 func (p *PostgresDiskRepository) GetDisks(ctx context.Context) ([]Disk, error) {
 	email := ctx.Value(consts.ContextEmail).(string)
 	query := `
-		SELECT d.id, d.name, u.email, u.name, d.size, d.shared, p.name, p.id, d.created_at
+		SELECT d.id, d.name, u.email, u.name, d.size, d.shared, p.name, p.id, d.provision_status, d.created_at,
+		       sc.id, COALESCE(sc.name, ''), COALESCE(sc.class_name, ''), COALESCE(sc.allow_shared, FALSE)
 		FROM disks d
 		JOIN users u
 		ON u.id = d.owner_id
 		JOIN projects p
 		ON p.id = d.project_id
+		LEFT JOIN storage_classes sc
+		ON sc.id = d.storage_class_id
 		WHERE u.email = $1
 	`
 
@@ -59,7 +93,12 @@ func (p *PostgresDiskRepository) GetDisks(ctx context.Context) ([]Disk, error) {
 			&disk.Shared,
 			&disk.Project.Name,
 			&disk.Project.ID,
+			&disk.ProvisionStatus,
 			&disk.CreatedAt,
+			&disk.StorageClass.ID,
+			&disk.StorageClass.Name,
+			&disk.StorageClass.ClassName,
+			&disk.StorageClass.AllowShared,
 		)
 
 		if err != nil {
@@ -149,22 +188,58 @@ func (p *PostgresDiskRepository) GetProjectsByName(ctx context.Context, name str
 	return projectList, nil
 }
 
-func (p *PostgresDiskRepository) CreateDisk(disk Disk) error {
+func (p *PostgresDiskRepository) CreateDisk(ctx context.Context, disk Disk, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProjectStorage(ctx, tx, disk.Project.ID, disk.Size); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO disks (id, name, owner_id, size, shared, project_id, storage_class_id, provision_status, created_at, source_snapshot_id)
+			VALUES ($1, $2, (SELECT id FROM users WHERE email = $3), $4, $5, $6, $7, $8, $9, $10)
+		`
+
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			disk.ID,
+			disk.Name,
+			disk.Owner.Email,
+			disk.Size,
+			disk.Shared,
+			disk.Project.ID,
+			disk.StorageClass.ID,
+			disk.ProvisionStatus,
+			disk.CreatedAt,
+			disk.SourceSnapshotID,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+// GetProvisionStatus returns sql.ErrNoRows once the row is deleted.
+func (p *PostgresDiskRepository) GetProvisionStatus(id uuid.UUID) (string, error) {
 	query := `
-		INSERT INTO disks (id, name, owner_id, size, shared, project_id, created_at)
-		VALUES ($1, $2, (SELECT id FROM users WHERE email = $3), $4, $5, $6, $7)
+		SELECT provision_status FROM disks WHERE id = $1
 	`
 
-	_, err := p.uow.DB().Exec(
-		query,
-		disk.ID,
-		disk.Name,
-		disk.Owner.Email,
-		disk.Size,
-		disk.Shared,
-		disk.Project.ID,
-		disk.CreatedAt,
-	)
+	var status string
+	err := p.uow.DB().QueryRowx(query, id).Scan(&status)
+
+	return status, err
+}
+
+func (p *PostgresDiskRepository) SetProvisionStatus(id uuid.UUID, status string) error {
+	query := `
+		UPDATE disks SET provision_status = $2, updated_at = NOW() WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, status)
 
 	if err != nil {
 		return err
@@ -189,28 +264,537 @@ func (p *PostgresDiskRepository) DeleteDisk(id uuid.UUID) error {
 
 func (p *PostgresDiskRepository) GetDiskByID(id uuid.UUID) (Disk, error) {
 	query := `
-		SELECT
-		    id,
-		    project_id
-		FROM
-		    disks
-		WHERE
-		    id = $1
+		SELECT d.id, d.name, u.email, u.name, d.size, d.shared, p.name, p.id, d.provision_status, d.created_at,
+		       sc.id, COALESCE(sc.name, ''), COALESCE(sc.class_name, ''), COALESCE(sc.allow_shared, FALSE)
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		JOIN projects p
+		ON p.id = d.project_id
+		LEFT JOIN storage_classes sc
+		ON sc.id = d.storage_class_id
+		WHERE d.id = $1
 	`
 
 	var disk Disk
-	var projectID uuid.UUID
 
-	err := p.uow.DB().QueryRowx(query, id).Scan(&disk.ID, &projectID)
+	err := p.uow.DB().QueryRowx(query, id).Scan(
+		&disk.ID,
+		&disk.Name,
+		&disk.Owner.Email,
+		&disk.Owner.Username,
+		&disk.Size,
+		&disk.Shared,
+		&disk.Project.Name,
+		&disk.Project.ID,
+		&disk.ProvisionStatus,
+		&disk.CreatedAt,
+		&disk.StorageClass.ID,
+		&disk.StorageClass.Name,
+		&disk.StorageClass.ClassName,
+		&disk.StorageClass.AllowShared,
+	)
 	if err != nil {
 		return Disk{}, err
 	}
 
-	disk.Project.ID = projectID
-
 	return disk, nil
 }
 
+// MarkDiskDeleting flags the disk and enqueues the PVC deletion in one
+// transaction, the row itself is removed once the claim is gone.
+func (p *PostgresDiskRepository) MarkDiskDeleting(ctx context.Context, id uuid.UUID, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE disks SET provision_status = $2, updated_at = NOW() WHERE id = $1
+		`
+
+		_, err := tx.ExecContext(ctx, query, id, outbox.ProvisionDeleting)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+func (p *PostgresDiskRepository) RecordRetainedVolume(disk Disk, pvName string) error {
+	query := `
+		INSERT INTO retained_volumes (disk_id, disk_name, project_id, owner_id, pv_name, size)
+		VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $6)
+		ON CONFLICT (disk_id) DO NOTHING
+	`
+
+	_, err := p.uow.DB().Exec(query, disk.ID, disk.Name, disk.Project.ID, disk.Owner.Email, pvName, disk.Size)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresDiskRepository) GetRetainedVolumes() ([]RetainedVolume, error) {
+	query := `
+		SELECT rv.id, rv.disk_id, rv.disk_name, p.name AS project_name, u.email, rv.pv_name, rv.size, rv.created_at
+		FROM retained_volumes rv
+		JOIN users u
+		ON u.id = rv.owner_id
+		LEFT JOIN projects p
+		ON p.id = rv.project_id
+		ORDER BY rv.created_at DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var volumes []RetainedVolume
+	for rows.Next() {
+		var volume RetainedVolume
+		if err := rows.StructScan(&volume); err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, nil
+}
+
+func (p *PostgresDiskRepository) ResizeDisk(ctx context.Context, id uuid.UUID, size int, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		var projectId uuid.UUID
+		var previousSize int
+
+		err := tx.QueryRowContext(ctx, `SELECT project_id, size FROM disks WHERE id = $1`, id).Scan(&projectId, &previousSize)
+		if err != nil {
+			return err
+		}
+
+		if err := checkProjectStorage(ctx, tx, projectId, size-previousSize); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE disks SET size = $2, updated_at = NOW() WHERE id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query, id, size)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+// RevertDiskSize puts the previous size back after a failed resize, unless
+// the size was changed again in the meantime.
+func (p *PostgresDiskRepository) RevertDiskSize(id uuid.UUID, size int, previousSize int) error {
+	query := `
+		UPDATE disks SET size = $3, updated_at = NOW() WHERE id = $1 AND size = $2
+	`
+
+	_, err := p.uow.DB().Exec(query, id, size, previousSize)
+
+	return err
+}
+
+// checkProjectStorage locks the project row, so concurrent creates and
+// resizes of its disks are checked one after another, and fails when adding
+// the given size goes above the storage limit.
+func checkProjectStorage(ctx context.Context, tx *sql.Tx, projectId uuid.UUID, size int) error {
+	var limit, used int
+
+	err := tx.QueryRowContext(ctx, `SELECT storage_limit FROM projects WHERE id = $1 FOR UPDATE`, projectId).Scan(&limit)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM disks WHERE project_id = $1`, projectId).Scan(&used)
+	if err != nil {
+		return err
+	}
+
+	if used+size > limit {
+		return ErrStorageLimitExceeded
+	}
+
+	return nil
+}
+
+func (p *PostgresDiskRepository) CanManageDisk(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		WHERE d.id = $1 AND u.email = $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresDiskRepository) CreateSnapshot(ctx context.Context, snapshot Snapshot, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO disk_snapshots (id, name, disk_id, disk_name, project_id, owner_id, storage_class_id, size, shared, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, (SELECT id FROM users WHERE email = $6), $7, $8, $9, $10, $11)
+		`
+
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			snapshot.ID,
+			snapshot.Name,
+			snapshot.DiskID,
+			snapshot.DiskName,
+			snapshot.ProjectID,
+			snapshot.Owner.Email,
+			snapshot.StorageClass.ID,
+			snapshot.Size,
+			snapshot.Shared,
+			snapshot.Status,
+			snapshot.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+const snapshotColumns = `
+	s.id, s.name, s.disk_id, s.disk_name, s.project_id, s.size, s.shared, s.status, s.created_at, u.name, u.email,
+	sc.id, COALESCE(sc.name, ''), COALESCE(sc.class_name, ''), COALESCE(sc.allow_shared, FALSE)
+`
+
+func scanSnapshot(scanner interface{ Scan(...any) error }) (Snapshot, error) {
+	var snapshot Snapshot
+
+	err := scanner.Scan(
+		&snapshot.ID,
+		&snapshot.Name,
+		&snapshot.DiskID,
+		&snapshot.DiskName,
+		&snapshot.ProjectID,
+		&snapshot.Size,
+		&snapshot.Shared,
+		&snapshot.Status,
+		&snapshot.CreatedAt,
+		&snapshot.Owner.Username,
+		&snapshot.Owner.Email,
+		&snapshot.StorageClass.ID,
+		&snapshot.StorageClass.Name,
+		&snapshot.StorageClass.ClassName,
+		&snapshot.StorageClass.AllowShared,
+	)
+
+	return snapshot, err
+}
+
+func (p *PostgresDiskRepository) GetDiskSnapshots(diskId uuid.UUID) ([]Snapshot, error) {
+	query := `
+		SELECT ` + snapshotColumns + `
+		FROM disk_snapshots s
+		JOIN users u
+		ON u.id = s.owner_id
+		LEFT JOIN storage_classes sc
+		ON sc.id = s.storage_class_id
+		WHERE s.disk_id = $1
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query, diskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []Snapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (p *PostgresDiskRepository) GetSnapshotByID(id uuid.UUID) (Snapshot, error) {
+	query := `
+		SELECT ` + snapshotColumns + `
+		FROM disk_snapshots s
+		JOIN users u
+		ON u.id = s.owner_id
+		LEFT JOIN storage_classes sc
+		ON sc.id = s.storage_class_id
+		WHERE s.id = $1
+	`
+
+	return scanSnapshot(p.uow.DB().QueryRowx(query, id))
+}
+
+func (p *PostgresDiskRepository) SetSnapshotStatus(id uuid.UUID, status string) error {
+	query := `
+		UPDATE disk_snapshots SET status = $2, updated_at = NOW() WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, status)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FailMissingSnapshot marks a pending snapshot whose VolumeSnapshot is gone
+// as failed, unless its creation is still waiting in the outbox.
+func (p *PostgresDiskRepository) FailMissingSnapshot(id uuid.UUID) error {
+	query := `
+		UPDATE disk_snapshots SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status = $3
+		AND NOT EXISTS (SELECT 1 FROM outbox WHERE aggregate_id = $1 AND status = $4)
+	`
+
+	_, err := p.uow.DB().Exec(query, id, SnapshotFailed, SnapshotPending, outbox.StatusPending)
+
+	return err
+}
+
+// GetSnapshotRestores returns the disks provisioned from the snapshot that
+// still exist.
+func (p *PostgresDiskRepository) GetSnapshotRestores(snapshotId uuid.UUID) ([]Disk, error) {
+	query := `
+		SELECT d.id, d.name, d.project_id, d.provision_status
+		FROM disks d
+		WHERE d.source_snapshot_id = $1
+	`
+
+	rows, err := p.uow.DB().Queryx(query, snapshotId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []Disk
+	for rows.Next() {
+		var disk Disk
+		if err := rows.Scan(&disk.ID, &disk.Name, &disk.Project.ID, &disk.ProvisionStatus); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, rows.Err()
+}
+
+func (p *PostgresDiskRepository) DeleteSnapshot(id uuid.UUID) error {
+	query := `
+		DELETE FROM disk_snapshots WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresDiskRepository) CanManageSnapshot(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM disk_snapshots s
+		JOIN users u
+		ON u.id = s.owner_id
+		WHERE s.id = $1 AND u.email = $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanUseDisk allows the disk owner and, for shared disks, the members of the
+// disk's project.
+func (p *PostgresDiskRepository) CanUseDisk(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM disks d
+		JOIN users disk_u
+		ON disk_u.id = d.owner_id
+		JOIN projects p
+		ON p.id = d.project_id
+		JOIN users owner_u
+		ON owner_u.id = p.owner_id
+		LEFT JOIN project_user_rel pur
+		ON p.id = pur.project_id
+		LEFT JOIN users rel_u
+		ON pur.user_id = rel_u.id
+		WHERE d.id = $1
+		AND (disk_u.email = $2 OR (d.shared AND (owner_u.email = $2 OR rel_u.email = $2)))
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresDiskRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresDiskRepository) GetStorageClasses() ([]DiskStorageClass, error) {
+	query := `
+		SELECT id, name, class_name, allow_shared
+		FROM storage_classes
+		ORDER BY name
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var storageClasses []DiskStorageClass
+	for rows.Next() {
+		var storageClass DiskStorageClass
+		if err := rows.StructScan(&storageClass); err != nil {
+			return nil, err
+		}
+		storageClasses = append(storageClasses, storageClass)
+	}
+
+	return storageClasses, nil
+}
+
+func (p *PostgresDiskRepository) GetStorageClassByID(id uuid.UUID) (DiskStorageClass, error) {
+	query := `
+		SELECT id, name, class_name, allow_shared
+		FROM storage_classes
+		WHERE id = $1
+	`
+
+	var storageClass DiskStorageClass
+
+	err := p.uow.DB().QueryRowx(query, id).StructScan(&storageClass)
+	if err != nil {
+		return DiskStorageClass{}, err
+	}
+
+	return storageClass, nil
+}
+
+func (p *PostgresDiskRepository) GetTensorBoard(diskId uuid.UUID) (TensorBoard, error) {
+	query := `
+		SELECT t.disk_id, t.log_dir, t.started_at, t.last_activity_at, u.name, u.email
+		FROM tensorboards t
+		JOIN users u
+		ON u.id = t.owner_id
+		WHERE t.disk_id = $1
+	`
+
+	var tensorBoard TensorBoard
+
+	err := p.uow.DB().QueryRowx(query, diskId).Scan(
+		&tensorBoard.DiskID,
+		&tensorBoard.LogDir,
+		&tensorBoard.StartedAt,
+		&tensorBoard.LastActivityAt,
+		&tensorBoard.Owner.Username,
+		&tensorBoard.Owner.Email,
+	)
+
+	return tensorBoard, err
+}
+
+func (p *PostgresDiskRepository) CreateTensorBoard(ctx context.Context, tensorBoard TensorBoard) error {
+	query := `
+		INSERT INTO tensorboards (disk_id, project_id, owner_id, log_dir, started_at, last_activity_at)
+		SELECT d.id, d.project_id, (SELECT id FROM users WHERE email = $2), $3, $4, $4
+		FROM disks d
+		WHERE d.id = $1
+	`
+
+	_, err := p.uow.DB().ExecContext(
+		ctx,
+		query,
+		tensorBoard.DiskID,
+		tensorBoard.Owner.Email,
+		tensorBoard.LogDir,
+		tensorBoard.StartedAt,
+	)
+
+	return err
+}
+
+// TouchTensorBoard records a proxied request. Every request of the page
+// lands here, the row is only written once a minute.
+func (p *PostgresDiskRepository) TouchTensorBoard(diskId uuid.UUID) error {
+	query := `
+		UPDATE tensorboards
+		SET last_activity_at = NOW()
+		WHERE disk_id = $1 AND last_activity_at < NOW() - INTERVAL '1 minute'
+	`
+
+	_, err := p.uow.DB().Exec(query, diskId)
+	return err
+}
+
+func (p *PostgresDiskRepository) DeleteTensorBoard(diskId uuid.UUID) error {
+	_, err := p.uow.DB().Exec(`DELETE FROM tensorboards WHERE disk_id = $1`, diskId)
+	return err
+}
+
 func ProvidePostgresDiskRepository(uow storage.UnitOfWork) DiskRepository {
 	return NewPostgresDiskRepository(uow)
 }
@@ -2,13 +2,20 @@ package disks
 
 import (
 	"aispace/internal/base"
+	"aispace/internal/config"
 	"aispace/internal/consts"
+	"aispace/internal/outbox"
 	"aispace/internal/services"
+	"aispace/internal/sse"
+	"aispace/web/pages/adminweb"
 	"aispace/web/pages/disksweb"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,12 +23,65 @@ import (
 )
 
 type DiskService struct {
+	cfg          *config.Config
 	repository   DiskRepository
 	kuberService *services.KuberService
+	statusBroker *sse.Broker[services.PVCStatusUpdate]
 }
 
-func NewDiskService(repository DiskRepository, kuberService *services.KuberService) *DiskService {
-	return &DiskService{repository: repository, kuberService: kuberService}
+func NewDiskService(cfg *config.Config, repository DiskRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *DiskService {
+	service := &DiskService{
+		cfg:          cfg,
+		repository:   repository,
+		kuberService: kuberService,
+		statusBroker: sse.NewBroker[services.PVCStatusUpdate](),
+	}
+	service.registerOutboxHandlers(dispatcher)
+
+	kuberService.OnPVCStatusChange(func(update services.PVCStatusUpdate) {
+		if strings.HasPrefix(update.PVCName, services.DiskPVCPrefix) {
+			service.statusBroker.Publish(update)
+		}
+	})
+
+	return service
+}
+
+// diskStatus reports the outbox state while the PVC is being created or
+// deleted.
+func (s *DiskService) diskStatus(ctx context.Context, disk Disk) (services.PVCStatus, error) {
+	switch disk.ProvisionStatus {
+	case outbox.ProvisionPending:
+		return services.Pending, nil
+	case outbox.ProvisionFailed:
+		return services.Failed, nil
+	case outbox.ProvisionDeleting:
+		return services.Terminating, nil
+	}
+
+	return s.kuberService.GetPVCStatus(ctx, disk.GetNamespace(), disk.GetPVCName())
+}
+
+// usableDisk loads the disk for everyone who may mount it.
+func (s *DiskService) usableDisk(w http.ResponseWriter, r *http.Request) (Disk, http.HandlerFunc) {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return Disk{}, base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseDisk(diskId, r.Context()) {
+		return Disk{}, base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return Disk{}, base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return disk, nil
 }
 
 func (s *DiskService) GetDisks(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
@@ -34,11 +94,13 @@ func (s *DiskService) GetDisks(w http.ResponseWriter, r *http.Request) http.Hand
 
 	for i := range disks {
 		disk := &disks[i]
-		status, err := s.kuberService.GetPVCStatus(r.Context(), disk.GetNamespace(), disk.GetPVCName())
+		status, err := s.diskStatus(r.Context(), *disk)
 		if err != nil {
 			status = services.Unknown
 		}
 		disk.Status = status
+		disk.Conditions = s.kuberService.GetPVCConditions(disk.GetNamespace(), disk.GetPVCName())
+		disk.Reason = s.kuberService.GetPVCReason(disk.GetNamespace(), disk.GetPVCName())
 	}
 
 	var webDiskList []disksweb.WebDisk
@@ -71,6 +133,47 @@ func (s *DiskService) GetProjectsForDisk(w http.ResponseWriter, r *http.Request)
 	return base.Serve(disksweb.DiskProjects(webDiskProjectsList), w)
 }
 
+var errStorageClassRequired = errors.New("storage class is required")
+
+// storageClassForDisk resolves the catalog entry picked in the form. Disks
+// may only fall back to the cluster default while the catalog is empty.
+func (s *DiskService) storageClassForDisk(value string) (DiskStorageClass, error) {
+	if value == "" {
+		storageClasses, err := s.repository.GetStorageClasses()
+		if err != nil {
+			return DiskStorageClass{}, err
+		}
+		if len(storageClasses) > 0 {
+			return DiskStorageClass{}, errStorageClassRequired
+		}
+		return DiskStorageClass{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return DiskStorageClass{}, err
+	}
+
+	return s.repository.GetStorageClassByID(id)
+}
+
+func (s *DiskService) GetStorageClassesForDisk(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	storageClasses, err := s.repository.GetStorageClasses()
+
+	if err != nil {
+		log.Printf("Error while fetching storage classes: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webStorageClassList []disksweb.WebDiskStorageClass
+
+	for _, storageClass := range storageClasses {
+		webStorageClassList = append(webStorageClassList, storageClass.ToWebDiskStorageClass())
+	}
+
+	return base.Serve(disksweb.DiskStorageClasses(webStorageClassList), w)
+}
+
 func (s *DiskService) CreateDisk(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
 	projectId := uuid.MustParse(r.FormValue("project_id"))
 	ownerEmail := r.Context().Value(consts.ContextEmail).(string)
@@ -86,6 +189,20 @@ func (s *DiskService) CreateDisk(w http.ResponseWriter, r *http.Request) http.Ha
 		return base.ErrorServe("Invalid request", http.StatusBadRequest, w)
 	}
 
+	storageClass, err := s.storageClassForDisk(r.FormValue("storage_class_id"))
+
+	if errors.Is(err, errStorageClassRequired) {
+		return base.ErrorServe("Select a storage class", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching storage class: %s", err)
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if diskShared && storageClass.ID.Valid && !storageClass.AllowShared {
+		return base.ErrorServe("Storage class doesn't support shared disks", http.StatusBadRequest, w)
+	}
+
 	projectName, err := s.repository.GetProjectNameByID(projectId)
 
 	if err != nil {
@@ -106,21 +223,32 @@ func (s *DiskService) CreateDisk(w http.ResponseWriter, r *http.Request) http.Ha
 			ID:   projectId,
 			Name: projectName,
 		},
-		CreatedAt: time.Now(),
+		StorageClass:    storageClass,
+		ProvisionStatus: outbox.ProvisionPending,
+		CreatedAt:       time.Now(),
 	}
 
-	err = s.repository.CreateDisk(disk)
+	msg, err := outbox.NewMessage(EventDiskProvision, disk.ID, ProvisionDiskPayload{
+		Namespace:        disk.GetNamespace(),
+		PVCName:          disk.GetPVCName(),
+		Size:             disk.GetPVCSize(),
+		OwnerEmail:       ownerEmail,
+		StorageClassName: disk.StorageClass.ClassName,
+		Shared:           disk.Shared,
+	})
 
 	if err != nil {
-		log.Printf("Error while creating disk: %s", err)
+		log.Printf("Error while creating outbox message: %s", err)
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	_, err = s.kuberService.CreatePVC(r.Context(), disk.GetNamespace(), disk.GetPVCName(), disk.GetPVCSize(), ownerEmail)
+	err = s.repository.CreateDisk(r.Context(), disk, msg)
 
+	if errors.Is(err, ErrStorageLimitExceeded) {
+		return base.ErrorServe("Project storage limit exceeded", http.StatusBadRequest, w)
+	}
 	if err != nil {
-		s.repository.DeleteDisk(diskId)
-		log.Printf("Error while creating PVC: %s", err)
+		log.Printf("Error while creating disk: %s", err)
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
@@ -130,16 +258,147 @@ func (s *DiskService) CreateDisk(w http.ResponseWriter, r *http.Request) http.Ha
 }
 
 func (s *DiskService) DeleteDisk(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
-	diskId := uuid.MustParse(chi.URLParam(r, "disk_id"))
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
 
-	err := s.repository.DeleteDisk(diskId)
+	if !s.repository.CanManageDisk(diskId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	switch disk.ProvisionStatus {
+	case outbox.ProvisionPending:
+		return base.ErrorServe("Disk is still being provisioned", http.StatusBadRequest, w)
+	case outbox.ProvisionDeleting:
+		return base.ErrorServe("Disk is already being deleted", http.StatusBadRequest, w)
+	}
+
+	workloads, err := s.kuberService.WorkloadsMountingPVC(r.Context(), disk.GetNamespace(), disk.GetPVCName())
+
+	if err != nil {
+		log.Printf("Error while checking disk workloads: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if len(workloads) > 0 {
+		return base.ErrorServe("Disk is used by a workload: "+strings.Join(workloads, ", "), http.StatusBadRequest, w)
+	}
+
+	err = s.kuberService.StopDiskBrowsers(r.Context(), disk.GetNamespace(), disk.ID.String())
+
+	if err != nil {
+		log.Printf("Error while stopping disk browsers: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	msg, err := outbox.NewMessage(EventDiskDelete, disk.ID, DeleteDiskPayload{
+		Namespace:  disk.GetNamespace(),
+		PVCName:    disk.GetPVCName(),
+		Retain:     r.FormValue("retain") == "true",
+		Name:       disk.Name,
+		Size:       disk.Size,
+		ProjectID:  disk.Project.ID,
+		OwnerEmail: disk.Owner.Email,
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.MarkDiskDeleting(r.Context(), disk.ID, msg)
 
 	if err != nil {
 		log.Printf("Error while deleting disk: %s", err)
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	return base.ServeNoSwap(w)
+	disk.ProvisionStatus = outbox.ProvisionDeleting
+	disk.Status = services.Terminating
+
+	return base.Serve(disksweb.DiskRow(disk.ToWebDisk()), w)
+}
+
+func (s *DiskService) ResizeDisk(w http.ResponseWriter, r *http.Request, command ResizeDiskCommand) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDisk(diskId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if disk.ProvisionStatus != outbox.ProvisionProvisioned {
+		return base.ErrorServe("Disk is not ready", http.StatusBadRequest, w)
+	}
+
+	// PVCs can only grow
+	if command.Size <= disk.Size {
+		return base.ErrorServe("New size must be bigger than the current one", http.StatusBadRequest, w)
+	}
+
+	err = s.kuberService.CanExpandPVC(r.Context(), disk.GetNamespace(), disk.GetPVCName())
+
+	if errors.Is(err, services.ErrExpansionNotAllowed) {
+		return base.ErrorServe("Storage class does not allow expansion", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while checking PVC expansion: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	previousSize := disk.Size
+	disk.Size = command.Size
+
+	msg, err := outbox.NewMessage(EventDiskResize, disk.ID, ResizeDiskPayload{
+		Namespace:    disk.GetNamespace(),
+		PVCName:      disk.GetPVCName(),
+		Size:         disk.GetPVCSize(),
+		NewSize:      disk.Size,
+		PreviousSize: previousSize,
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.ResizeDisk(r.Context(), disk.ID, disk.Size, msg)
+
+	if errors.Is(err, ErrStorageLimitExceeded) {
+		return base.ErrorServe("Project storage limit exceeded", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while resizing disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disk.Status, err = s.diskStatus(r.Context(), disk)
+	if err != nil {
+		disk.Status = services.Unknown
+	}
+	disk.Conditions = s.kuberService.GetPVCConditions(disk.GetNamespace(), disk.GetPVCName())
+	disk.Reason = s.kuberService.GetPVCReason(disk.GetNamespace(), disk.GetPVCName())
+
+	return base.Serve(disksweb.DiskRow(disk.ToWebDisk()), w)
 }
 
 func (s *DiskService) GetDiskStatus(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
@@ -152,16 +411,495 @@ func (s *DiskService) GetDiskStatus(w http.ResponseWriter, r *http.Request) http
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	status, err := s.kuberService.GetPVCStatus(r.Context(), disk.GetNamespace(), disk.GetPVCName())
+	status, err := s.diskStatus(r.Context(), disk)
 
 	if err != nil {
 		log.Printf("Error while fetching PVC status: %s", err)
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	return base.Serve(disksweb.DiskStatus(disk.ID, status.String()), w)
+	conditions := s.kuberService.GetPVCConditions(disk.GetNamespace(), disk.GetPVCName())
+	reason := s.kuberService.GetPVCReason(disk.GetNamespace(), disk.GetPVCName())
+
+	return base.Serve(disksweb.DiskStatus(disk.ID, status.String(), conditions, reason), w)
+}
+
+// StreamDiskStatus pushes the PVC changes seen by the informer as rendered
+// status badges. Membership is checked for every change, so a user removed
+// from a project stops getting its disks, the event name matches the sse-swap
+// attribute of the badge.
+func (s *DiskService) StreamDiskStatus(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := sse.Stream(w)
+		if !ok {
+			http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		updates, unsubscribe := s.statusBroker.Subscribe()
+		defer unsubscribe()
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if err := sse.WriteComment(w, flusher, "keep-alive"); err != nil {
+					return
+				}
+			case update := <-updates:
+				projectId, err := uuid.Parse(strings.TrimPrefix(update.Namespace, services.ProjectNamespacePrefix))
+				if err != nil || !s.repository.CanUseProject(projectId, r.Context()) {
+					continue
+				}
+
+				diskId, err := uuid.Parse(strings.TrimPrefix(update.PVCName, services.DiskPVCPrefix))
+				if err != nil {
+					continue
+				}
+
+				var html strings.Builder
+				conditions := s.kuberService.GetPVCConditions(update.Namespace, update.PVCName)
+				err = disksweb.DiskStatus(diskId, update.Status.String(), conditions, update.Reason).Render(r.Context(), &html)
+				if err != nil {
+					log.Printf("Error while rendering disk status: %s", err)
+					continue
+				}
+
+				if err := sse.WriteEvent(w, flusher, fmt.Sprintf("disk_status_%s", diskId), html.String()); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *DiskService) toWebSnapshots(snapshots []Snapshot) []disksweb.WebSnapshot {
+	var webSnapshotList []disksweb.WebSnapshot
+
+	for _, snapshot := range snapshots {
+		webSnapshotList = append(webSnapshotList, snapshot.ToWebSnapshot())
+	}
+
+	return webSnapshotList
+}
+
+// refreshSnapshots asks the cluster about the snapshots that are not ready
+// yet and stores the outcome.
+func (s *DiskService) refreshSnapshots(ctx context.Context, snapshots []Snapshot) {
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if snapshot.Status != SnapshotPending {
+			continue
+		}
+
+		state, err := s.kuberService.GetVolumeSnapshotState(ctx, snapshot.GetNamespace(), snapshot.GetSnapshotName())
+		if err != nil {
+			log.Printf("Error while fetching volume snapshot state: %s", err)
+			continue
+		}
+
+		if !state.Exists {
+			if err := s.repository.FailMissingSnapshot(snapshot.ID); err != nil {
+				log.Printf("Error while updating snapshot status: %s", err)
+			}
+			continue
+		}
+
+		switch {
+		case state.ReadyToUse:
+			snapshot.Status = SnapshotReady
+		case state.Error != "":
+			log.Printf("Volume snapshot %s failed: %s", snapshot.GetSnapshotName(), state.Error)
+			snapshot.Status = SnapshotFailed
+		default:
+			continue
+		}
+
+		if err := s.repository.SetSnapshotStatus(snapshot.ID, snapshot.Status); err != nil {
+			log.Printf("Error while updating snapshot status: %s", err)
+		}
+	}
+}
+
+func (s *DiskService) serveDiskSnapshots(w http.ResponseWriter, r *http.Request, disk Disk) http.HandlerFunc {
+	snapshots, err := s.repository.GetDiskSnapshots(disk.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching snapshots: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	s.refreshSnapshots(r.Context(), snapshots)
+
+	return base.Serve(disksweb.DiskSnapshots(disk.ToWebDisk(), s.toWebSnapshots(snapshots)), w)
+}
+
+func (s *DiskService) GetDiskSnapshots(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDisk(diskId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveDiskSnapshots(w, r, disk)
+}
+
+func (s *DiskService) CreateSnapshot(w http.ResponseWriter, r *http.Request, command CreateSnapshotCommand) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageDisk(diskId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if disk.ProvisionStatus != outbox.ProvisionProvisioned {
+		return base.ErrorServe("Disk is not ready", http.StatusBadRequest, w)
+	}
+
+	snapshot := Snapshot{
+		ID:           uuid.New(),
+		Name:         command.Name,
+		DiskID:       uuid.NullUUID{UUID: disk.ID, Valid: true},
+		DiskName:     disk.Name,
+		ProjectID:    disk.Project.ID,
+		Owner:        Owner{Email: r.Context().Value(consts.ContextEmail).(string)},
+		StorageClass: disk.StorageClass,
+		Size:         disk.Size,
+		Shared:       disk.Shared,
+		Status:       SnapshotPending,
+		CreatedAt:    time.Now(),
+	}
+
+	msg, err := outbox.NewMessage(EventSnapshotCreate, snapshot.ID, CreateSnapshotPayload{
+		Namespace:    snapshot.GetNamespace(),
+		SnapshotName: snapshot.GetSnapshotName(),
+		PVCName:      disk.GetPVCName(),
+		ProjectID:    disk.Project.ID,
+		DiskID:       disk.ID,
+		OwnerEmail:   snapshot.Owner.Email,
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.CreateSnapshot(r.Context(), snapshot, msg)
+
+	if err != nil {
+		log.Printf("Error while creating snapshot: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveDiskSnapshots(w, r, disk)
+}
+
+// RestoreSnapshot creates a new disk in the snapshot's project that is
+// provisioned from the VolumeSnapshot.
+func (s *DiskService) RestoreSnapshot(w http.ResponseWriter, r *http.Request, command RestoreSnapshotCommand) http.HandlerFunc {
+	snapshotId, err := uuid.Parse(chi.URLParam(r, "snapshot_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageSnapshot(snapshotId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	snapshot, err := s.repository.GetSnapshotByID(snapshotId)
+
+	if err != nil {
+		log.Printf("Error while fetching snapshot: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if snapshot.Status != SnapshotReady {
+		return base.ErrorServe("Snapshot is not ready", http.StatusBadRequest, w)
+	}
+
+	projectName, err := s.repository.GetProjectNameByID(snapshot.ProjectID)
+
+	if err != nil {
+		log.Printf("Error while fetching project name: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disk := Disk{
+		ID:   uuid.New(),
+		Name: command.DiskName,
+		Owner: Owner{
+			Username: r.Context().Value(consts.ContextUsername).(string),
+			Email:    r.Context().Value(consts.ContextEmail).(string),
+		},
+		Size:   snapshot.Size,
+		Shared: snapshot.Shared,
+		Project: DiskProject{
+			ID:   snapshot.ProjectID,
+			Name: projectName,
+		},
+		StorageClass:     snapshot.StorageClass,
+		ProvisionStatus:  outbox.ProvisionPending,
+		CreatedAt:        time.Now(),
+		SourceSnapshotID: uuid.NullUUID{UUID: snapshot.ID, Valid: true},
+	}
+
+	msg, err := outbox.NewMessage(EventDiskProvision, disk.ID, ProvisionDiskPayload{
+		Namespace:        disk.GetNamespace(),
+		PVCName:          disk.GetPVCName(),
+		Size:             disk.GetPVCSize(),
+		OwnerEmail:       disk.Owner.Email,
+		StorageClassName: disk.StorageClass.ClassName,
+		Shared:           disk.Shared,
+		SnapshotName:     snapshot.GetSnapshotName(),
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.CreateDisk(r.Context(), disk, msg)
+
+	if errors.Is(err, ErrStorageLimitExceeded) {
+		return base.ErrorServe("Project storage limit exceeded", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while creating disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(disksweb.DiskRow(disk.ToWebDisk()), w)
+}
+
+// restoringDisks names the disks whose PVC is still being filled from the
+// snapshot, the data is only copied once the claim binds.
+func (s *DiskService) restoringDisks(ctx context.Context, snapshotId uuid.UUID) ([]string, error) {
+	disks, err := s.repository.GetSnapshotRestores(snapshotId)
+	if err != nil {
+		return nil, err
+	}
+
+	var restoring []string
+	for _, disk := range disks {
+		switch disk.ProvisionStatus {
+		case outbox.ProvisionFailed, outbox.ProvisionDeleting:
+			continue
+		case outbox.ProvisionProvisioned:
+			status, err := s.kuberService.GetPVCStatus(ctx, disk.GetNamespace(), disk.GetPVCName())
+			if err != nil {
+				return nil, err
+			}
+			if status == services.Succeeded {
+				continue
+			}
+		}
+		restoring = append(restoring, disk.Name)
+	}
+
+	return restoring, nil
+}
+
+func (s *DiskService) DeleteSnapshot(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	snapshotId, err := uuid.Parse(chi.URLParam(r, "snapshot_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageSnapshot(snapshotId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	snapshot, err := s.repository.GetSnapshotByID(snapshotId)
+
+	if err != nil {
+		log.Printf("Error while fetching snapshot: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	restoring, err := s.restoringDisks(r.Context(), snapshotId)
+
+	if err != nil {
+		log.Printf("Error while checking snapshot restores: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if len(restoring) > 0 {
+		return base.ErrorServe("Snapshot is being restored into: "+strings.Join(restoring, ", "), http.StatusBadRequest, w)
+	}
+
+	err = s.kuberService.DeleteVolumeSnapshot(r.Context(), snapshot.GetNamespace(), snapshot.GetSnapshotName())
+
+	if err != nil {
+		log.Printf("Error while deleting volume snapshot: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteSnapshot(snapshotId)
+
+	if err != nil {
+		log.Printf("Error while deleting snapshot: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func (s *DiskService) GetCloneDiskForm(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseDisk(diskId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(disksweb.CloneDiskForm(disk.ToWebDisk(), s.cfg.Kuber.CrossNamespaceClone), w)
+}
+
+// CloneDisk creates a new disk whose PVC uses the source PVC as data source.
+func (s *DiskService) CloneDisk(w http.ResponseWriter, r *http.Request, command CloneDiskCommand) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	projectId := uuid.MustParse(command.ProjectID)
+
+	if !s.repository.CanUseDisk(diskId, r.Context()) || !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	source, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		log.Printf("Error while fetching disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if source.ProvisionStatus != outbox.ProvisionProvisioned {
+		return base.ErrorServe("Disk is not ready", http.StatusBadRequest, w)
+	}
+
+	if projectId != source.Project.ID && !s.cfg.Kuber.CrossNamespaceClone {
+		return base.ErrorServe("Cloning into another project is not supported by the storage backend", http.StatusBadRequest, w)
+	}
+
+	if command.Size < source.Size {
+		return base.ErrorServe("Clone can't be smaller than the source disk", http.StatusBadRequest, w)
+	}
+
+	projectName, err := s.repository.GetProjectNameByID(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching project name: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	disk := Disk{
+		ID:   uuid.New(),
+		Name: command.DiskName,
+		Owner: Owner{
+			Username: r.Context().Value(consts.ContextUsername).(string),
+			Email:    r.Context().Value(consts.ContextEmail).(string),
+		},
+		Size:   command.Size,
+		Shared: source.Shared,
+		Project: DiskProject{
+			ID:   projectId,
+			Name: projectName,
+		},
+		// CSI drivers only clone within the same StorageClass
+		StorageClass:    source.StorageClass,
+		ProvisionStatus: outbox.ProvisionPending,
+		CreatedAt:       time.Now(),
+	}
+
+	msg, err := outbox.NewMessage(EventDiskProvision, disk.ID, ProvisionDiskPayload{
+		Namespace:        disk.GetNamespace(),
+		PVCName:          disk.GetPVCName(),
+		Size:             disk.GetPVCSize(),
+		OwnerEmail:       disk.Owner.Email,
+		StorageClassName: disk.StorageClass.ClassName,
+		Shared:           disk.Shared,
+		SourceNamespace:  source.GetNamespace(),
+		SourcePVCName:    source.GetPVCName(),
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.CreateDisk(r.Context(), disk, msg)
+
+	if errors.Is(err, ErrStorageLimitExceeded) {
+		return base.ErrorServe("Project storage limit exceeded", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while creating disk: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(disksweb.DiskRow(disk.ToWebDisk()), w)
+}
+
+func (s *DiskService) GetRetainedVolumes(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	volumes, err := s.repository.GetRetainedVolumes()
+
+	if err != nil {
+		log.Printf("Error while fetching retained volumes: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webVolumeList []adminweb.WebRetainedVolume
+
+	for _, volume := range volumes {
+		webVolumeList = append(webVolumeList, volume.ToWebRetainedVolume())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.RetainedVolumesPartial(webVolumeList), w)
+	}
+	return base.Serve(adminweb.RetainedVolumesFull(webVolumeList), w)
 }
 
-func ProvideDiskService(repository DiskRepository, kuberService *services.KuberService) *DiskService {
-	return NewDiskService(repository, kuberService)
+func ProvideDiskService(cfg *config.Config, repository DiskRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *DiskService {
+	return NewDiskService(cfg, repository, kuberService, dispatcher)
 }
@@ -0,0 +1,155 @@
+package disks
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/consts"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/web/pages/disksweb"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (s *DiskService) serveTensorBoard(w http.ResponseWriter, disk Disk) http.HandlerFunc {
+	tensorBoard, err := s.repository.GetTensorBoard(disk.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return base.Serve(disksweb.DiskTensorBoard(disk.ToWebDisk(), nil), w)
+	}
+
+	if err != nil {
+		log.Printf("Error while fetching tensorboard: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	status := s.kuberService.GetPodStatus(disk.GetNamespace(), disk.GetTensorBoardName())
+	webTensorBoard := tensorBoard.ToWebTensorBoard(disk, status.String())
+
+	return base.Serve(disksweb.DiskTensorBoard(disk.ToWebDisk(), &webTensorBoard), w)
+}
+
+func (s *DiskService) GetTensorBoard(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	disk, handler := s.usableDisk(w, r)
+	if handler != nil {
+		return handler
+	}
+
+	return s.serveTensorBoard(w, disk)
+}
+
+// StartTensorBoard runs TensorBoard on the disk. Everyone who can mount the
+// disk shares the same instance.
+func (s *DiskService) StartTensorBoard(w http.ResponseWriter, r *http.Request, command StartTensorBoardCommand) http.HandlerFunc {
+	disk, handler := s.usableDisk(w, r)
+	if handler != nil {
+		return handler
+	}
+
+	if disk.ProvisionStatus != outbox.ProvisionProvisioned {
+		return base.ErrorServe("Disk is not ready", http.StatusBadRequest, w)
+	}
+
+	if _, err := s.repository.GetTensorBoard(disk.ID); err == nil {
+		return base.ErrorServe("TensorBoard is already running", http.StatusBadRequest, w)
+	}
+
+	tensorBoard := TensorBoard{
+		DiskID:    disk.ID,
+		LogDir:    cleanDiskPath(command.LogDir),
+		Owner:     Owner{Email: r.Context().Value(consts.ContextEmail).(string)},
+		StartedAt: time.Now(),
+	}
+
+	err := s.repository.CreateTensorBoard(r.Context(), tensorBoard)
+
+	if err != nil {
+		log.Printf("Error while creating tensorboard: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.StartTensorBoard(r.Context(), disk.GetTensorBoardSpec(s.cfg.TensorBoard.Image, tensorBoard))
+
+	if err != nil {
+		log.Printf("Error while starting tensorboard: %s", err)
+		if err := s.repository.DeleteTensorBoard(disk.ID); err != nil {
+			log.Printf("Error while deleting tensorboard: %s", err)
+		}
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveTensorBoard(w, disk)
+}
+
+func (s *DiskService) StopTensorBoard(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	disk, handler := s.usableDisk(w, r)
+	if handler != nil {
+		return handler
+	}
+
+	err := s.kuberService.StopTensorBoard(r.Context(), disk.GetNamespace(), disk.GetTensorBoardName())
+
+	if err != nil {
+		log.Printf("Error while stopping tensorboard: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteTensorBoard(disk.ID)
+
+	if err != nil {
+		log.Printf("Error while deleting tensorboard: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveTensorBoard(w, disk)
+}
+
+// ProxyTensorBoard forwards to the TensorBoard service. TensorBoard serves
+// under the same path, the prefix is kept. Every request counts as activity
+// for the culler.
+func (s *DiskService) ProxyTensorBoard(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	diskId, err := uuid.Parse(chi.URLParam(r, "disk_id"))
+
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+		}
+	}
+
+	if !s.repository.CanUseDisk(diskId, r.Context()) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+
+	disk, err := s.repository.GetDiskByID(diskId)
+
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "TensorBoard is not running", http.StatusServiceUnavailable)
+		}
+	}
+
+	if _, err := s.repository.GetTensorBoard(diskId); err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "TensorBoard is not running", http.StatusServiceUnavailable)
+		}
+	}
+
+	if s.kuberService.GetPodStatus(disk.GetNamespace(), disk.GetTensorBoardName()) != services.WorkloadRunning {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "TensorBoard is starting", http.StatusServiceUnavailable)
+		}
+	}
+
+	if err := s.repository.TouchTensorBoard(diskId); err != nil {
+		log.Printf("Error while updating tensorboard activity: %s", err)
+	}
+
+	return s.kuberService.ServiceProxy(disk.GetNamespace(), disk.GetTensorBoardName(), services.TensorBoardPort, "").ServeHTTP
+}
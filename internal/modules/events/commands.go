@@ -0,0 +1,24 @@
+package events
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// ProjectEventsQuery filters the event timeline, empty fields match
+// everything.
+type ProjectEventsQuery struct {
+	Type   string `validate:"omitempty,oneof=Normal Warning" form:"type"`
+	Kind   string `validate:"max=63" form:"kind"`
+	Search string `validate:"max=200" form:"search"`
+}
+
+func (c *ProjectEventsQuery) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type EventHandler struct {
+	eventService *EventService
+}
+
+func NewEventHandler(eventService *EventService) *EventHandler {
+	return &EventHandler{eventService: eventService}
+}
+
+func (h *EventHandler) GetProjectEvents(w http.ResponseWriter, r *http.Request) {
+	query := ProjectEventsQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.eventService.GetProjectEvents(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideEventHandler(eventService *EventService) *EventHandler {
+	return NewEventHandler(eventService)
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/eventsweb"
+	"strings"
+)
+
+// matches reports whether the event passes the filter, the search looks into
+// the object name, the reason and the note.
+func (q ProjectEventsQuery) matches(event services.ProjectEvent) bool {
+	if q.Type != "" && event.Type != q.Type {
+		return false
+	}
+	if q.Kind != "" && event.Kind != q.Kind {
+		return false
+	}
+	if q.Search == "" {
+		return true
+	}
+
+	search := strings.ToLower(q.Search)
+	for _, field := range []string{event.Name, event.Reason, event.Note} {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func toWebEvent(event services.ProjectEvent) eventsweb.WebEvent {
+	return eventsweb.WebEvent{
+		Kind:     event.Kind,
+		Name:     event.Name,
+		Type:     event.Type,
+		Reason:   event.Reason,
+		Note:     event.Note,
+		Count:    event.Count,
+		LastSeen: event.LastSeen.Format("2006-01-02 15:04:05"),
+	}
+}
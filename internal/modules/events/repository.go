@@ -0,0 +1,52 @@
+package events
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type EventRepository interface {
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresEventRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresEventRepository(uow storage.UnitOfWork) *PostgresEventRepository {
+	return &PostgresEventRepository{uow: uow}
+}
+
+func (p *PostgresEventRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresEventRepository(uow storage.UnitOfWork) EventRepository {
+	return NewPostgresEventRepository(uow)
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/services"
+	"aispace/web/pages/eventsweb"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type EventService struct {
+	repository   EventRepository
+	kuberService *services.KuberService
+}
+
+func NewEventService(repository EventRepository, kuberService *services.KuberService) *EventService {
+	return &EventService{repository: repository, kuberService: kuberService}
+}
+
+// GetProjectEvents renders the timeline with its filter form on the first
+// load, later filter requests only swap the list.
+func (s *EventService) GetProjectEvents(w http.ResponseWriter, r *http.Request, query ProjectEventsQuery) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	var kinds []string
+	var webEventList []eventsweb.WebEvent
+
+	for _, event := range s.kuberService.GetProjectEvents(services.ProjectNamespacePrefix + projectId.String()) {
+		if !slices.Contains(kinds, event.Kind) {
+			kinds = append(kinds, event.Kind)
+		}
+		if query.matches(event) {
+			webEventList = append(webEventList, toWebEvent(event))
+		}
+	}
+	slices.Sort(kinds)
+
+	if r.Header.Get("HX-Target") == "project_event_list" {
+		return base.Serve(eventsweb.EventList(webEventList), w)
+	}
+	return base.Serve(eventsweb.EventTimeline(projectId, kinds, webEventList), w)
+}
+
+func ProvideEventService(repository EventRepository, kuberService *services.KuberService) *EventService {
+	return NewEventService(repository, kuberService)
+}
@@ -0,0 +1,37 @@
+package images
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// SaveImageCommand creates a catalog entry or replaces all fields of one.
+type SaveImageCommand struct {
+	Name           string `validate:"required,min=3,max=100" form:"name"`
+	Image          string `validate:"required,max=500" form:"image"`
+	Description    string `validate:"max=1000" form:"description"`
+	DefaultCommand string `validate:"max=4000" form:"default_command"`
+	Port           int    `validate:"gte=0,lte=65535" form:"port"`
+	CPU            int    `validate:"required,gte=1" form:"cpu"`
+	RAM            int    `validate:"required,gte=1" form:"ram"`
+}
+
+func (c *SaveImageCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+type UpdateProjectImageCommand struct {
+	Pinned bool `form:"pinned"`
+	Hidden bool `form:"hidden"`
+}
+
+func (c *UpdateProjectImageCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
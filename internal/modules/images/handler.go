@@ -0,0 +1,141 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type ImageHandler struct {
+	imageService *ImageService
+}
+
+func NewImageHandler(imageService *ImageService) *ImageHandler {
+	return &ImageHandler{imageService: imageService}
+}
+
+func (h *ImageHandler) GetImages(w http.ResponseWriter, r *http.Request) {
+	handler := h.imageService.GetImages(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ImageHandler) CreateImage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := SaveImageCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.imageService.CreateImage(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *ImageHandler) GetImageRow(w http.ResponseWriter, r *http.Request) {
+	handler := h.imageService.GetImageRow(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ImageHandler) GetImageEditRow(w http.ResponseWriter, r *http.Request) {
+	handler := h.imageService.GetImageEditRow(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ImageHandler) UpdateImage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := SaveImageCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.imageService.UpdateImage(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	handler := h.imageService.DeleteImage(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ImageHandler) GetProjectImages(w http.ResponseWriter, r *http.Request) {
+	handler := h.imageService.GetProjectImages(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ImageHandler) UpdateProjectImage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := UpdateProjectImageCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.imageService.UpdateProjectImage(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func ProvideImageHandler(imageService *ImageService) *ImageHandler {
+	return NewImageHandler(imageService)
+}
@@ -0,0 +1,79 @@
+package images
+
+import (
+	"aispace/web/pages/adminweb"
+	"aispace/web/pages/projectsweb"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Image is an admin curated environment. Workspaces and jobs start from it,
+// the command, port and resources are defaults the user may still change.
+type Image struct {
+	ID             uuid.UUID `db:"id"`
+	Name           string    `db:"name"`
+	Image          string    `db:"image"`
+	Description    string    `db:"description"`
+	DefaultCommand string    `db:"default_command"`
+	Port           *int      `db:"port"`
+	CPU            int       `db:"cpu"`
+	RAM            int       `db:"ram"`
+	WorkloadCount  int       `db:"workload_count"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// ProjectImage is a catalog entry as seen by one project.
+type ProjectImage struct {
+	Image
+	Pinned bool `db:"pinned"`
+	Hidden bool `db:"hidden"`
+}
+
+func (i *Image) fromCommand(command SaveImageCommand) {
+	i.Name = command.Name
+	i.Image = command.Image
+	i.Description = command.Description
+	i.DefaultCommand = command.DefaultCommand
+	i.CPU = command.CPU
+	i.RAM = command.RAM
+	i.Port = nil
+	if command.Port > 0 {
+		port := command.Port
+		i.Port = &port
+	}
+}
+
+func (i *Image) portValue() int {
+	if i.Port == nil {
+		return 0
+	}
+	return *i.Port
+}
+
+func (i *Image) ToWebImage() adminweb.WebImage {
+	return adminweb.WebImage{
+		ID:             i.ID,
+		Name:           i.Name,
+		Image:          i.Image,
+		Description:    i.Description,
+		DefaultCommand: i.DefaultCommand,
+		Port:           i.portValue(),
+		CPU:            i.CPU,
+		RAM:            i.RAM,
+		WorkloadCount:  i.WorkloadCount,
+		CreatedAt:      i.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+func (i *ProjectImage) ToWebProjectImage(projectId uuid.UUID) projectsweb.WebProjectImage {
+	return projectsweb.WebProjectImage{
+		ID:          i.ID,
+		ProjectID:   projectId,
+		Name:        i.Name,
+		Image:       i.Image.Image,
+		Description: i.Description,
+		Pinned:      i.Pinned,
+		Hidden:      i.Hidden,
+	}
+}
@@ -0,0 +1,226 @@
+package images
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ImageRepository interface {
+	GetImages() ([]Image, error)
+	GetImageByID(id uuid.UUID) (Image, error)
+	CreateImage(image Image) error
+	UpdateImage(image Image) error
+	DeleteImage(id uuid.UUID) error
+	GetProjectImages(projectId uuid.UUID) ([]ProjectImage, error)
+	GetProjectImage(projectId uuid.UUID, imageId uuid.UUID) (ProjectImage, error)
+	SetProjectImage(projectId uuid.UUID, imageId uuid.UUID, pinned bool, hidden bool) error
+	CanEditProject(projectId uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresImageRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresImageRepository(uow storage.UnitOfWork) *PostgresImageRepository {
+	return &PostgresImageRepository{uow: uow}
+}
+
+func (p *PostgresImageRepository) GetImages() ([]Image, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram, i.created_at,
+			(SELECT COUNT(*) FROM workspaces w WHERE w.image_id = i.id)
+			+ (SELECT COUNT(*) FROM jobs j WHERE j.image_id = i.id) AS workload_count
+		FROM images i
+		ORDER BY i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []Image
+	for rows.Next() {
+		var image Image
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresImageRepository) GetImageByID(id uuid.UUID) (Image, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram, i.created_at,
+			(SELECT COUNT(*) FROM workspaces w WHERE w.image_id = i.id)
+			+ (SELECT COUNT(*) FROM jobs j WHERE j.image_id = i.id) AS workload_count
+		FROM images i
+		WHERE i.id = $1
+	`
+
+	var image Image
+	err := p.uow.DB().QueryRowx(query, id).StructScan(&image)
+
+	return image, err
+}
+
+func (p *PostgresImageRepository) CreateImage(image Image) error {
+	query := `
+		INSERT INTO images (id, name, image, description, default_command, port, cpu, ram, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := p.uow.DB().Exec(
+		query,
+		image.ID,
+		image.Name,
+		image.Image,
+		image.Description,
+		image.DefaultCommand,
+		image.Port,
+		image.CPU,
+		image.RAM,
+		image.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateImage changes the catalog entry only. Workloads copied the image
+// string when they were created, so running ones are not affected.
+func (p *PostgresImageRepository) UpdateImage(image Image) error {
+	query := `
+		UPDATE images
+		SET name = $2, image = $3, description = $4, default_command = $5, port = $6, cpu = $7, ram = $8, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(
+		query,
+		image.ID,
+		image.Name,
+		image.Image,
+		image.Description,
+		image.DefaultCommand,
+		image.Port,
+		image.CPU,
+		image.RAM,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresImageRepository) DeleteImage(id uuid.UUID) error {
+	query := `
+		DELETE FROM images WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const projectImageQuery = `
+	SELECT
+		i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram, i.created_at,
+		COALESCE(pi.pinned, FALSE) AS pinned,
+		COALESCE(pi.hidden, FALSE) AS hidden
+	FROM images i
+	LEFT JOIN project_images pi
+	ON pi.image_id = i.id AND pi.project_id = $1
+`
+
+func (p *PostgresImageRepository) GetProjectImages(projectId uuid.UUID) ([]ProjectImage, error) {
+	query := projectImageQuery + `
+		ORDER BY pinned DESC, i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []ProjectImage
+	for rows.Next() {
+		var image ProjectImage
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresImageRepository) GetProjectImage(projectId uuid.UUID, imageId uuid.UUID) (ProjectImage, error) {
+	query := projectImageQuery + `
+		WHERE i.id = $2
+	`
+
+	var image ProjectImage
+	err := p.uow.DB().QueryRowx(query, projectId, imageId).StructScan(&image)
+
+	return image, err
+}
+
+func (p *PostgresImageRepository) SetProjectImage(projectId uuid.UUID, imageId uuid.UUID, pinned bool, hidden bool) error {
+	query := `
+		INSERT INTO project_images (project_id, image_id, pinned, hidden)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, image_id)
+		DO UPDATE SET pinned = EXCLUDED.pinned, hidden = EXCLUDED.hidden
+	`
+
+	_, err := p.uow.DB().Exec(query, projectId, imageId, pinned, hidden)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresImageRepository) CanEditProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+
+	query := `
+		SELECT 1 FROM projects p
+		JOIN users u
+		ON u.id = p.owner_id
+		WHERE p.id = $1 and u.email = $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresImageRepository(uow storage.UnitOfWork) ImageRepository {
+	return NewPostgresImageRepository(uow)
+}
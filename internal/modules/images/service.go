@@ -0,0 +1,205 @@
+package images
+
+import (
+	"aispace/internal/base"
+	"aispace/web/pages/adminweb"
+	"aispace/web/pages/projectsweb"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ImageService struct {
+	repository ImageRepository
+}
+
+func NewImageService(repository ImageRepository) *ImageService {
+	return &ImageService{repository: repository}
+}
+
+func (s *ImageService) GetImages(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	images, err := s.repository.GetImages()
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webImageList []adminweb.WebImage
+
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebImage())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.ImagesPartial(webImageList), w)
+	}
+	return base.Serve(adminweb.ImagesFull(webImageList), w)
+}
+
+func (s *ImageService) CreateImage(w http.ResponseWriter, r *http.Request, command SaveImageCommand) http.HandlerFunc {
+	image := Image{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+	}
+	image.fromCommand(command)
+
+	err := s.repository.CreateImage(image)
+
+	if err != nil {
+		log.Printf("Error while creating image: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.ImageRow(image.ToWebImage()), w)
+}
+
+func (s *ImageService) getImage(w http.ResponseWriter, r *http.Request) (Image, http.HandlerFunc) {
+	imageId, err := uuid.Parse(chi.URLParam(r, "image_id"))
+
+	if err != nil {
+		return Image{}, base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	image, err := s.repository.GetImageByID(imageId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Image{}, base.ErrorServe("Image not found", http.StatusNotFound, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching image: %s", err)
+		return Image{}, base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return image, nil
+}
+
+func (s *ImageService) GetImageRow(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	image, errServe := s.getImage(w, r)
+	if errServe != nil {
+		return errServe
+	}
+
+	return base.Serve(adminweb.ImageRow(image.ToWebImage()), w)
+}
+
+func (s *ImageService) GetImageEditRow(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	image, errServe := s.getImage(w, r)
+	if errServe != nil {
+		return errServe
+	}
+
+	return base.Serve(adminweb.ImageEditRow(image.ToWebImage()), w)
+}
+
+func (s *ImageService) UpdateImage(w http.ResponseWriter, r *http.Request, command SaveImageCommand) http.HandlerFunc {
+	image, errServe := s.getImage(w, r)
+	if errServe != nil {
+		return errServe
+	}
+
+	image.fromCommand(command)
+
+	err := s.repository.UpdateImage(image)
+
+	if err != nil {
+		log.Printf("Error while updating image: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.ImageRow(image.ToWebImage()), w)
+}
+
+// DeleteImage removes the entry from the catalog. Workloads started from it
+// keep their image string and only lose the reference.
+func (s *ImageService) DeleteImage(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	imageId, err := uuid.Parse(chi.URLParam(r, "image_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	err = s.repository.DeleteImage(imageId)
+
+	if err != nil {
+		log.Printf("Error while deleting image: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func (s *ImageService) GetProjectImages(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching project images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webImageList []projectsweb.WebProjectImage
+
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebProjectImage(projectId))
+	}
+
+	return base.Serve(projectsweb.ProjectImages(webImageList), w)
+}
+
+// UpdateProjectImage pins or hides a catalog entry for one project. Pinned
+// entries are offered first, hidden ones are not offered at all.
+func (s *ImageService) UpdateProjectImage(w http.ResponseWriter, r *http.Request, command UpdateProjectImageCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	imageId, err := uuid.Parse(chi.URLParam(r, "image_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	// Hiding wins, a hidden image is never offered so it can't stay pinned.
+	pinned := command.Pinned && !command.Hidden
+
+	err = s.repository.SetProjectImage(projectId, imageId, pinned, command.Hidden)
+
+	if err != nil {
+		log.Printf("Error while updating project image: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	image, err := s.repository.GetProjectImage(projectId, imageId)
+
+	if err != nil {
+		log.Printf("Error while fetching project image: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(projectsweb.ProjectImageRow(image.ToWebProjectImage(projectId)), w)
+}
+
+func ProvideImageService(repository ImageRepository) *ImageService {
+	return NewImageService(repository)
+}
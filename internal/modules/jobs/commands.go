@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+type CreateJobCommand struct {
+	Name      string   `validate:"required,min=3,max=100" form:"name"`
+	ProjectID string   `validate:"required,uuid" form:"project_id"`
+	ImageID   string   `validate:"omitempty,uuid" form:"image_id"`
+	Image     string   `validate:"max=500" form:"image"`
+	Command   string   `validate:"required,max=4000" form:"command"`
+	CPU       int      `validate:"required,gte=1" form:"cpu"`
+	RAM       int      `validate:"required,gte=1" form:"ram"`
+	DiskIDs   []string `validate:"dive,uuid" form:"disk_ids"`
+	Secrets   []string `validate:"dive,max=50" form:"secrets"`
+}
+
+func (c *CreateJobCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
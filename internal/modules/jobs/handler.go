@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type JobHandler struct {
+	jobService *JobService
+}
+
+func NewJobHandler(jobService *JobService) *JobHandler {
+	return &JobHandler{jobService: jobService}
+}
+
+func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetJobs(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetProjectJobs(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetProjectJobs(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetProjectsForJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetProjectsForJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetDisksForJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetDisksForJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetSecretsForJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetSecretsForJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetImagesForJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetImagesForJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) GetJobLogs(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetJobLogs(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateJobCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.jobService.CreateJob(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *JobHandler) GetJobStatus(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.GetJobStatus(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *JobHandler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	handler := h.jobService.DeleteJob(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideJobHandler(jobService *JobService) *JobHandler {
+	return NewJobHandler(jobService)
+}
@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/jobsweb"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Job struct {
+	ID         uuid.UUID `db:"id"`
+	Name       string    `db:"name"`
+	Project    JobProject
+	Owner      Owner
+	ImageID    uuid.NullUUID `db:"image_id"`
+	Image      string        `db:"image"`
+	Command    string        `db:"command"`
+	CPU        int           `db:"cpu"`
+	RAM        int           `db:"ram"`
+	Status     string        `db:"status"`
+	StartedAt  *time.Time    `db:"started_at"`
+	FinishedAt *time.Time    `db:"finished_at"`
+	ExitCode   *int          `db:"exit_code"`
+	Disks      []JobDisk
+	Secrets    []JobSecret
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type JobProject struct {
+	ID   uuid.UUID
+	Name string
+}
+
+type JobDisk struct {
+	ID   uuid.UUID `db:"id"`
+	Name string    `db:"name"`
+}
+
+// JobSecret is a project secret imported as environment variables or, with
+// AsFile, as files.
+type JobSecret struct {
+	ID     uuid.UUID `db:"id"`
+	Name   string    `db:"name"`
+	AsFile bool      `db:"as_file"`
+}
+
+// JobImage is a catalog entry the project offers.
+type JobImage struct {
+	ID             uuid.UUID `db:"id"`
+	Name           string    `db:"name"`
+	Image          string    `db:"image"`
+	Description    string    `db:"description"`
+	DefaultCommand string    `db:"default_command"`
+	Port           *int      `db:"port"`
+	CPU            int       `db:"cpu"`
+	RAM            int       `db:"ram"`
+	Pinned         bool      `db:"pinned"`
+}
+
+type Owner struct {
+	Username string `db:"name"`
+	Email    string `db:"email"`
+}
+
+func (j *Job) GetNamespace() string {
+	return fmt.Sprintf("project-%s", j.Project.ID.String())
+}
+
+func (j *Job) GetJobName() string {
+	return fmt.Sprintf("job-%s", j.ID.String())
+}
+
+func (d *JobDisk) GetPVCName() string {
+	return fmt.Sprintf("disk-%s", d.ID.String())
+}
+
+func (s *JobSecret) GetSecretName() string {
+	return fmt.Sprintf("secret-%s", s.ID.String())
+}
+
+func (j *Job) ToSpec() services.JobSpec {
+	var mounts []services.DiskMount
+	for _, disk := range j.Disks {
+		mounts = append(mounts, services.DiskMount{
+			PVCName:   disk.GetPVCName(),
+			MountPath: path.Join("/mnt/disks", services.MountDirName(disk.Name)),
+		})
+	}
+
+	var secretMounts []services.SecretMount
+	for _, secret := range j.Secrets {
+		secretMounts = append(secretMounts, services.SecretMount{
+			SecretName: secret.GetSecretName(),
+			MountPath:  path.Join("/mnt/secrets", services.MountDirName(secret.Name)),
+			AsFile:     secret.AsFile,
+		})
+	}
+
+	return services.JobSpec{
+		ID:         j.ID.String(),
+		Name:       j.GetJobName(),
+		Namespace:  j.GetNamespace(),
+		ProjectID:  j.Project.ID.String(),
+		OwnerEmail: j.Owner.Email,
+		Image:      j.Image,
+		Command:    j.Command,
+		CPU:        j.CPU,
+		RAM:        j.RAM,
+		Disks:      mounts,
+		Secrets:    secretMounts,
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func (p *JobProject) ToWebJobProject(project JobProject) jobsweb.WebJobProject {
+	return jobsweb.WebJobProject{
+		ID:   project.ID,
+		Name: project.Name,
+	}
+}
+
+func (d *JobDisk) ToWebJobDisk(disk JobDisk) jobsweb.WebJobDisk {
+	return jobsweb.WebJobDisk{
+		ID:   disk.ID,
+		Name: disk.Name,
+	}
+}
+
+func (s *JobSecret) ToWebJobSecret(secret JobSecret) jobsweb.WebJobSecret {
+	return jobsweb.WebJobSecret{
+		ID:   secret.ID,
+		Name: secret.Name,
+	}
+}
+
+func (i *JobImage) ToWebJobImage(image JobImage) jobsweb.WebJobImage {
+	return jobsweb.WebJobImage{
+		ID:             image.ID,
+		Name:           image.Name,
+		Description:    image.Description,
+		DefaultCommand: image.DefaultCommand,
+		CPU:            image.CPU,
+		RAM:            image.RAM,
+		Pinned:         image.Pinned,
+	}
+}
+
+func (j *Job) ToWebJob() jobsweb.WebJob {
+	var webDisks []jobsweb.WebJobDisk
+	for _, disk := range j.Disks {
+		webDisks = append(webDisks, disk.ToWebJobDisk(disk))
+	}
+
+	exitCode := "-"
+	if j.ExitCode != nil {
+		exitCode = fmt.Sprint(*j.ExitCode)
+	}
+
+	return jobsweb.WebJob{
+		ID:            j.ID,
+		Name:          j.Name,
+		OwnerUsername: j.Owner.Username,
+		OwnerEmail:    j.Owner.Email,
+		Project:       j.Project.ToWebJobProject(j.Project),
+		Image:         j.Image,
+		Command:       j.Command,
+		CPU:           j.CPU,
+		RAM:           j.RAM,
+		Status:        j.Status,
+		StartedAt:     formatTime(j.StartedAt),
+		FinishedAt:    formatTime(j.FinishedAt),
+		ExitCode:      exitCode,
+		Disks:         webDisks,
+		CreatedAt:     j.CreatedAt.Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,472 @@
+package jobs
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type JobRepository interface {
+	GetJobs(ctx context.Context) ([]Job, error)
+	GetProjectJobs(projectId uuid.UUID) ([]Job, error)
+	GetJobByID(id uuid.UUID) (Job, error)
+	CreateJob(ctx context.Context, job Job) error
+	UpdateJobState(state services.JobState) error
+	DeleteJob(id uuid.UUID) error
+	GetProjectsByName(ctx context.Context, name string) ([]JobProject, error)
+	GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]JobDisk, error)
+	GetProjectSecrets(projectId uuid.UUID) ([]JobSecret, error)
+	GetProjectImages(projectId uuid.UUID) ([]JobImage, error)
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanManageJob(id uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresJobRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresJobRepository(uow storage.UnitOfWork) *PostgresJobRepository {
+	return &PostgresJobRepository{uow: uow}
+}
+
+const jobColumns = `
+	j.id, j.name, j.image_id, j.image, j.command, j.cpu, j.ram, j.status,
+	j.started_at, j.finished_at, j.exit_code, j.created_at,
+	u.name, u.email, p.id, p.name
+`
+
+func scanJob(scanner interface{ Scan(...any) error }) (Job, error) {
+	var job Job
+
+	err := scanner.Scan(
+		&job.ID,
+		&job.Name,
+		&job.ImageID,
+		&job.Image,
+		&job.Command,
+		&job.CPU,
+		&job.RAM,
+		&job.Status,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.ExitCode,
+		&job.CreatedAt,
+		&job.Owner.Username,
+		&job.Owner.Email,
+		&job.Project.ID,
+		&job.Project.Name,
+	)
+
+	return job, err
+}
+
+func (p *PostgresJobRepository) queryJobs(query string, args ...any) ([]Job, error) {
+	rows, err := p.uow.DB().Queryx(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobList []Job
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		jobList = append(jobList, job)
+	}
+
+	for i := range jobList {
+		disks, err := p.getJobDisks(jobList[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		jobList[i].Disks = disks
+	}
+
+	return jobList, nil
+}
+
+// GetJobs returns the jobs of every project the current user takes part in.
+func (p *PostgresJobRepository) GetJobs(ctx context.Context) ([]Job, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs j
+		JOIN users u
+		ON u.id = j.owner_id
+		JOIN projects p
+		ON p.id = j.project_id
+		WHERE j.project_id IN (
+			SELECT p.id
+			FROM projects p
+			JOIN users owner_u ON p.owner_id = owner_u.id
+			LEFT JOIN project_user_rel pur ON p.id = pur.project_id
+			LEFT JOIN users rel_u ON pur.user_id = rel_u.id
+			WHERE owner_u.email = $1 OR rel_u.email = $1
+		)
+		ORDER BY j.created_at DESC
+	`
+
+	return p.queryJobs(query, email)
+}
+
+func (p *PostgresJobRepository) GetProjectJobs(projectId uuid.UUID) ([]Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs j
+		JOIN users u
+		ON u.id = j.owner_id
+		JOIN projects p
+		ON p.id = j.project_id
+		WHERE j.project_id = $1
+		ORDER BY j.created_at DESC
+	`
+
+	return p.queryJobs(query, projectId)
+}
+
+func (p *PostgresJobRepository) GetJobByID(id uuid.UUID) (Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs j
+		JOIN users u
+		ON u.id = j.owner_id
+		JOIN projects p
+		ON p.id = j.project_id
+		WHERE j.id = $1
+	`
+
+	job, err := scanJob(p.uow.DB().QueryRowx(query, id))
+	if err != nil {
+		return Job{}, err
+	}
+
+	job.Disks, err = p.getJobDisks(id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+func (p *PostgresJobRepository) getJobDisks(id uuid.UUID) ([]JobDisk, error) {
+	query := `
+		SELECT d.id, d.name
+		FROM job_disks jd
+		JOIN disks d
+		ON d.id = jd.disk_id
+		WHERE jd.job_id = $1
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []JobDisk
+	for rows.Next() {
+		var disk JobDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresJobRepository) CreateJob(ctx context.Context, job Job) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO jobs (id, name, project_id, owner_id, image_id, image, command, cpu, ram, status, created_at)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $6, $7, $8, $9, $10, $11)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			job.ID,
+			job.Name,
+			job.Project.ID,
+			job.Owner.Email,
+			job.ImageID,
+			job.Image,
+			job.Command,
+			job.CPU,
+			job.RAM,
+			job.Status,
+			job.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, disk := range job.Disks {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO job_disks (job_id, disk_id) VALUES ($1, $2)`,
+				job.ID,
+				disk.ID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, secret := range job.Secrets {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO job_secrets (job_id, secret_id, as_file) VALUES ($1, $2, $3)`,
+				job.ID,
+				secret.ID,
+				secret.AsFile,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UpdateJobState stores the state reported by the informers. Rows are only
+// touched when something actually changed, the informer resyncs often.
+func (p *PostgresJobRepository) UpdateJobState(state services.JobState) error {
+	query := `
+		UPDATE jobs
+		SET status = $2, started_at = $3, finished_at = $4, exit_code = $5, updated_at = NOW()
+		WHERE id = $1
+		AND (
+			status IS DISTINCT FROM $2
+			OR started_at IS DISTINCT FROM $3
+			OR finished_at IS DISTINCT FROM $4
+			OR exit_code IS DISTINCT FROM $5
+		)
+	`
+
+	jobId, err := uuid.Parse(state.JobID)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.uow.DB().Exec(query, jobId, state.Status.String(), state.StartedAt, state.FinishedAt, state.ExitCode)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresJobRepository) DeleteJob(id uuid.UUID) error {
+	query := `
+		DELETE FROM jobs WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresJobRepository) GetProjectsByName(ctx context.Context, name string) ([]JobProject, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT DISTINCT
+		    p.id,
+		    p.name
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.name ILIKE $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, "%"+name+"%")
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projectList []JobProject
+
+	for rows.Next() {
+		var project JobProject
+
+		err = rows.Scan(
+			&project.ID,
+			&project.Name,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		projectList = append(projectList, project)
+	}
+
+	return projectList, nil
+}
+
+func (p *PostgresJobRepository) GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]JobDisk, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT d.id, d.name
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		WHERE d.project_id = $1
+		AND (u.email = $2 OR d.shared)
+		AND d.provision_status = 'provisioned'
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []JobDisk
+	for rows.Next() {
+		var disk JobDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresJobRepository) GetProjectSecrets(projectId uuid.UUID) ([]JobSecret, error) {
+	query := `
+		SELECT id, name, FALSE AS as_file
+		FROM project_secrets
+		WHERE project_id = $1
+		ORDER BY name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []JobSecret
+	for rows.Next() {
+		var secret JobSecret
+		if err := rows.StructScan(&secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// GetProjectImages returns the catalog entries the project did not hide,
+// pinned ones first.
+func (p *PostgresJobRepository) GetProjectImages(projectId uuid.UUID) ([]JobImage, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram,
+			COALESCE(pi.pinned, FALSE) AS pinned
+		FROM images i
+		LEFT JOIN project_images pi
+		ON pi.image_id = i.id AND pi.project_id = $1
+		WHERE NOT COALESCE(pi.hidden, FALSE)
+		ORDER BY pinned DESC, i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []JobImage
+	for rows.Next() {
+		var image JobImage
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresJobRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanManageJob allows the job owner and the project owner.
+func (p *PostgresJobRepository) CanManageJob(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM jobs j
+		JOIN users job_u
+		ON job_u.id = j.owner_id
+		JOIN projects p
+		ON p.id = j.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE j.id = $1 AND (job_u.email = $2 OR project_u.email = $2)
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresJobRepository(uow storage.UnitOfWork) JobRepository {
+	return NewPostgresJobRepository(uow)
+}
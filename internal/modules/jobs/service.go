@@ -0,0 +1,434 @@
+package jobs
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/web/pages/jobsweb"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type JobService struct {
+	cfg          *config.Config
+	repository   JobRepository
+	kuberService *services.KuberService
+}
+
+func NewJobService(cfg *config.Config, repository JobRepository, kuberService *services.KuberService) *JobService {
+	service := &JobService{cfg: cfg, repository: repository, kuberService: kuberService}
+
+	kuberService.OnJobStateChange(func(state services.JobState) {
+		if err := repository.UpdateJobState(state); err != nil {
+			log.Printf("Error while updating job %s state: %s", state.JobID, err)
+		}
+	})
+
+	return service
+}
+
+func (s *JobService) toWebJobs(jobs []Job) []jobsweb.WebJob {
+	var webJobList []jobsweb.WebJob
+
+	for _, job := range jobs {
+		webJobList = append(webJobList, job.ToWebJob())
+	}
+
+	return webJobList
+}
+
+func (s *JobService) GetJobs(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	jobs, err := s.repository.GetJobs(r.Context())
+
+	if err != nil {
+		log.Printf("Error while fetching jobs: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(jobsweb.JobsPartial(s.toWebJobs(jobs)), w)
+	}
+	return base.Serve(jobsweb.JobsFull(s.toWebJobs(jobs)), w)
+}
+
+func (s *JobService) GetProjectJobs(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	jobs, err := s.repository.GetProjectJobs(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching jobs: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(jobsweb.JobTable(s.toWebJobs(jobs)), w)
+}
+
+func (s *JobService) GetProjectsForJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	name := r.URL.Query().Get("project_name")
+	projects, err := s.repository.GetProjectsByName(r.Context(), name)
+
+	if err != nil {
+		log.Printf("Error while fetching projects: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webProjectList []jobsweb.WebJobProject
+
+	for _, project := range projects {
+		webProjectList = append(webProjectList, project.ToWebJobProject(project))
+	}
+
+	return base.Serve(jobsweb.JobProjects(webProjectList), w)
+}
+
+func (s *JobService) GetDisksForJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(jobsweb.JobDisks(nil), w)
+	}
+
+	disks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webDiskList []jobsweb.WebJobDisk
+
+	for _, disk := range disks {
+		webDiskList = append(webDiskList, disk.ToWebJobDisk(disk))
+	}
+
+	return base.Serve(jobsweb.JobDisks(webDiskList), w)
+}
+
+func (s *JobService) GetSecretsForJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(jobsweb.JobSecrets(nil), w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	secrets, err := s.repository.GetProjectSecrets(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webSecretList []jobsweb.WebJobSecret
+
+	for _, secret := range secrets {
+		webSecretList = append(webSecretList, secret.ToWebJobSecret(secret))
+	}
+
+	return base.Serve(jobsweb.JobSecrets(webSecretList), w)
+}
+
+func (s *JobService) GetImagesForJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(jobsweb.JobImages(nil, s.cfg.Workspace.DefaultImage), w)
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webImageList []jobsweb.WebJobImage
+
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebJobImage(image))
+	}
+
+	return base.Serve(jobsweb.JobImages(webImageList, s.cfg.Workspace.DefaultImage), w)
+}
+
+var (
+	errImageRequired      = errors.New("image is required")
+	errImageNotAvailable  = errors.New("image is not available")
+	errSecretNotAvailable = errors.New("secret is not available")
+)
+
+// secretsForJob resolves the "env:<id>" and "file:<id>" picks of the form,
+// empty picks import nothing.
+func (s *JobService) secretsForJob(projectId uuid.UUID, command CreateJobCommand) ([]JobSecret, error) {
+	projectSecrets, err := s.repository.GetProjectSecrets(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedSecrets := make(map[string]JobSecret)
+	for _, secret := range projectSecrets {
+		allowedSecrets[secret.ID.String()] = secret
+	}
+
+	var secrets []JobSecret
+	for _, pick := range command.Secrets {
+		if pick == "" {
+			continue
+		}
+		mode, secretId, _ := strings.Cut(pick, ":")
+		secret, ok := allowedSecrets[secretId]
+		if !ok || (mode != "env" && mode != "file") {
+			return nil, errSecretNotAvailable
+		}
+		secret.AsFile = mode == "file"
+		secrets = append(secrets, secret)
+		delete(allowedSecrets, secretId)
+	}
+
+	return secrets, nil
+}
+
+// imageForJob resolves the catalog entry picked in the form. A raw image
+// string is only accepted while the project has no catalog entries.
+func (s *JobService) imageForJob(projectId uuid.UUID, command CreateJobCommand) (*JobImage, error) {
+	images, err := s.repository.GetProjectImages(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.ImageID == "" {
+		if len(images) > 0 || command.Image == "" {
+			return nil, errImageRequired
+		}
+		return nil, nil
+	}
+
+	imageId := uuid.MustParse(command.ImageID)
+	for _, image := range images {
+		if image.ID == imageId {
+			return &image, nil
+		}
+	}
+
+	return nil, errImageNotAvailable
+}
+
+func (s *JobService) CreateJob(w http.ResponseWriter, r *http.Request, command CreateJobCommand) http.HandlerFunc {
+	projectId := uuid.MustParse(command.ProjectID)
+	ownerEmail := r.Context().Value(consts.ContextEmail).(string)
+	ownerUsername := r.Context().Value(consts.ContextUsername).(string)
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	projectDisks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	allowedDisks := make(map[uuid.UUID]JobDisk)
+	for _, disk := range projectDisks {
+		allowedDisks[disk.ID] = disk
+	}
+
+	var disks []JobDisk
+	for _, diskId := range command.DiskIDs {
+		disk, ok := allowedDisks[uuid.MustParse(diskId)]
+		if !ok {
+			return base.ErrorServe("Disk is not available", http.StatusBadRequest, w)
+		}
+		disks = append(disks, disk)
+	}
+
+	secrets, err := s.secretsForJob(projectId, command)
+
+	if errors.Is(err, errSecretNotAvailable) {
+		return base.ErrorServe("Secret is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	image, err := s.imageForJob(projectId, command)
+
+	if errors.Is(err, errImageRequired) {
+		return base.ErrorServe("Select an image", http.StatusBadRequest, w)
+	}
+	if errors.Is(err, errImageNotAvailable) {
+		return base.ErrorServe("Image is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	job := Job{
+		ID:   uuid.New(),
+		Name: command.Name,
+		Project: JobProject{
+			ID: projectId,
+		},
+		Owner: Owner{
+			Username: ownerUsername,
+			Email:    ownerEmail,
+		},
+		Image:     command.Image,
+		Command:   command.Command,
+		CPU:       command.CPU,
+		RAM:       command.RAM,
+		Status:    services.WorkloadPending.String(),
+		Disks:     disks,
+		Secrets:   secrets,
+		CreatedAt: time.Now(),
+	}
+
+	if image != nil {
+		job.ImageID = uuid.NullUUID{UUID: image.ID, Valid: true}
+		job.Image = image.Image
+	}
+
+	err = s.repository.CreateJob(r.Context(), job)
+
+	if err != nil {
+		log.Printf("Error while creating job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	spec := job.ToSpec()
+	spec.TTL = s.cfg.Job.TTL
+
+	err = s.kuberService.CreateJob(r.Context(), spec)
+
+	if err != nil {
+		s.repository.DeleteJob(job.ID)
+		log.Printf("Error while creating kubernetes job: %s", err)
+		if apierrors.IsForbidden(err) {
+			return base.ErrorServe("Project quota exceeded", http.StatusBadRequest, w)
+		}
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	job, err = s.repository.GetJobByID(job.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(jobsweb.JobRow(job.ToWebJob()), w)
+}
+
+func (s *JobService) GetJobStatus(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	jobId, err := uuid.Parse(chi.URLParam(r, "job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	job, err := s.repository.GetJobByID(jobId)
+
+	if err != nil {
+		log.Printf("Error while fetching job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !s.repository.CanUseProject(job.Project.ID, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	return base.Serve(jobsweb.JobRow(job.ToWebJob()), w)
+}
+
+// GetJobLogs sends the browser to the log page of the newest job pod.
+func (s *JobService) GetJobLogs(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	jobId, err := uuid.Parse(chi.URLParam(r, "job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	job, err := s.repository.GetJobByID(jobId)
+
+	if err != nil {
+		log.Printf("Error while fetching job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !s.repository.CanUseProject(job.Project.ID, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	podName, ok := s.kuberService.GetJobPodName(job.GetNamespace(), job.ID.String())
+
+	if !ok {
+		return base.ErrorServe("Job has no pod", http.StatusNotFound, w)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, fmt.Sprintf("/projects/%s/pods/%s/logs", job.Project.ID, podName), http.StatusSeeOther)
+	}
+}
+
+func (s *JobService) DeleteJob(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	jobId, err := uuid.Parse(chi.URLParam(r, "job_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageJob(jobId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	job, err := s.repository.GetJobByID(jobId)
+
+	if err != nil {
+		log.Printf("Error while fetching job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.DeleteJob(r.Context(), job.GetNamespace(), job.GetJobName())
+
+	if err != nil {
+		log.Printf("Error while deleting kubernetes job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteJob(jobId)
+
+	if err != nil {
+		log.Printf("Error while deleting job: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func ProvideJobService(cfg *config.Config, repository JobRepository, kuberService *services.KuberService) *JobService {
+	return NewJobService(cfg, repository, kuberService)
+}
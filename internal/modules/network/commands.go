@@ -0,0 +1,30 @@
+package network
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+type CreateEgressRuleCommand struct {
+	CIDR        string `validate:"required,cidr" form:"cidr"`
+	Protocol    string `validate:"omitempty,oneof=TCP UDP SCTP" form:"protocol"`
+	Port        int    `validate:"gte=0,lte=65535" form:"port"`
+	Description string `validate:"max=255" form:"description"`
+}
+
+func (c *CreateEgressRuleCommand) Validate() error {
+	if err := validate.Struct(c); err != nil {
+		return err
+	}
+	if c.Port > 0 && c.Protocol == "" {
+		return errors.New("port needs a protocol")
+	}
+	return nil
+}
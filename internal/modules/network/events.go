@@ -0,0 +1,44 @@
+package network
+
+import (
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"context"
+	"fmt"
+)
+
+const EventNetworkSync = "network.sync"
+
+func (s *NetworkService) registerOutboxHandlers(dispatcher *outbox.Dispatcher) {
+	dispatcher.Register(EventNetworkSync, outbox.Handler{
+		Handle: s.syncNetworkPolicies,
+	})
+}
+
+// syncNetworkPolicies applies the current egress rules to every provisioned
+// project. Applying is idempotent, so a retry simply starts over.
+func (s *NetworkService) syncNetworkPolicies(ctx context.Context, msg outbox.Message) error {
+	rules, err := s.repository.GetEgressRules()
+	if err != nil {
+		return fmt.Errorf("fetch egress rules: %w", err)
+	}
+
+	var serviceRules []services.EgressRule
+	for _, rule := range rules {
+		serviceRules = append(serviceRules, rule.ToServiceRule())
+	}
+
+	projectIds, err := s.repository.GetProvisionedProjectIDs()
+	if err != nil {
+		return fmt.Errorf("fetch projects: %w", err)
+	}
+
+	for _, projectId := range projectIds {
+		namespace := services.ProjectNamespacePrefix + projectId.String()
+		if err := s.kuberService.ApplyNetworkPolicies(ctx, namespace, serviceRules); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package network
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type NetworkHandler struct {
+	networkService *NetworkService
+}
+
+func NewNetworkHandler(networkService *NetworkService) *NetworkHandler {
+	return &NetworkHandler{networkService: networkService}
+}
+
+func (h *NetworkHandler) GetEgressRules(w http.ResponseWriter, r *http.Request) {
+	handler := h.networkService.GetEgressRules(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *NetworkHandler) CreateEgressRule(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateEgressRuleCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.networkService.CreateEgressRule(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *NetworkHandler) DeleteEgressRule(w http.ResponseWriter, r *http.Request) {
+	handler := h.networkService.DeleteEgressRule(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideNetworkHandler(networkService *NetworkService) *NetworkHandler {
+	return NewNetworkHandler(networkService)
+}
@@ -0,0 +1,46 @@
+package network
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EgressRule is an admin configured destination every project may reach.
+type EgressRule struct {
+	ID          uuid.UUID `db:"id"`
+	CIDR        string    `db:"cidr"`
+	Protocol    string    `db:"protocol"`
+	Port        int       `db:"port"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (e *EgressRule) ToServiceRule() services.EgressRule {
+	return services.EgressRule{
+		CIDR:     e.CIDR,
+		Protocol: e.Protocol,
+		Port:     e.Port,
+	}
+}
+
+func (e *EgressRule) ToWebEgressRule() adminweb.WebEgressRule {
+	ports := "all"
+	switch {
+	case e.Protocol != "" && e.Port > 0:
+		ports = fmt.Sprintf("%s/%d", e.Protocol, e.Port)
+	case e.Protocol != "":
+		ports = e.Protocol
+	}
+
+	return adminweb.WebEgressRule{
+		ID:          e.ID,
+		CIDR:        e.CIDR,
+		Ports:       ports,
+		Description: e.Description,
+		CreatedAt:   e.CreatedAt.Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,104 @@
+package network
+
+import (
+	"aispace/internal/outbox"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type NetworkRepository interface {
+	GetEgressRules() ([]EgressRule, error)
+	CreateEgressRule(ctx context.Context, rule EgressRule, msg outbox.Message) error
+	DeleteEgressRule(ctx context.Context, id uuid.UUID, msg outbox.Message) error
+	GetProvisionedProjectIDs() ([]uuid.UUID, error)
+}
+
+type PostgresNetworkRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresNetworkRepository(uow storage.UnitOfWork) *PostgresNetworkRepository {
+	return &PostgresNetworkRepository{uow: uow}
+}
+
+func (p *PostgresNetworkRepository) GetEgressRules() ([]EgressRule, error) {
+	query := `
+		SELECT id, cidr, protocol, port, description, created_at
+		FROM egress_rules
+		ORDER BY created_at
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []EgressRule
+	for rows.Next() {
+		var rule EgressRule
+		if err := rows.StructScan(&rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (p *PostgresNetworkRepository) CreateEgressRule(ctx context.Context, rule EgressRule, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO egress_rules (id, cidr, protocol, port, description, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`
+
+		_, err := tx.ExecContext(ctx, query, rule.ID, rule.CIDR, rule.Protocol, rule.Port, rule.Description, rule.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+func (p *PostgresNetworkRepository) DeleteEgressRule(ctx context.Context, id uuid.UUID, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM egress_rules WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+func (p *PostgresNetworkRepository) GetProvisionedProjectIDs() ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM projects WHERE provision_status = $1
+	`
+
+	rows, err := p.uow.DB().Queryx(query, outbox.ProvisionProvisioned)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projectIds []uuid.UUID
+	for rows.Next() {
+		var projectId uuid.UUID
+		if err := rows.Scan(&projectId); err != nil {
+			return nil, err
+		}
+		projectIds = append(projectIds, projectId)
+	}
+
+	return projectIds, nil
+}
+
+func ProvidePostgresNetworkRepository(uow storage.UnitOfWork) NetworkRepository {
+	return NewPostgresNetworkRepository(uow)
+}
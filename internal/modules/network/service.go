@@ -0,0 +1,100 @@
+package network
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type NetworkService struct {
+	repository   NetworkRepository
+	kuberService *services.KuberService
+}
+
+func NewNetworkService(repository NetworkRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *NetworkService {
+	service := &NetworkService{repository: repository, kuberService: kuberService}
+	service.registerOutboxHandlers(dispatcher)
+	return service
+}
+
+func (s *NetworkService) GetEgressRules(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	rules, err := s.repository.GetEgressRules()
+
+	if err != nil {
+		log.Printf("Error while fetching egress rules: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webRuleList []adminweb.WebEgressRule
+
+	for _, rule := range rules {
+		webRuleList = append(webRuleList, rule.ToWebEgressRule())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.EgressRulesPartial(webRuleList), w)
+	}
+	return base.Serve(adminweb.EgressRulesFull(webRuleList), w)
+}
+
+func (s *NetworkService) CreateEgressRule(w http.ResponseWriter, r *http.Request, command CreateEgressRuleCommand) http.HandlerFunc {
+	rule := EgressRule{
+		ID:          uuid.New(),
+		CIDR:        command.CIDR,
+		Protocol:    command.Protocol,
+		Port:        command.Port,
+		Description: command.Description,
+		CreatedAt:   time.Now(),
+	}
+
+	msg, err := outbox.NewMessage(EventNetworkSync, rule.ID, struct{}{})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.CreateEgressRule(r.Context(), rule, msg)
+
+	if err != nil {
+		log.Printf("Error while creating egress rule: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.EgressRuleRow(rule.ToWebEgressRule()), w)
+}
+
+func (s *NetworkService) DeleteEgressRule(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	ruleId, err := uuid.Parse(chi.URLParam(r, "rule_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	msg, err := outbox.NewMessage(EventNetworkSync, ruleId, struct{}{})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteEgressRule(r.Context(), ruleId, msg)
+
+	if err != nil {
+		log.Printf("Error while deleting egress rule: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func ProvideNetworkService(repository NetworkRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *NetworkService {
+	return NewNetworkService(repository, kuberService, dispatcher)
+}
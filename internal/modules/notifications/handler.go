@@ -0,0 +1,31 @@
+package notifications
+
+import (
+	"net/http"
+)
+
+type NotificationHandler struct {
+	notificationService *NotificationService
+}
+
+func NewNotificationHandler(notificationService *NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	handler := h.notificationService.GetNotifications(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *NotificationHandler) GetUnreadBadge(w http.ResponseWriter, r *http.Request) {
+	handler := h.notificationService.GetUnreadBadge(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideNotificationHandler(notificationService *NotificationService) *NotificationHandler {
+	return NewNotificationHandler(notificationService)
+}
@@ -0,0 +1,26 @@
+package notifications
+
+import (
+	"aispace/web/pages/notificationsweb"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a message mlspace left for a user, e.g. a workspace the
+// culler is about to stop. ReadAt is empty until the user opened the list.
+type Notification struct {
+	ID        uuid.UUID  `db:"id"`
+	Message   string     `db:"message"`
+	CreatedAt time.Time  `db:"created_at"`
+	ReadAt    *time.Time `db:"read_at"`
+}
+
+func (n *Notification) ToWebNotification() notificationsweb.WebNotification {
+	return notificationsweb.WebNotification{
+		ID:        n.ID,
+		Message:   n.Message,
+		CreatedAt: n.CreatedAt.Format("2006-01-02 15:04"),
+		Unread:    n.ReadAt == nil,
+	}
+}
@@ -0,0 +1,82 @@
+package notifications
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+)
+
+type NotificationRepository interface {
+	GetNotifications(ctx context.Context, limit int) ([]Notification, error)
+	CountUnread(ctx context.Context) (int, error)
+	MarkAllRead(ctx context.Context) error
+}
+
+type PostgresNotificationRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresNotificationRepository(uow storage.UnitOfWork) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{uow: uow}
+}
+
+func (p *PostgresNotificationRepository) GetNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT n.id, n.message, n.created_at, n.read_at
+		FROM notifications n
+		JOIN users u
+		ON u.id = n.user_id
+		WHERE u.email = $1
+		ORDER BY n.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, email, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		var notification Notification
+		if err := rows.StructScan(&notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (p *PostgresNotificationRepository) CountUnread(ctx context.Context) (int, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT COUNT(*)
+		FROM notifications n
+		JOIN users u
+		ON u.id = n.user_id
+		WHERE u.email = $1 AND n.read_at IS NULL
+	`
+
+	var count int
+	err := p.uow.DB().QueryRowx(query, email).Scan(&count)
+	return count, err
+}
+
+func (p *PostgresNotificationRepository) MarkAllRead(ctx context.Context) error {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE read_at IS NULL AND user_id = (SELECT id FROM users WHERE email = $1)
+	`
+
+	_, err := p.uow.DB().Exec(query, email)
+	return err
+}
+
+func ProvidePostgresNotificationRepository(uow storage.UnitOfWork) NotificationRepository {
+	return NewPostgresNotificationRepository(uow)
+}
@@ -0,0 +1,59 @@
+package notifications
+
+import (
+	"aispace/internal/base"
+	"aispace/web/pages/notificationsweb"
+	"log"
+	"net/http"
+)
+
+const notificationLimit = 100
+
+type NotificationService struct {
+	repository NotificationRepository
+}
+
+func NewNotificationService(repository NotificationRepository) *NotificationService {
+	return &NotificationService{repository: repository}
+}
+
+// GetNotifications shows the latest notifications of the user, opening the
+// list marks them read.
+func (s *NotificationService) GetNotifications(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	notifications, err := s.repository.GetNotifications(r.Context(), notificationLimit)
+
+	if err != nil {
+		log.Printf("Error while fetching notifications: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if err := s.repository.MarkAllRead(r.Context()); err != nil {
+		log.Printf("Error while marking notifications read: %s", err)
+	}
+
+	var webNotificationList []notificationsweb.WebNotification
+
+	for _, notification := range notifications {
+		webNotificationList = append(webNotificationList, notification.ToWebNotification())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(notificationsweb.NotificationsPartial(webNotificationList), w)
+	}
+	return base.Serve(notificationsweb.NotificationsFull(webNotificationList), w)
+}
+
+func (s *NotificationService) GetUnreadBadge(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	count, err := s.repository.CountUnread(r.Context())
+
+	if err != nil {
+		log.Printf("Error while counting notifications: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(notificationsweb.NotificationBadge(count), w)
+}
+
+func ProvideNotificationService(repository NotificationRepository) *NotificationService {
+	return NewNotificationService(repository)
+}
@@ -0,0 +1,35 @@
+package pods
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+const defaultTailLines = 500
+
+// PodLogsQuery is read from the query string of the log routes. TailLines 0
+// reads the whole log.
+type PodLogsQuery struct {
+	Container string `validate:"max=253" form:"container"`
+	Previous  bool   `form:"previous"`
+	TailLines int64  `validate:"gte=0,lte=100000" form:"tail"`
+}
+
+func (c *PodLogsQuery) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+type PodTerminalQuery struct {
+	Container string `validate:"max=253" form:"container"`
+}
+
+func (c *PodTerminalQuery) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,141 @@
+package pods
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type PodHandler struct {
+	podService *PodService
+}
+
+func NewPodHandler(podService *PodService) *PodHandler {
+	return &PodHandler{podService: podService}
+}
+
+func decodePodLogsQuery(w http.ResponseWriter, r *http.Request) (PodLogsQuery, bool) {
+	query := PodLogsQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return query, false
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return query, false
+	}
+
+	return query, true
+}
+
+func (h *PodHandler) GetProjectPods(w http.ResponseWriter, r *http.Request) {
+	handler := h.podService.GetProjectPods(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodePodLogsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.podService.GetPodLogs(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) GetPodLogViewer(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodePodLogsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.podService.GetPodLogViewer(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodePodLogsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.podService.StreamPodLogs(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) DownloadPodLogs(w http.ResponseWriter, r *http.Request) {
+	query, ok := decodePodLogsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	handler := h.podService.DownloadPodLogs(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) GetPodTerminal(w http.ResponseWriter, r *http.Request) {
+	query := PodTerminalQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.podService.GetPodTerminal(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) ServePodTerminal(w http.ResponseWriter, r *http.Request) {
+	query := PodTerminalQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.podService.ServePodTerminal(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *PodHandler) GetTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	handler := h.podService.GetTerminalSessions(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvidePodHandler(podService *PodService) *PodHandler {
+	return NewPodHandler(podService)
+}
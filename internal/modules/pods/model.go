@@ -0,0 +1,73 @@
+package pods
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"aispace/web/pages/podsweb"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TerminalSession is the audit record of one browser terminal. EndedAt stays
+// empty while the session is open.
+type TerminalSession struct {
+	ID          uuid.UUID  `db:"id"`
+	ProjectID   uuid.UUID  `db:"project_id"`
+	ProjectName string     `db:"project_name"`
+	UserName    string     `db:"user_name"`
+	UserEmail   string     `db:"user_email"`
+	PodName     string     `db:"pod_name"`
+	Container   string     `db:"container"`
+	StartedAt   time.Time  `db:"started_at"`
+	EndedAt     *time.Time `db:"ended_at"`
+}
+
+func (t *TerminalSession) ToWebTerminalSession() adminweb.WebTerminalSession {
+	duration := "active"
+	if t.EndedAt != nil {
+		duration = t.EndedAt.Sub(t.StartedAt).Round(time.Second).String()
+	}
+
+	return adminweb.WebTerminalSession{
+		ID:          t.ID,
+		ProjectName: t.ProjectName,
+		UserName:    t.UserName,
+		UserEmail:   t.UserEmail,
+		PodName:     t.PodName,
+		Container:   t.Container,
+		StartedAt:   t.StartedAt.Format("2006-01-02 15:04:05"),
+		Duration:    duration,
+	}
+}
+
+func toWebPod(projectId uuid.UUID, pod services.PodSummary) podsweb.WebPod {
+	return podsweb.WebPod{
+		ProjectID: projectId,
+		Name:      pod.Name,
+		Workload:  pod.Workload,
+		Phase:     pod.Phase,
+		Restarts:  pod.Restarts,
+		CreatedAt: pod.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func toWebPodTerminal(projectId uuid.UUID, podName string, containers []string, container string) podsweb.WebPodTerminal {
+	return podsweb.WebPodTerminal{
+		ProjectID:  projectId,
+		PodName:    podName,
+		Containers: containers,
+		Container:  container,
+	}
+}
+
+func toWebPodLogs(projectId uuid.UUID, podName string, containers []string, query PodLogsQuery) podsweb.WebPodLogs {
+	return podsweb.WebPodLogs{
+		ProjectID:  projectId,
+		PodName:    podName,
+		Containers: containers,
+		Container:  query.Container,
+		Previous:   query.Previous,
+		TailLines:  query.TailLines,
+	}
+}
@@ -0,0 +1,187 @@
+package pods
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/internal/storage"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PodRepository interface {
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanExecInPod(projectId uuid.UUID, owner services.PodOwner, ctx context.Context) bool
+	CreateTerminalSession(session TerminalSession) error
+	EndTerminalSession(id uuid.UUID, endedAt time.Time) error
+	GetTerminalSessions(limit int) ([]TerminalSession, error)
+}
+
+type PostgresPodRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresPodRepository(uow storage.UnitOfWork) *PostgresPodRepository {
+	return &PostgresPodRepository{uow: uow}
+}
+
+func (p *PostgresPodRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanExecInPod allows who may manage the workload of the pod: the owner of a
+// workspace, the owner of a job, cron job or deployment and the project
+// owner. Helper pods are never opened, pods mlspace didn't start only by the
+// project owner.
+func (p *PostgresPodRepository) CanExecInPod(projectId uuid.UUID, owner services.PodOwner, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+
+	projectOwner := `
+		SELECT 1 FROM projects p
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE p.id = $1 AND project_u.email = $2
+	`
+
+	var query string
+	switch owner.Kind {
+	case "":
+		query = projectOwner
+	case services.PodOwnerWorkspace:
+		query = `
+			SELECT 1 FROM workspaces w
+			JOIN users u
+			ON u.id = w.owner_id
+			WHERE w.project_id = $1 AND w.id::text = $3 AND u.email = $2
+		`
+	case services.PodOwnerJob, services.PodOwnerCronJob, services.PodOwnerDeployment:
+		table := map[string]string{
+			services.PodOwnerJob:        "jobs",
+			services.PodOwnerCronJob:    "cron_jobs",
+			services.PodOwnerDeployment: "deployments",
+		}[owner.Kind]
+		query = `
+			SELECT 1 FROM ` + table + ` t
+			JOIN users u
+			ON u.id = t.owner_id
+			JOIN projects p
+			ON p.id = t.project_id
+			JOIN users project_u
+			ON project_u.id = p.owner_id
+			WHERE t.project_id = $1 AND t.id::text = $3 AND (u.email = $2 OR project_u.email = $2)
+		`
+	default:
+		return false
+	}
+
+	args := []any{projectId, email}
+	if owner.Kind != "" {
+		args = append(args, owner.ID)
+	}
+
+	rows, err := p.uow.DB().Queryx(query, args...)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresPodRepository) CreateTerminalSession(session TerminalSession) error {
+	query := `
+		INSERT INTO terminal_sessions (id, project_id, user_id, pod_name, container, started_at)
+		VALUES ($1, $2, (SELECT id FROM users WHERE email = $3), $4, $5, $6)
+	`
+
+	_, err := p.uow.DB().Exec(
+		query,
+		session.ID,
+		session.ProjectID,
+		session.UserEmail,
+		session.PodName,
+		session.Container,
+		session.StartedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresPodRepository) EndTerminalSession(id uuid.UUID, endedAt time.Time) error {
+	query := `
+		UPDATE terminal_sessions SET ended_at = $2 WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, endedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresPodRepository) GetTerminalSessions(limit int) ([]TerminalSession, error) {
+	query := `
+		SELECT
+			ts.id, ts.project_id, p.name AS project_name, u.name AS user_name, u.email AS user_email,
+			ts.pod_name, ts.container, ts.started_at, ts.ended_at
+		FROM terminal_sessions ts
+		JOIN projects p
+		ON p.id = ts.project_id
+		JOIN users u
+		ON u.id = ts.user_id
+		ORDER BY ts.started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := p.uow.DB().Queryx(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []TerminalSession
+	for rows.Next() {
+		var session TerminalSession
+		if err := rows.StructScan(&session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func ProvidePostgresPodRepository(uow storage.UnitOfWork) PodRepository {
+	return NewPostgresPodRepository(uow)
+}
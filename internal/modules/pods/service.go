@@ -0,0 +1,352 @@
+package pods
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/internal/sse"
+	"aispace/web/pages/adminweb"
+	"aispace/web/pages/podsweb"
+	"bufio"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const terminalSessionLimit = 200
+
+type PodService struct {
+	repository   PodRepository
+	kuberService *services.KuberService
+}
+
+func NewPodService(repository PodRepository, kuberService *services.KuberService) *PodService {
+	return &PodService{repository: repository, kuberService: kuberService}
+}
+
+func projectNamespace(projectId uuid.UUID) string {
+	return services.ProjectNamespacePrefix + projectId.String()
+}
+
+func (s *PodService) GetProjectPods(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	var webPodList []podsweb.WebPod
+
+	for _, pod := range s.kuberService.GetProjectPods(projectNamespace(projectId)) {
+		webPodList = append(webPodList, toWebPod(projectId, pod))
+	}
+
+	return base.Serve(podsweb.PodTable(webPodList), w)
+}
+
+// projectPod checks the project membership and resolves the container, the
+// first container of the pod when none is given.
+func (s *PodService) projectPod(w http.ResponseWriter, r *http.Request, container *string) (uuid.UUID, []string, http.HandlerFunc) {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return uuid.Nil, nil, base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return uuid.Nil, nil, base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	containers, err := s.kuberService.GetPodContainers(projectNamespace(projectId), chi.URLParam(r, "pod_name"))
+
+	if err != nil {
+		return uuid.Nil, nil, base.ErrorServe("Pod not found", http.StatusNotFound, w)
+	}
+
+	if *container == "" && len(containers) > 0 {
+		*container = containers[0]
+	}
+
+	if !slices.Contains(containers, *container) {
+		return uuid.Nil, nil, base.ErrorServe("Container not found", http.StatusNotFound, w)
+	}
+
+	return projectId, containers, nil
+}
+
+func (s *PodService) podLogs(w http.ResponseWriter, r *http.Request, query *PodLogsQuery) (podsweb.WebPodLogs, http.HandlerFunc) {
+	projectId, containers, errServe := s.projectPod(w, r, &query.Container)
+	if errServe != nil {
+		return podsweb.WebPodLogs{}, errServe
+	}
+
+	return toWebPodLogs(projectId, chi.URLParam(r, "pod_name"), containers, *query), nil
+}
+
+func (s *PodService) GetPodLogs(w http.ResponseWriter, r *http.Request, query PodLogsQuery) http.HandlerFunc {
+	if !r.URL.Query().Has("tail") {
+		query.TailLines = defaultTailLines
+	}
+
+	logs, errServe := s.podLogs(w, r, &query)
+	if errServe != nil {
+		return errServe
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(podsweb.PodLogsPartial(logs), w)
+	}
+	return base.Serve(podsweb.PodLogsFull(logs), w)
+}
+
+func (s *PodService) GetPodLogViewer(w http.ResponseWriter, r *http.Request, query PodLogsQuery) http.HandlerFunc {
+	logs, errServe := s.podLogs(w, r, &query)
+	if errServe != nil {
+		return errServe
+	}
+
+	return base.Serve(podsweb.PodLogViewer(logs), w)
+}
+
+func writeLogEnd(w http.ResponseWriter, r *http.Request, flusher http.Flusher, message string) {
+	var end strings.Builder
+	if err := podsweb.PodLogEnd(message).Render(r.Context(), &end); err != nil {
+		log.Printf("Error while rendering log end: %s", err)
+		return
+	}
+
+	sse.WriteEvent(w, flusher, "end", end.String())
+}
+
+// StreamPodLogs follows the container log and sends every line as a log
+// event. The end event tells the page to drop the connection, otherwise the
+// browser would reconnect and replay the tail.
+func (s *PodService) StreamPodLogs(w http.ResponseWriter, r *http.Request, query PodLogsQuery) http.HandlerFunc {
+	logs, errServe := s.podLogs(w, r, &query)
+	if errServe != nil {
+		return errServe
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		stream, err := s.kuberService.StreamPodLogs(r.Context(), projectNamespace(logs.ProjectID), logs.PodName, services.PodLogOptions{
+			Container: query.Container,
+			Previous:  query.Previous,
+			TailLines: query.TailLines,
+			Follow:    !query.Previous,
+		})
+
+		flusher, ok := sse.Stream(w)
+		if !ok {
+			http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		if err != nil {
+			log.Printf("Error while streaming pod logs: %s", err)
+			writeLogEnd(w, r, flusher, "Logs are not available")
+			return
+		}
+		defer stream.Close()
+
+		lines := make(chan string)
+		go func() {
+			defer close(lines)
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				select {
+				case lines <- scanner.Text():
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if err := sse.WriteComment(w, flusher, "keep-alive"); err != nil {
+					return
+				}
+			case line, ok := <-lines:
+				if !ok {
+					writeLogEnd(w, r, flusher, "End of log")
+					return
+				}
+				// the trailing empty data field keeps the line break
+				if err := sse.WriteEvent(w, flusher, "log", html.EscapeString(line)+"\n"); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *PodService) DownloadPodLogs(w http.ResponseWriter, r *http.Request, query PodLogsQuery) http.HandlerFunc {
+	logs, errServe := s.podLogs(w, r, &query)
+	if errServe != nil {
+		return errServe
+	}
+
+	stream, err := s.kuberService.StreamPodLogs(r.Context(), projectNamespace(logs.ProjectID), logs.PodName, services.PodLogOptions{
+		Container: query.Container,
+		Previous:  query.Previous,
+		TailLines: query.TailLines,
+	})
+
+	if err != nil {
+		log.Printf("Error while fetching pod logs: %s", err)
+		return base.ErrorServe("Logs are not available", http.StatusBadRequest, w)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer stream.Close()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.log"`, logs.PodName, logs.Container))
+		if _, err := io.Copy(w, stream); err != nil {
+			log.Printf("Error while downloading pod logs: %s", err)
+		}
+	}
+}
+
+// terminalPod is projectPod with the stricter check of a shell, which sees
+// the secrets and disks of the pod.
+func (s *PodService) terminalPod(w http.ResponseWriter, r *http.Request, container *string) (uuid.UUID, []string, http.HandlerFunc) {
+	projectId, containers, errServe := s.projectPod(w, r, container)
+	if errServe != nil {
+		return uuid.Nil, nil, errServe
+	}
+
+	owner, err := s.kuberService.GetPodOwner(projectNamespace(projectId), chi.URLParam(r, "pod_name"))
+
+	if err != nil {
+		return uuid.Nil, nil, base.ErrorServe("Pod not found", http.StatusNotFound, w)
+	}
+
+	if !s.repository.CanExecInPod(projectId, owner, r.Context()) {
+		return uuid.Nil, nil, base.ErrorServe("You can't brother", http.StatusForbidden, w)
+	}
+
+	return projectId, containers, nil
+}
+
+func (s *PodService) GetPodTerminal(w http.ResponseWriter, r *http.Request, query PodTerminalQuery) http.HandlerFunc {
+	projectId, containers, errServe := s.terminalPod(w, r, &query.Container)
+	if errServe != nil {
+		return errServe
+	}
+
+	terminal := toWebPodTerminal(projectId, chi.URLParam(r, "pod_name"), containers, query.Container)
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(podsweb.PodTerminalPartial(terminal), w)
+	}
+	return base.Serve(podsweb.PodTerminalFull(terminal), w)
+}
+
+// ServePodTerminal bridges the browser WebSocket to a shell in the pod. The
+// session is recorded before the shell starts and closed when it ends.
+func (s *PodService) ServePodTerminal(w http.ResponseWriter, r *http.Request, query PodTerminalQuery) http.HandlerFunc {
+	projectId, _, errServe := s.terminalPod(w, r, &query.Container)
+	if errServe != nil {
+		return errServe
+	}
+
+	if s.kuberService.GetPodStatus(projectNamespace(projectId), chi.URLParam(r, "pod_name")) != services.WorkloadRunning {
+		return base.ErrorServe("Pod is not running", http.StatusBadRequest, w)
+	}
+
+	session := TerminalSession{
+		ID:        uuid.New(),
+		ProjectID: projectId,
+		UserEmail: r.Context().Value(consts.ContextEmail).(string),
+		PodName:   chi.URLParam(r, "pod_name"),
+		Container: query.Container,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("Error while upgrading terminal connection: %s", err)
+			return
+		}
+		defer conn.Close()
+
+		session.StartedAt = time.Now()
+		if err := s.repository.CreateTerminalSession(session); err != nil {
+			log.Printf("Error while recording terminal session: %s", err)
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Something went wrong"))
+			return
+		}
+		defer func() {
+			if err := s.repository.EndTerminalSession(session.ID, time.Now()); err != nil {
+				log.Printf("Error while closing terminal session %s: %s", session.ID, err)
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		terminal := newTerminalConn(conn)
+		go terminal.readLoop(cancel)
+
+		err = s.kuberService.ExecInPod(ctx, projectNamespace(projectId), session.PodName, services.ExecOptions{
+			Container: session.Container,
+			Command:   services.TerminalShell,
+			Stdin:     terminal.stdin,
+			Stdout:    terminal,
+			Resize:    terminal.sizes,
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error while running terminal session %s: %s", session.ID, err)
+		}
+
+		terminal.writeMu.Lock()
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		terminal.writeMu.Unlock()
+	}
+}
+
+func (s *PodService) GetTerminalSessions(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	sessions, err := s.repository.GetTerminalSessions(terminalSessionLimit)
+
+	if err != nil {
+		log.Printf("Error while fetching terminal sessions: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webSessionList []adminweb.WebTerminalSession
+
+	for _, session := range sessions {
+		webSessionList = append(webSessionList, session.ToWebTerminalSession())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.TerminalSessionsPartial(webSessionList), w)
+	}
+	return base.Serve(adminweb.TerminalSessionsFull(webSessionList), w)
+}
+
+func ProvidePodService(repository PodRepository, kuberService *services.KuberService) *PodService {
+	return NewPodService(repository, kuberService)
+}
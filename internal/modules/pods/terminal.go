@@ -0,0 +1,88 @@
+package pods
+
+import (
+	"aispace/internal/services"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// the default origin check only lets the mlspace pages connect
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// terminalMessage is sent by the terminal page, input carries keystrokes and
+// resize the size of the terminal in characters.
+type terminalMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+// terminalConn adapts a browser WebSocket to the streams of an exec session.
+type terminalConn struct {
+	conn        *websocket.Conn
+	writeMu     sync.Mutex
+	stdin       *io.PipeReader
+	stdinWriter *io.PipeWriter
+	sizes       chan services.TerminalSize
+}
+
+func newTerminalConn(conn *websocket.Conn) *terminalConn {
+	stdin, stdinWriter := io.Pipe()
+
+	return &terminalConn{
+		conn:        conn,
+		stdin:       stdin,
+		stdinWriter: stdinWriter,
+		sizes:       make(chan services.TerminalSize, 4),
+	}
+}
+
+// Write sends container output to the browser.
+func (t *terminalConn) Write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// readLoop feeds stdin and the size queue until the browser goes away, then
+// closes both so the exec session ends.
+func (t *terminalConn) readLoop(done func()) {
+	defer done()
+	defer close(t.sizes)
+	defer t.stdinWriter.Close()
+
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg terminalMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "input":
+			if _, err := t.stdinWriter.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case "resize":
+			select {
+			case t.sizes <- services.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			default:
+			}
+		}
+	}
+}
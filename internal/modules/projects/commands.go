@@ -22,3 +22,14 @@ func (c *CreateProjectCommand) Validate() error {
 	err := validate.Struct(c)
 	return err
 }
+
+type UpdateProjectLimitsCommand struct {
+	CPULimit     int `validate:"required,gte=1" form:"cpu_limit"`
+	RAMLimit     int `validate:"required,gte=1" form:"ram_limit"`
+	StorageLimit int `validate:"required,gte=1" form:"storage_limit"`
+}
+
+func (c *UpdateProjectLimitsCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,179 @@
+package projects
+
+import (
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventProjectProvision = "project.provision"
+	EventProjectLimits    = "project.limits"
+	EventProjectBind      = "project.bind"
+	EventProjectUnbind    = "project.unbind"
+	EventProjectDelete    = "project.delete"
+)
+
+type ProvisionProjectPayload struct {
+	Namespace  string                 `json:"namespace"`
+	OwnerEmail string                 `json:"ownerEmail"`
+	Limits     services.ProjectLimits `json:"limits"`
+}
+
+type ProjectLimitsPayload struct {
+	Namespace string                 `json:"namespace"`
+	Limits    services.ProjectLimits `json:"limits"`
+}
+
+type DeleteProjectPayload struct {
+	// only informative, the namespace follows from the aggregate ID
+	Namespace string `json:"namespace"`
+}
+
+type ProjectBindingPayload struct {
+	Namespace string    `json:"namespace"`
+	UserID    uuid.UUID `json:"userId"`
+	// only informative, the handler binds the role of the current membership
+	Role string `json:"role,omitempty"`
+}
+
+func (s *ProjectService) registerOutboxHandlers(dispatcher *outbox.Dispatcher) {
+	dispatcher.Register(EventProjectProvision, outbox.Handler{
+		Handle:    s.provisionProject,
+		OnFailure: s.failProjectProvisioning,
+	})
+	dispatcher.Register(EventProjectLimits, outbox.Handler{
+		Handle: s.applyProjectLimits,
+	})
+	dispatcher.Register(EventProjectBind, outbox.Handler{
+		Handle: s.bindProjectUser,
+	})
+	dispatcher.Register(EventProjectUnbind, outbox.Handler{
+		Handle: s.unbindProjectUser,
+	})
+	dispatcher.Register(EventProjectDelete, outbox.Handler{
+		Handle: s.deleteProjectNamespace,
+	})
+}
+
+// provisionProject does nothing for a project deleted before the message was
+// handled, and removes the namespace again when it is deleted meanwhile.
+func (s *ProjectService) provisionProject(ctx context.Context, msg outbox.Message) error {
+	var payload ProvisionProjectPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	_, err := s.repository.GetProvisionStatus(msg.AggregateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fetch provision status: %w", err)
+	}
+
+	if err := s.kuberService.CreateNamespace(ctx, payload.Namespace, payload.OwnerEmail); err != nil {
+		return err
+	}
+
+	if err := s.kuberService.ApplyProjectLimits(ctx, payload.Namespace, payload.Limits); err != nil {
+		return err
+	}
+
+	egressRules, err := s.repository.GetEgressRules()
+	if err != nil {
+		return fmt.Errorf("fetch egress rules: %w", err)
+	}
+
+	if err := s.kuberService.ApplyNetworkPolicies(ctx, payload.Namespace, egressRules); err != nil {
+		return err
+	}
+
+	if err := s.kuberService.EnsureProjectRoles(ctx, payload.Namespace); err != nil {
+		return err
+	}
+
+	owner, err := s.repository.GetProjectOwner(msg.AggregateID)
+	if err != nil {
+		return fmt.Errorf("fetch project owner: %w", err)
+	}
+
+	if err := s.kuberService.BindProjectUser(ctx, payload.Namespace, owner.ID.String(), owner.Email, services.ProjectOwnerRole); err != nil {
+		return err
+	}
+
+	_, err = s.repository.GetProvisionStatus(msg.AggregateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.kuberService.DeleteNamespace(ctx, msg.AggregateID.String())
+	}
+	if err != nil {
+		return fmt.Errorf("fetch provision status: %w", err)
+	}
+
+	return s.repository.SetProvisionStatus(msg.AggregateID, outbox.ProvisionProvisioned)
+}
+
+func (s *ProjectService) failProjectProvisioning(ctx context.Context, msg outbox.Message, err error) error {
+	log.Printf("Project %s provisioning failed: %s", msg.AggregateID, err)
+	return s.repository.SetProvisionStatus(msg.AggregateID, outbox.ProvisionFailed)
+}
+
+func (s *ProjectService) applyProjectLimits(ctx context.Context, msg outbox.Message) error {
+	var payload ProjectLimitsPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	return s.kuberService.ApplyProjectLimits(ctx, payload.Namespace, payload.Limits)
+}
+
+// bindProjectUser and unbindProjectUser both sync the binding with the
+// membership at the time the message is handled, so a bind handled after a
+// later removal (or the other way round) can't leave a stale binding.
+func (s *ProjectService) bindProjectUser(ctx context.Context, msg outbox.Message) error {
+	return s.syncProjectUser(ctx, msg)
+}
+
+func (s *ProjectService) unbindProjectUser(ctx context.Context, msg outbox.Message) error {
+	return s.syncProjectUser(ctx, msg)
+}
+
+func (s *ProjectService) syncProjectUser(ctx context.Context, msg outbox.Message) error {
+	var payload ProjectBindingPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	role, err := s.repository.GetProjectUserRole(msg.AggregateID, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("fetch project role: %w", err)
+	}
+
+	if role == "" {
+		return s.kuberService.UnbindProjectUser(ctx, payload.Namespace, payload.UserID.String())
+	}
+
+	email, err := s.repository.GetUserEmail(payload.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.kuberService.UnbindProjectUser(ctx, payload.Namespace, payload.UserID.String())
+	}
+	if err != nil {
+		return fmt.Errorf("fetch user email: %w", err)
+	}
+
+	if err := s.kuberService.EnsureProjectRoles(ctx, payload.Namespace); err != nil {
+		return err
+	}
+
+	return s.kuberService.BindProjectUser(ctx, payload.Namespace, payload.UserID.String(), email, role)
+}
+
+func (s *ProjectService) deleteProjectNamespace(ctx context.Context, msg outbox.Message) error {
+	return s.kuberService.DeleteNamespace(ctx, msg.AggregateID.String())
+}
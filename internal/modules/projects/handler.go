@@ -92,6 +92,51 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *ProjectHandler) GetProjectNetwork(w http.ResponseWriter, r *http.Request) {
+	handler := h.projectService.GetProjectNetwork(w, r)
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *ProjectHandler) DownloadKubeconfig(w http.ResponseWriter, r *http.Request) {
+	handler := h.projectService.DownloadKubeconfig(w, r)
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *ProjectHandler) UpdateProjectLimits(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := UpdateProjectLimitsCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.projectService.UpdateProjectLimits(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
 func ProvideProjectHandler(projectService *ProjectService) *ProjectHandler {
 	return NewProjectHandler(projectService)
 }
@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"aispace/internal/services"
 	"aispace/web/pages/projectsweb"
 	"fmt"
 
@@ -8,31 +9,41 @@ import (
 )
 
 type Project struct {
-	ID           uuid.UUID `db:"id"`
-	Name         string    `db:"name"`
-	Description  string    `db:"description"`
-	Owner        Owner
-	CPULimit     int    `db:"cpu_limit"`
-	RAMLimit     int    `db:"ram_limit"`
-	StorageLimit int    `db:"storage_limit"`
-	CreatedAt    string `db:"created_at"`
+	ID              uuid.UUID `db:"id"`
+	Name            string    `db:"name"`
+	Description     string    `db:"description"`
+	Owner           Owner
+	CPULimit        int    `db:"cpu_limit"`
+	RAMLimit        int    `db:"ram_limit"`
+	StorageLimit    int    `db:"storage_limit"`
+	ProvisionStatus string `db:"provision_status"`
+	CreatedAt       string `db:"created_at"`
 }
 
 func (p *Project) GetNamespace() string {
 	return fmt.Sprintf("project-%s", p.ID.String())
 }
 
+func (p *Project) GetLimits() services.ProjectLimits {
+	return services.ProjectLimits{
+		CPU:     p.CPULimit,
+		RAM:     p.RAMLimit,
+		Storage: p.StorageLimit,
+	}
+}
+
 func (p *Project) ToWebProject(project Project) projectsweb.WebProject {
 	return projectsweb.WebProject{
-		ID:            project.ID,
-		Name:          project.Name,
-		Description:   project.Description,
-		OwnerUsername: project.Owner.Username,
-		OwnerEmail:    project.Owner.Email,
-		CPULimit:      project.CPULimit,
-		RAMLimit:      project.RAMLimit,
-		StorageLimit:  project.StorageLimit,
-		CreatedAt:     project.CreatedAt,
+		ID:              project.ID,
+		Name:            project.Name,
+		Description:     project.Description,
+		OwnerUsername:   project.Owner.Username,
+		OwnerEmail:      project.Owner.Email,
+		CPULimit:        project.CPULimit,
+		RAMLimit:        project.RAMLimit,
+		StorageLimit:    project.StorageLimit,
+		ProvisionStatus: project.ProvisionStatus,
+		CreatedAt:       project.CreatedAt,
 	}
 
 }
@@ -2,8 +2,11 @@ package projects
 
 import (
 	"aispace/internal/consts"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
 	"aispace/internal/storage"
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -14,13 +17,23 @@ type ProjectRepository interface {
 	GetProject(projectId uuid.UUID) (*Project, error)
 	GetProjectParticipants(projectId uuid.UUID) ([]Participant, error)
 	GetAvailableUsers(projectId uuid.UUID) []Participant
-	CreateProject(project Project) error
-	AddParticipants(participants []uuid.UUID, projectId uuid.UUID) error
-	DeleteParticipant(participant uuid.UUID, projectId uuid.UUID) error
+	CreateProject(ctx context.Context, project Project, msg outbox.Message) error
+	AddParticipants(ctx context.Context, participants []uuid.UUID, projectId uuid.UUID, msgs []outbox.Message) error
+	DeleteParticipant(ctx context.Context, participant uuid.UUID, projectId uuid.UUID, msg outbox.Message) error
+	GetProjectOwner(projectId uuid.UUID) (Participant, error)
+	GetUserEmail(userId uuid.UUID) (string, error)
+	GetProjectUserRole(projectId uuid.UUID, userId uuid.UUID) (string, error)
+	GetUserByEmail(email string) (Participant, error)
+	GetEgressRules() ([]services.EgressRule, error)
 	CanGetProject(projectId uuid.UUID, ctx context.Context) bool
-	DeleteProject(projectId uuid.UUID) error
+	DeleteProject(ctx context.Context, projectId uuid.UUID, msg outbox.Message) error
 	CanDeleteProject(projectId uuid.UUID, ctx context.Context) bool
 	HasDisks(projectId uuid.UUID) bool
+	CanEditProject(projectId uuid.UUID, ctx context.Context) bool
+	UpdateProjectLimits(ctx context.Context, project Project, msg outbox.Message) error
+	SetProvisionStatus(projectId uuid.UUID, status string) error
+	GetProvisionStatus(projectId uuid.UUID) (string, error)
+	GetUsedStorage(projectId uuid.UUID) (int, error)
 }
 
 type PostgresProjectRepository struct {
@@ -43,6 +56,7 @@ func (p *PostgresProjectRepository) GetProjects(ctx context.Context) ([]Project,
 		    p.cpu_limit,
 		    p.ram_limit,
 		    p.storage_limit,
+			p.provision_status,
 			p.created_at
 		FROM
 		    projects p
@@ -78,6 +92,7 @@ func (p *PostgresProjectRepository) GetProjects(ctx context.Context) ([]Project,
 			&project.CPULimit,
 			&project.RAMLimit,
 			&project.StorageLimit,
+			&project.ProvisionStatus,
 			&project.CreatedAt,
 		)
 		if err != nil {
@@ -106,6 +121,7 @@ func (p *PostgresProjectRepository) GetProject(projectId uuid.UUID) (*Project, e
 			projects.cpu_limit,
 			projects.ram_limit,
 			projects.storage_limit,
+			projects.provision_status,
 			projects.created_at
 		FROM projects
 		JOIN users ON projects.owner_id = users.id
@@ -130,6 +146,7 @@ func (p *PostgresProjectRepository) GetProject(projectId uuid.UUID) (*Project, e
 			&project.CPULimit,
 			&project.RAMLimit,
 			&project.StorageLimit,
+			&project.ProvisionStatus,
 			&project.CreatedAt,
 		)
 		if err != nil {
@@ -172,27 +189,31 @@ func (p *PostgresProjectRepository) GetProjectParticipants(projectId uuid.UUID)
 	return projectParticipants, nil
 }
 
-func (p *PostgresProjectRepository) CreateProject(project Project) error {
-	query := `
-		INSERT INTO projects (id, name, description, owner_id, cpu_limit, ram_limit, storage_limit)
-		VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $7), $4, $5, $6)
-	`
-	_, err := p.uow.DB().Exec(
-		query,
-		project.ID,
-		project.Name,
-		project.Description,
-		project.CPULimit,
-		project.RAMLimit,
-		project.StorageLimit,
-		project.Owner.Email,
-	)
+func (p *PostgresProjectRepository) CreateProject(ctx context.Context, project Project, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO projects (id, name, description, owner_id, cpu_limit, ram_limit, storage_limit, provision_status)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $7), $4, $5, $6, $8)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			project.ID,
+			project.Name,
+			project.Description,
+			project.CPULimit,
+			project.RAMLimit,
+			project.StorageLimit,
+			project.Owner.Email,
+			project.ProvisionStatus,
+		)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return outbox.Enqueue(ctx, tx, msg)
+	})
 }
 
 func (p *PostgresProjectRepository) GetAvailableUsers(projectId uuid.UUID) []Participant {
@@ -224,42 +245,116 @@ func (p *PostgresProjectRepository) GetAvailableUsers(projectId uuid.UUID) []Par
 	return participants
 }
 
-func (p *PostgresProjectRepository) AddParticipants(participants []uuid.UUID, projectId uuid.UUID) error {
-	query := "INSERT INTO project_user_rel (user_id, project_id) VALUES"
-	var args []interface{}
-	for i, participant := range participants {
-		query += fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2)
-		args = append(args, participant, projectId)
-		if i < len(participants)-1 {
-			query += ", "
-		}
+// AddParticipants stores the participants together with the messages that
+// bind them to the project Role.
+func (p *PostgresProjectRepository) AddParticipants(ctx context.Context, participants []uuid.UUID, projectId uuid.UUID, msgs []outbox.Message) error {
+	if len(participants) == 0 {
+		return nil
 	}
-	query += " ON CONFLICT DO NOTHING"
 
-	_, err := p.uow.DB().Exec(query, args...)
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := "INSERT INTO project_user_rel (user_id, project_id) VALUES"
+		var args []interface{}
+		for i, participant := range participants {
+			query += fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2)
+			args = append(args, participant, projectId)
+			if i < len(participants)-1 {
+				query += ", "
+			}
+		}
+		query += " ON CONFLICT DO NOTHING"
 
+		_, err := tx.ExecContext(ctx, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if err := outbox.Enqueue(ctx, tx, msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (p *PostgresProjectRepository) DeleteParticipant(ctx context.Context, participant uuid.UUID, projectId uuid.UUID, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM project_user_rel pur
+			WHERE pur.user_id = $1
+			AND pur.project_id = $2
+		`
+		_, err := tx.ExecContext(ctx, query, participant, projectId)
+
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+func (p *PostgresProjectRepository) GetProjectOwner(projectId uuid.UUID) (Participant, error) {
+	query := `
+		SELECT u.id, u.name, u.email
+		FROM projects p
+		JOIN users u
+		ON u.id = p.owner_id
+		WHERE p.id = $1
+	`
+
+	var owner Participant
+
+	err := p.uow.DB().QueryRowx(query, projectId).StructScan(&owner)
 	if err != nil {
-		return err
+		return Participant{}, err
 	}
 
-	return nil
+	return owner, nil
+}
 
+func (p *PostgresProjectRepository) GetUserEmail(userId uuid.UUID) (string, error) {
+	var email string
+
+	err := p.uow.DB().QueryRowx(`SELECT email FROM users WHERE id = $1`, userId).Scan(&email)
+	if err != nil {
+		return "", err
+	}
+
+	return email, nil
 }
 
-func (p *PostgresProjectRepository) DeleteParticipant(participant uuid.UUID, projectId uuid.UUID) error {
+// GetProjectUserRole returns the Role a user is bound to by the current
+// membership, empty when the user is not in the project (anymore).
+func (p *PostgresProjectRepository) GetProjectUserRole(projectId uuid.UUID, userId uuid.UUID) (string, error) {
 	query := `
-		DELETE FROM project_user_rel pur
-		WHERE pur.user_id = $1
-		AND pur.project_id = $2
+		SELECT
+			p.owner_id = $2 AS owner,
+			EXISTS (SELECT 1 FROM project_user_rel pur WHERE pur.project_id = p.id AND pur.user_id = $2) AS member
+		FROM projects p
+		WHERE p.id = $1
 	`
-	_, err := p.uow.DB().Exec(query, participant, projectId)
 
+	var owner, member bool
+	err := p.uow.DB().QueryRowx(query, projectId, userId).Scan(&owner, &member)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	switch {
+	case owner:
+		return services.ProjectOwnerRole, nil
+	case member:
+		return services.ProjectMemberRole, nil
+	}
 
+	return "", nil
 }
 
 func (p *PostgresProjectRepository) CanGetProject(projectId uuid.UUID, ctx context.Context) bool {
@@ -300,20 +395,29 @@ func (p *PostgresProjectRepository) CanGetProject(projectId uuid.UUID, ctx conte
 	return rows.Next()
 }
 
-func (p *PostgresProjectRepository) DeleteProject(projectId uuid.UUID) error {
-	query := `
-		DELETE FROM projects p
-		WHERE p.id = $1
-	`
-	_, err := p.uow.DB().Exec(query, projectId)
+// DeleteProject removes the row and enqueues the namespace deletion in one
+// transaction, the outbox retries it until the namespace is gone.
+func (p *PostgresProjectRepository) DeleteProject(ctx context.Context, projectId uuid.UUID, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM projects p
+			WHERE p.id = $1
+		`
+		_, err := tx.ExecContext(ctx, query, projectId)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
-	return nil
+		return outbox.Enqueue(ctx, tx, msg)
+	})
 }
 
 func (p *PostgresProjectRepository) CanDeleteProject(projectId uuid.UUID, ctx context.Context) bool {
+	return p.isProjectOwner(projectId, ctx)
+}
+
+// isProjectOwner backs the owner only actions, deleting and editing.
+func (p *PostgresProjectRepository) isProjectOwner(projectId uuid.UUID, ctx context.Context) bool {
 	email := ctx.Value(consts.ContextEmail).(string)
 
 	query := `
@@ -349,6 +453,101 @@ func (p *PostgresProjectRepository) HasDisks(projectId uuid.UUID) bool {
 	return rows.Next()
 }
 
+func (p *PostgresProjectRepository) CanEditProject(projectId uuid.UUID, ctx context.Context) bool {
+	return p.isProjectOwner(projectId, ctx)
+}
+
+func (p *PostgresProjectRepository) UpdateProjectLimits(ctx context.Context, project Project, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE projects
+			SET cpu_limit = $2, ram_limit = $3, storage_limit = $4, updated_at = NOW()
+			WHERE id = $1
+		`
+		_, err := tx.ExecContext(ctx, query, project.ID, project.CPULimit, project.RAMLimit, project.StorageLimit)
+
+		if err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+// GetProvisionStatus returns sql.ErrNoRows once the row is deleted.
+func (p *PostgresProjectRepository) GetProvisionStatus(projectId uuid.UUID) (string, error) {
+	query := `
+		SELECT provision_status FROM projects WHERE id = $1
+	`
+
+	var status string
+	err := p.uow.DB().QueryRowx(query, projectId).Scan(&status)
+
+	return status, err
+}
+
+func (p *PostgresProjectRepository) SetProvisionStatus(projectId uuid.UUID, status string) error {
+	query := `
+		UPDATE projects SET provision_status = $2, updated_at = NOW() WHERE id = $1
+	`
+	_, err := p.uow.DB().Exec(query, projectId, status)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresProjectRepository) GetUsedStorage(projectId uuid.UUID) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(d.size), 0) FROM disks d
+		WHERE d.project_id = $1
+	`
+
+	var used int
+	err := p.uow.DB().QueryRowx(query, projectId).Scan(&used)
+	if err != nil {
+		return 0, err
+	}
+
+	return used, nil
+}
+
+func (p *PostgresProjectRepository) GetUserByEmail(email string) (Participant, error) {
+	var user Participant
+
+	err := p.uow.DB().QueryRowx(`SELECT id, name, email FROM users WHERE email = $1`, email).StructScan(&user)
+	if err != nil {
+		return Participant{}, err
+	}
+
+	return user, nil
+}
+
+func (p *PostgresProjectRepository) GetEgressRules() ([]services.EgressRule, error) {
+	query := `
+		SELECT cidr, protocol, port FROM egress_rules ORDER BY created_at
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []services.EgressRule
+	for rows.Next() {
+		var rule services.EgressRule
+		if err := rows.Scan(&rule.CIDR, &rule.Protocol, &rule.Port); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
 func ProvidePostgresProjectRepository(uow storage.UnitOfWork) ProjectRepository {
 	return NewPostgresProjectRepository(uow)
 }
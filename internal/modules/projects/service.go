@@ -2,9 +2,12 @@ package projects
 
 import (
 	"aispace/internal/base"
+	"aispace/internal/outbox"
 	"aispace/internal/services"
 	"aispace/internal/consts"
 	"aispace/web/pages/projectsweb"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -17,8 +20,10 @@ type ProjectService struct {
 	kuberService *services.KuberService
 }
 
-func NewProjectService(repository ProjectRepository, kuberService *services.KuberService) *ProjectService {
-	return &ProjectService{repository: repository, kuberService: kuberService}
+func NewProjectService(repository ProjectRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *ProjectService {
+	service := &ProjectService{repository: repository, kuberService: kuberService}
+	service.registerOutboxHandlers(dispatcher)
+	return service
 }
 
 func (s *ProjectService) GetProjects(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
@@ -49,25 +54,29 @@ func (s *ProjectService) CreateProject(w http.ResponseWriter, r *http.Request, c
 	projectId := uuid.New()
 
 	project := Project{
-		ID:           projectId,
-		Name:         command.Name,
-		Description:  command.Description,
-		Owner:        Owner{Email: email, Username: username},
-		CPULimit:     command.CPULimit,
-		RAMLimit:     command.RAMLimit,
-		StorageLimit: command.StorageLimit,
+		ID:              projectId,
+		Name:            command.Name,
+		Description:     command.Description,
+		Owner:           Owner{Email: email, Username: username},
+		CPULimit:        command.CPULimit,
+		RAMLimit:        command.RAMLimit,
+		StorageLimit:    command.StorageLimit,
+		ProvisionStatus: outbox.ProvisionPending,
 	}
 
-	err := s.kuberService.CreateNamespace(r.Context(), project.GetNamespace(), email)
+	msg, err := outbox.NewMessage(EventProjectProvision, project.ID, ProvisionProjectPayload{
+		Namespace:  project.GetNamespace(),
+		OwnerEmail: email,
+		Limits:     project.GetLimits(),
+	})
 	if err != nil {
 		log.Println(err)
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	err = s.repository.CreateProject(project)
+	err = s.repository.CreateProject(r.Context(), project, msg)
 	if err != nil {
 		log.Println(err)
-		s.kuberService.DeleteNamespace(r.Context(), project.ID.String())
 		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
@@ -139,7 +148,42 @@ func (s *ProjectService) AddParticipants(w http.ResponseWriter, r *http.Request)
 		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
 	}
 
-	s.repository.AddParticipants(participantUUIDs, projectId)
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusForbidden, w)
+	}
+
+	owner, err := s.repository.GetProjectOwner(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	project := Project{ID: projectId}
+
+	var msgs []outbox.Message
+	for _, participantId := range participantUUIDs {
+		// the owner is bound to the owner Role, a member binding would replace it
+		if participantId == owner.ID {
+			return base.ErrorServe("The owner is already in the project", http.StatusBadRequest, w)
+		}
+
+		msg, err := outbox.NewMessage(EventProjectBind, projectId, ProjectBindingPayload{
+			Namespace: project.GetNamespace(),
+			UserID:    participantId,
+			Role:      services.ProjectMemberRole,
+		})
+		if err != nil {
+			log.Println(err)
+			return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	err = s.repository.AddParticipants(r.Context(), participantUUIDs, projectId, msgs)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
 
 	participants, err := s.repository.GetProjectParticipants(projectId)
 
@@ -152,13 +196,47 @@ func (s *ProjectService) AddParticipants(w http.ResponseWriter, r *http.Request)
 
 func (s *ProjectService) DeleteParticipant(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
 	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
 	participantId, err := uuid.Parse(chi.URLParam(r, "participant_id"))
 
 	if err != nil {
 		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
 	}
 
-	s.repository.DeleteParticipant(participantId, projectId)
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusForbidden, w)
+	}
+
+	owner, err := s.repository.GetProjectOwner(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if participantId == owner.ID {
+		return base.ErrorServe("The owner can't be removed", http.StatusBadRequest, w)
+	}
+
+	project := Project{ID: projectId}
+
+	msg, err := outbox.NewMessage(EventProjectUnbind, projectId, ProjectBindingPayload{
+		Namespace: project.GetNamespace(),
+		UserID:    participantId,
+	})
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteParticipant(r.Context(), participantId, projectId, msg)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
 
 	return base.ServeNoSwap(w)
 }
@@ -174,17 +252,29 @@ func (s *ProjectService) DeleteProject(w http.ResponseWriter, r *http.Request) h
 		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
 	}
 
+	status, err := s.repository.GetProvisionStatus(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if status == outbox.ProvisionPending {
+		return base.ErrorServe("Project is still being provisioned", http.StatusBadRequest, w)
+	}
+
 	if s.repository.HasDisks(projectId) {
 		return base.ErrorServe("Project has disks", http.StatusBadRequest, w)
 	}
 
-	err = s.repository.DeleteProject(projectId)
+	msg, err := outbox.NewMessage(EventProjectDelete, projectId, DeleteProjectPayload{
+		Namespace: fmt.Sprintf("%s%s", services.ProjectNamespacePrefix, projectId),
+	})
 	if err != nil {
 		log.Println(err)
-		return base.ErrorServe("Something went wrong", http.StatusBadRequest, w)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
 	}
 
-	err = s.kuberService.DeleteNamespace(r.Context(), projectId.String())
+	err = s.repository.DeleteProject(r.Context(), projectId, msg)
 	if err != nil {
 		log.Println(err)
 		return base.ErrorServe("Something went wrong", http.StatusBadRequest, w)
@@ -193,6 +283,130 @@ func (s *ProjectService) DeleteProject(w http.ResponseWriter, r *http.Request) h
 	return base.ServeNoSwap(w)
 }
 
-func ProvideProjectService(repository ProjectRepository, kuberService *services.KuberService) *ProjectService {
-	return NewProjectService(repository, kuberService)
+func (s *ProjectService) UpdateProjectLimits(w http.ResponseWriter, r *http.Request, command UpdateProjectLimitsCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	usedStorage, err := s.repository.GetUsedStorage(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if command.StorageLimit < usedStorage {
+		return base.ErrorServe("Storage limit is lower than used storage", http.StatusBadRequest, w)
+	}
+
+	project, err := s.repository.GetProject(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	project.CPULimit = command.CPULimit
+	project.RAMLimit = command.RAMLimit
+	project.StorageLimit = command.StorageLimit
+
+	msg, err := outbox.NewMessage(EventProjectLimits, project.ID, ProjectLimitsPayload{
+		Namespace: project.GetNamespace(),
+		Limits:    project.GetLimits(),
+	})
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.UpdateProjectLimits(r.Context(), *project, msg)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(projectsweb.ProjectLimits(project.ToWebProject(*project)), w)
+}
+
+// GetProjectNetwork shows the project owner every NetworkPolicy of the
+// namespace, the managed ones and those added with kubectl.
+func (s *ProjectService) GetProjectNetwork(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanEditProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	project := Project{ID: projectId}
+
+	policies, err := s.kuberService.GetNetworkPolicies(r.Context(), project.GetNamespace())
+
+	if err != nil {
+		log.Printf("Error while fetching network policies: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webPolicies []projectsweb.WebNetworkPolicy
+	for _, policy := range policies {
+		webPolicies = append(webPolicies, projectsweb.WebNetworkPolicy{
+			Name:    policy.Name,
+			Ingress: policy.Ingress,
+			Egress:  policy.Egress,
+		})
+	}
+
+	return base.Serve(projectsweb.ProjectNetwork(webPolicies), w)
+}
+
+// DownloadKubeconfig serves a kubeconfig for the project namespace. It only
+// reads, the RoleBinding of the user is kept by provisioning, the membership
+// events and the reconciler.
+func (s *ProjectService) DownloadKubeconfig(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanGetProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	project, err := s.repository.GetProject(projectId)
+	if err != nil {
+		log.Println(err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if project.ProvisionStatus != outbox.ProvisionProvisioned {
+		return base.ErrorServe("Project is not ready", http.StatusBadRequest, w)
+	}
+
+	email := r.Context().Value(consts.ContextEmail).(string)
+	kubeconfig, err := s.kuberService.ProjectKubeconfig(project.GetNamespace(), email)
+	if errors.Is(err, services.ErrKubeconfigDisabled) {
+		return base.ErrorServe("Kubeconfig download is not configured", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while building kubeconfig: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kubeconfig-%s.yaml"`, project.GetNamespace()))
+		w.Write(kubeconfig)
+	}
+}
+
+func ProvideProjectService(repository ProjectRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *ProjectService {
+	return NewProjectService(repository, kuberService, dispatcher)
 }
@@ -0,0 +1,36 @@
+package reconciler
+
+import "net/http"
+
+type ReconcilerHandler struct {
+	reconcilerService *ReconcilerService
+}
+
+func NewReconcilerHandler(reconcilerService *ReconcilerService) *ReconcilerHandler {
+	return &ReconcilerHandler{reconcilerService: reconcilerService}
+}
+
+func (h *ReconcilerHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	handler := h.reconcilerService.GetReport(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ReconcilerHandler) RunCheck(w http.ResponseWriter, r *http.Request) {
+	handler := h.reconcilerService.RunCheck(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *ReconcilerHandler) RunRepair(w http.ResponseWriter, r *http.Request) {
+	handler := h.reconcilerService.RunRepair(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideReconcilerHandler(reconcilerService *ReconcilerService) *ReconcilerHandler {
+	return NewReconcilerHandler(reconcilerService)
+}
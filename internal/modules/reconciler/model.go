@@ -0,0 +1,122 @@
+package reconciler
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DriftKind string
+
+const (
+	DriftMissingNamespace  DriftKind = "Missing namespace"
+	DriftMissingPVC        DriftKind = "Missing PVC"
+	DriftOrphanedNamespace DriftKind = "Orphaned namespace"
+	DriftOrphanedPVC       DriftKind = "Orphaned PVC"
+)
+
+type ProjectRef struct {
+	ID              uuid.UUID `db:"id"`
+	OwnerID         uuid.UUID `db:"owner_id"`
+	OwnerEmail      string    `db:"email"`
+	CPULimit        int       `db:"cpu_limit"`
+	RAMLimit        int       `db:"ram_limit"`
+	StorageLimit    int       `db:"storage_limit"`
+	ProvisionStatus string    `db:"provision_status"`
+}
+
+type MemberRef struct {
+	ID    uuid.UUID `db:"id"`
+	Email string    `db:"email"`
+}
+
+type DiskRef struct {
+	ID              uuid.UUID `db:"id"`
+	ProjectID       uuid.UUID `db:"project_id"`
+	OwnerEmail      string    `db:"email"`
+	Size            int       `db:"size"`
+	Shared          bool      `db:"shared"`
+	ClassName       string    `db:"class_name"`
+	ProvisionStatus string    `db:"provision_status"`
+}
+
+// Drift is a single difference between Postgres and the cluster. Project or
+// Disk is set for the drifts that are repaired from the DB row.
+type Drift struct {
+	Kind      DriftKind
+	Namespace string
+	Name      string
+	Project   *ProjectRef
+	Disk      *DiskRef
+}
+
+type Report struct {
+	CheckedAt    time.Time
+	Drifts       []Drift
+	Repaired     int
+	RepairErrors []string
+}
+
+func (p *ProjectRef) GetNamespace() string {
+	return fmt.Sprintf("%s%s", services.ProjectNamespacePrefix, p.ID.String())
+}
+
+func (p *ProjectRef) GetLimits() services.ProjectLimits {
+	return services.ProjectLimits{
+		CPU:     p.CPULimit,
+		RAM:     p.RAMLimit,
+		Storage: p.StorageLimit,
+	}
+}
+
+func (d *DiskRef) GetNamespace() string {
+	return fmt.Sprintf("%s%s", services.ProjectNamespacePrefix, d.ProjectID.String())
+}
+
+func (d *DiskRef) GetPVCName() string {
+	return fmt.Sprintf("%s%s", services.DiskPVCPrefix, d.ID.String())
+}
+
+func (d *DiskRef) GetPVCSize() string {
+	return fmt.Sprintf("%dGi", d.Size)
+}
+
+func (d *DiskRef) GetPVCStorage() services.PVCStorage {
+	return services.PVCStorage{
+		ClassName: d.ClassName,
+		Shared:    d.Shared,
+	}
+}
+
+func (d *Drift) String() string {
+	if d.Namespace == "" {
+		return fmt.Sprintf("%s %s", d.Kind, d.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", d.Kind, d.Namespace, d.Name)
+}
+
+func (r *Report) ToWebReport() adminweb.WebReconcileReport {
+	var drifts []adminweb.WebDrift
+	for _, drift := range r.Drifts {
+		drifts = append(drifts, adminweb.WebDrift{
+			Kind:      string(drift.Kind),
+			Namespace: drift.Namespace,
+			Name:      drift.Name,
+		})
+	}
+
+	checkedAt := "never"
+	if !r.CheckedAt.IsZero() {
+		checkedAt = r.CheckedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return adminweb.WebReconcileReport{
+		CheckedAt:    checkedAt,
+		Drifts:       drifts,
+		Repaired:     r.Repaired,
+		RepairErrors: r.RepairErrors,
+	}
+}
@@ -0,0 +1,154 @@
+package reconciler
+
+import (
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type ReconcilerRepository interface {
+	GetProjects() ([]ProjectRef, error)
+	GetDisks() ([]DiskRef, error)
+	GetProjectMembers(projectId uuid.UUID) ([]MemberRef, error)
+	GetEgressRules() ([]services.EgressRule, error)
+	MarkProjectProvisioned(id uuid.UUID) error
+	MarkDiskProvisioned(id uuid.UUID) error
+}
+
+type PostgresReconcilerRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresReconcilerRepository(uow storage.UnitOfWork) *PostgresReconcilerRepository {
+	return &PostgresReconcilerRepository{uow: uow}
+}
+
+func (p *PostgresReconcilerRepository) GetProjects() ([]ProjectRef, error) {
+	query := `
+		SELECT p.id, p.owner_id, u.email, p.cpu_limit, p.ram_limit, p.storage_limit, p.provision_status
+		FROM projects p
+		JOIN users u
+		ON u.id = p.owner_id
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []ProjectRef
+	for rows.Next() {
+		var project ProjectRef
+		if err := rows.StructScan(&project); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+func (p *PostgresReconcilerRepository) GetDisks() ([]DiskRef, error) {
+	query := `
+		SELECT d.id, d.project_id, u.email, d.size, d.shared, COALESCE(sc.class_name, '') AS class_name, d.provision_status
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		LEFT JOIN storage_classes sc
+		ON sc.id = d.storage_class_id
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []DiskRef
+	for rows.Next() {
+		var disk DiskRef
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresReconcilerRepository) GetProjectMembers(projectId uuid.UUID) ([]MemberRef, error) {
+	query := `
+		SELECT u.id, u.email
+		FROM project_user_rel pur
+		JOIN users u
+		ON u.id = pur.user_id
+		WHERE pur.project_id = $1
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []MemberRef
+	for rows.Next() {
+		var member MemberRef
+		if err := rows.StructScan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (p *PostgresReconcilerRepository) GetEgressRules() ([]services.EgressRule, error) {
+	query := `
+		SELECT cidr, protocol, port FROM egress_rules ORDER BY created_at
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []services.EgressRule
+	for rows.Next() {
+		var rule services.EgressRule
+		if err := rows.Scan(&rule.CIDR, &rule.Protocol, &rule.Port); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (p *PostgresReconcilerRepository) MarkProjectProvisioned(id uuid.UUID) error {
+	query := `
+		UPDATE projects SET provision_status = $2, updated_at = NOW() WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, outbox.ProvisionProvisioned)
+
+	return err
+}
+
+func (p *PostgresReconcilerRepository) MarkDiskProvisioned(id uuid.UUID) error {
+	query := `
+		UPDATE disks SET provision_status = $2, updated_at = NOW() WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, outbox.ProvisionProvisioned)
+
+	return err
+}
+
+func ProvidePostgresReconcilerRepository(uow storage.UnitOfWork) ReconcilerRepository {
+	return NewPostgresReconcilerRepository(uow)
+}
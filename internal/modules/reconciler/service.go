@@ -0,0 +1,358 @@
+package reconciler
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReconcilerService periodically compares the projects and disks tables with
+// the project-* namespaces and disk-* PVCs of the cluster.
+type ReconcilerService struct {
+	cfg          *config.Config
+	repository   ReconcilerRepository
+	kuberService *services.KuberService
+	mu           sync.RWMutex
+	report       Report
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+func NewReconcilerService(cfg *config.Config, repository ReconcilerRepository, kuberService *services.KuberService) *ReconcilerService {
+	return &ReconcilerService{
+		cfg:          cfg,
+		repository:   repository,
+		kuberService: kuberService,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+func (s *ReconcilerService) Start() {
+	go s.run()
+}
+
+func (s *ReconcilerService) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+	fmt.Println("Reconciler: stopped.")
+}
+
+func (s *ReconcilerService) run() {
+	defer close(s.doneCh)
+
+	if err := s.backfill(context.Background()); err != nil {
+		log.Printf("Reconciler: backfill: %s", err)
+	}
+
+	ticker := time.NewTicker(s.cfg.Reconcile.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			var err error
+			if s.cfg.Reconcile.AutoRepair {
+				_, err = s.Repair(context.Background())
+			} else {
+				_, err = s.Check(context.Background())
+			}
+			if err != nil {
+				log.Printf("Reconciler: %s", err)
+			}
+		}
+	}
+}
+
+func (s *ReconcilerService) LastReport() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.report
+}
+
+func (s *ReconcilerService) setReport(report Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report = report
+}
+
+// detect lists the cluster before reading the DB, so an entity created in
+// between is seen in the DB and never reported as orphaned.
+func (s *ReconcilerService) detect(ctx context.Context) ([]Drift, error) {
+	namespaceList, err := s.kuberService.ListProjectNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pvcList := s.kuberService.ListDiskPVCs()
+
+	projects, err := s.repository.GetProjects()
+	if err != nil {
+		return nil, fmt.Errorf("fetch projects: %w", err)
+	}
+	disks, err := s.repository.GetDisks()
+	if err != nil {
+		return nil, fmt.Errorf("fetch disks: %w", err)
+	}
+
+	namespaces := make(map[string]bool)
+	for _, namespace := range namespaceList {
+		namespaces[namespace.Name] = true
+	}
+	pvcs := make(map[string]bool)
+	for _, pvc := range pvcList {
+		pvcs[pvc.Namespace+"/"+pvc.Name] = true
+	}
+
+	var drifts []Drift
+
+	knownNamespaces := make(map[string]bool)
+	for i := range projects {
+		project := &projects[i]
+		knownNamespaces[project.GetNamespace()] = true
+
+		// the outbox is still working on it
+		if project.ProvisionStatus == outbox.ProvisionPending {
+			continue
+		}
+		if !namespaces[project.GetNamespace()] {
+			drifts = append(drifts, Drift{Kind: DriftMissingNamespace, Name: project.GetNamespace(), Project: project})
+		}
+	}
+
+	knownPVCs := make(map[string]bool)
+	for i := range disks {
+		disk := &disks[i]
+		knownPVCs[disk.GetNamespace()+"/"+disk.GetPVCName()] = true
+
+		if disk.ProvisionStatus == outbox.ProvisionPending || disk.ProvisionStatus == outbox.ProvisionDeleting {
+			continue
+		}
+		if !pvcs[disk.GetNamespace()+"/"+disk.GetPVCName()] {
+			drifts = append(drifts, Drift{Kind: DriftMissingPVC, Namespace: disk.GetNamespace(), Name: disk.GetPVCName(), Disk: disk})
+		}
+	}
+
+	for _, namespace := range namespaceList {
+		if !knownNamespaces[namespace.Name] {
+			drifts = append(drifts, Drift{Kind: DriftOrphanedNamespace, Name: namespace.Name})
+		}
+	}
+
+	for _, pvc := range pvcList {
+		// deleting the orphaned namespace takes its claims with it
+		if !knownNamespaces[pvc.Namespace] {
+			continue
+		}
+		if !knownPVCs[pvc.Namespace+"/"+pvc.Name] {
+			drifts = append(drifts, Drift{Kind: DriftOrphanedPVC, Namespace: pvc.Namespace, Name: pvc.Name})
+		}
+	}
+
+	return drifts, nil
+}
+
+// Check detects drift and stores the report without touching the cluster.
+func (s *ReconcilerService) Check(ctx context.Context) (Report, error) {
+	drifts, err := s.detect(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	for _, drift := range drifts {
+		log.Printf("Reconciler: %s", drift.String())
+	}
+
+	report := Report{CheckedAt: time.Now(), Drifts: drifts}
+	s.setReport(report)
+
+	return report, nil
+}
+
+// Repair detects drift again and fixes every item, the cluster follows the DB.
+func (s *ReconcilerService) Repair(ctx context.Context) (Report, error) {
+	drifts, err := s.detect(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{CheckedAt: time.Now()}
+
+	for _, drift := range drifts {
+		if err := s.repair(ctx, drift); err != nil {
+			log.Printf("Reconciler: repair of %s failed: %s", drift.String(), err)
+			report.Drifts = append(report.Drifts, drift)
+			report.RepairErrors = append(report.RepairErrors, fmt.Sprintf("%s: %s", drift.String(), err))
+			continue
+		}
+		log.Printf("Reconciler: repaired %s", drift.String())
+		report.Repaired++
+	}
+
+	s.setReport(report)
+
+	return report, nil
+}
+
+func (s *ReconcilerService) repairProjectRBAC(ctx context.Context, project ProjectRef) error {
+	namespace := project.GetNamespace()
+
+	if err := s.kuberService.EnsureProjectRoles(ctx, namespace); err != nil {
+		return err
+	}
+	if err := s.kuberService.BindProjectUser(ctx, namespace, project.OwnerID.String(), project.OwnerEmail, services.ProjectOwnerRole); err != nil {
+		return err
+	}
+
+	members, err := s.repository.GetProjectMembers(project.ID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := s.kuberService.BindProjectUser(ctx, namespace, member.ID.String(), member.Email, services.ProjectMemberRole); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfill brings the managed objects of existing project namespaces up to
+// date once at startup, namespaces created by an older mlspace keep what was
+// applied back then otherwise.
+func (s *ReconcilerService) backfill(ctx context.Context) error {
+	namespaceList, err := s.kuberService.ListProjectNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	projects, err := s.repository.GetProjects()
+	if err != nil {
+		return fmt.Errorf("fetch projects: %w", err)
+	}
+
+	egressRules, err := s.repository.GetEgressRules()
+	if err != nil {
+		return fmt.Errorf("fetch egress rules: %w", err)
+	}
+
+	namespaces := make(map[string]bool)
+	for _, namespace := range namespaceList {
+		namespaces[namespace.Name] = true
+	}
+
+	for _, project := range projects {
+		if project.ProvisionStatus != outbox.ProvisionProvisioned {
+			continue
+		}
+		// namespaces from before the managed-by label are invisible to detect
+		if !namespaces[project.GetNamespace()] {
+			exists, err := s.kuberService.AdoptProjectNamespace(ctx, project.GetNamespace())
+			if err != nil {
+				log.Printf("Reconciler: backfill of %s failed: %s", project.GetNamespace(), err)
+			}
+			if !exists {
+				continue
+			}
+		}
+		if err := s.kuberService.ApplyProjectLimits(ctx, project.GetNamespace(), project.GetLimits()); err != nil {
+			log.Printf("Reconciler: backfill of %s failed: %s", project.GetNamespace(), err)
+		}
+		if err := s.kuberService.ApplyNetworkPolicies(ctx, project.GetNamespace(), egressRules); err != nil {
+			log.Printf("Reconciler: backfill of %s failed: %s", project.GetNamespace(), err)
+		}
+		if err := s.repairProjectRBAC(ctx, project); err != nil {
+			log.Printf("Reconciler: backfill of %s failed: %s", project.GetNamespace(), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReconcilerService) repair(ctx context.Context, drift Drift) error {
+	switch drift.Kind {
+	case DriftMissingNamespace:
+		project := drift.Project
+		if err := s.kuberService.CreateNamespace(ctx, project.GetNamespace(), project.OwnerEmail); err != nil {
+			return err
+		}
+		if err := s.kuberService.ApplyProjectLimits(ctx, project.GetNamespace(), project.GetLimits()); err != nil {
+			return err
+		}
+		egressRules, err := s.repository.GetEgressRules()
+		if err != nil {
+			return err
+		}
+		if err := s.kuberService.ApplyNetworkPolicies(ctx, project.GetNamespace(), egressRules); err != nil {
+			return err
+		}
+		if err := s.repairProjectRBAC(ctx, *project); err != nil {
+			return err
+		}
+		return s.repository.MarkProjectProvisioned(project.ID)
+	case DriftMissingPVC:
+		disk := drift.Disk
+		_, err := s.kuberService.CreatePVC(ctx, disk.GetNamespace(), disk.GetPVCName(), disk.GetPVCSize(), disk.OwnerEmail, disk.GetPVCStorage())
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		return s.repository.MarkDiskProvisioned(disk.ID)
+	case DriftOrphanedNamespace:
+		if !services.IsProjectNamespace(drift.Name) {
+			return fmt.Errorf("namespace %s is not a project namespace", drift.Name)
+		}
+		return s.kuberService.DeleteNamespace(ctx, strings.TrimPrefix(drift.Name, services.ProjectNamespacePrefix))
+	case DriftOrphanedPVC:
+		return s.kuberService.DeletePVC(ctx, drift.Namespace, drift.Name)
+	}
+
+	return fmt.Errorf("unknown drift %q", drift.Kind)
+}
+
+func (s *ReconcilerService) GetReport(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	report := s.LastReport()
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.ReconcilerPartial(report.ToWebReport()), w)
+	}
+	return base.Serve(adminweb.ReconcilerFull(report.ToWebReport()), w)
+}
+
+func (s *ReconcilerService) RunCheck(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	report, err := s.Check(r.Context())
+
+	if err != nil {
+		log.Printf("Error while checking cluster drift: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.ReconcileReport(report.ToWebReport()), w)
+}
+
+func (s *ReconcilerService) RunRepair(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	report, err := s.Repair(r.Context())
+
+	if err != nil {
+		log.Printf("Error while repairing cluster drift: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.ReconcileReport(report.ToWebReport()), w)
+}
+
+func ProvideReconcilerService(cfg *config.Config, repository ReconcilerRepository, kuberService *services.KuberService) *ReconcilerService {
+	return NewReconcilerService(cfg, repository, kuberService)
+}
@@ -0,0 +1,35 @@
+package secrets
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// CreateSecretCommand creates a secret with its first key, more keys are
+// set on the secret afterwards.
+type CreateSecretCommand struct {
+	Name  string `validate:"required,min=1,max=63" form:"name"`
+	Key   string `validate:"required,max=253" form:"key"`
+	Value string `validate:"required,max=65536" form:"value"`
+}
+
+func (c *CreateSecretCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// SetSecretKeyCommand adds a key or overwrites its value.
+type SetSecretKeyCommand struct {
+	Key   string `validate:"required,max=253" form:"key"`
+	Value string `validate:"required,max=65536" form:"value"`
+}
+
+func (c *SetSecretKeyCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
@@ -0,0 +1,29 @@
+package secrets
+
+import (
+	"aispace/internal/outbox"
+	"context"
+	"fmt"
+)
+
+const EventSecretDelete = "secret.delete"
+
+type DeleteSecretPayload struct {
+	Namespace  string `json:"namespace"`
+	SecretName string `json:"secretName"`
+}
+
+func (s *SecretService) registerOutboxHandlers(dispatcher *outbox.Dispatcher) {
+	dispatcher.Register(EventSecretDelete, outbox.Handler{
+		Handle: s.deleteSecret,
+	})
+}
+
+func (s *SecretService) deleteSecret(ctx context.Context, msg outbox.Message) error {
+	var payload DeleteSecretPayload
+	if err := msg.Decode(&payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	return s.kuberService.DeleteSecret(ctx, payload.Namespace, payload.SecretName)
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type SecretHandler struct {
+	secretService *SecretService
+}
+
+func NewSecretHandler(secretService *SecretService) *SecretHandler {
+	return &SecretHandler{secretService: secretService}
+}
+
+func (h *SecretHandler) GetProjectSecrets(w http.ResponseWriter, r *http.Request) {
+	handler := h.secretService.GetProjectSecrets(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *SecretHandler) CreateSecret(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateSecretCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.secretService.CreateSecret(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *SecretHandler) SetSecretKey(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := SetSecretKeyCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.secretService.SetSecretKey(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *SecretHandler) RemoveSecretKey(w http.ResponseWriter, r *http.Request) {
+	handler := h.secretService.RemoveSecretKey(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *SecretHandler) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+	handler := h.secretService.DeleteSecret(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideSecretHandler(secretService *SecretService) *SecretHandler {
+	return NewSecretHandler(secretService)
+}
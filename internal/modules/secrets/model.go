@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"aispace/web/pages/secretsweb"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditCreated    = "created"
+	AuditKeySet     = "key set"
+	AuditKeyRemoved = "key removed"
+	AuditDeleted    = "deleted"
+)
+
+// keys become environment variables or file names, so only names valid
+// as both are accepted
+var secretKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type ProjectSecret struct {
+	ID                uuid.UUID `db:"id"`
+	Name              string    `db:"name"`
+	ProjectID         uuid.UUID `db:"project_id"`
+	Owner             Owner
+	ProjectOwnerEmail string
+	Keys              []string
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+type Owner struct {
+	Username string `db:"name"`
+	Email    string `db:"email"`
+}
+
+type SecretAuditEntry struct {
+	SecretName string    `db:"secret_name"`
+	Action     string    `db:"action"`
+	Key        *string   `db:"key"`
+	Username   string    `db:"username"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (s *ProjectSecret) GetNamespace() string {
+	return fmt.Sprintf("project-%s", s.ProjectID.String())
+}
+
+func (s *ProjectSecret) GetSecretName() string {
+	return fmt.Sprintf("secret-%s", s.ID.String())
+}
+
+// ToWebSecret marks the secret manageable for its owner and the project
+// owner, the same rule CanManageSecret applies.
+func (s *ProjectSecret) ToWebSecret(email string) secretsweb.WebSecret {
+	return secretsweb.WebSecret{
+		ID:            s.ID,
+		Name:          s.Name,
+		ProjectID:     s.ProjectID,
+		OwnerUsername: s.Owner.Username,
+		Keys:          s.Keys,
+		UpdatedAt:     s.UpdatedAt.Format("2006-01-02 15:04"),
+		CanManage:     email == s.Owner.Email || email == s.ProjectOwnerEmail,
+	}
+}
+
+func (e *SecretAuditEntry) ToWebSecretAuditEntry() secretsweb.WebSecretAuditEntry {
+	key := "-"
+	if e.Key != nil {
+		key = *e.Key
+	}
+
+	return secretsweb.WebSecretAuditEntry{
+		SecretName: e.SecretName,
+		Action:     e.Action,
+		Key:        key,
+		Username:   e.Username,
+		CreatedAt:  e.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,360 @@
+package secrets
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/outbox"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type SecretRepository interface {
+	GetProjectSecrets(projectId uuid.UUID) ([]ProjectSecret, error)
+	GetSecretByID(id uuid.UUID) (ProjectSecret, error)
+	CreateSecret(ctx context.Context, secret ProjectSecret, apply func() error) error
+	SetSecretKey(ctx context.Context, secret ProjectSecret, key string, apply func() error) error
+	RemoveSecretKey(ctx context.Context, secret ProjectSecret, key string, apply func() error) error
+	DeleteSecret(ctx context.Context, secret ProjectSecret, msg outbox.Message) error
+	GetSecretAudit(projectId uuid.UUID, limit int) ([]SecretAuditEntry, error)
+	IsSecretInUse(id uuid.UUID) (bool, error)
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanManageSecret(id uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresSecretRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresSecretRepository(uow storage.UnitOfWork) *PostgresSecretRepository {
+	return &PostgresSecretRepository{uow: uow}
+}
+
+func (p *PostgresSecretRepository) GetProjectSecrets(projectId uuid.UUID) ([]ProjectSecret, error) {
+	query := `
+		SELECT s.id, s.name, s.project_id, s.created_at, s.updated_at, u.name, u.email, project_u.email
+		FROM project_secrets s
+		JOIN users u
+		ON u.id = s.owner_id
+		JOIN projects p
+		ON p.id = s.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE s.project_id = $1
+		ORDER BY s.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secretList []ProjectSecret
+
+	for rows.Next() {
+		var secret ProjectSecret
+
+		err = rows.Scan(
+			&secret.ID,
+			&secret.Name,
+			&secret.ProjectID,
+			&secret.CreatedAt,
+			&secret.UpdatedAt,
+			&secret.Owner.Username,
+			&secret.Owner.Email,
+			&secret.ProjectOwnerEmail,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		secretList = append(secretList, secret)
+	}
+
+	for i := range secretList {
+		keys, err := p.getSecretKeys(secretList[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		secretList[i].Keys = keys
+	}
+
+	return secretList, nil
+}
+
+func (p *PostgresSecretRepository) GetSecretByID(id uuid.UUID) (ProjectSecret, error) {
+	query := `
+		SELECT s.id, s.name, s.project_id, s.created_at, s.updated_at, u.name, u.email, project_u.email
+		FROM project_secrets s
+		JOIN users u
+		ON u.id = s.owner_id
+		JOIN projects p
+		ON p.id = s.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE s.id = $1
+	`
+
+	var secret ProjectSecret
+
+	err := p.uow.DB().QueryRowx(query, id).Scan(
+		&secret.ID,
+		&secret.Name,
+		&secret.ProjectID,
+		&secret.CreatedAt,
+		&secret.UpdatedAt,
+		&secret.Owner.Username,
+		&secret.Owner.Email,
+		&secret.ProjectOwnerEmail,
+	)
+	if err != nil {
+		return ProjectSecret{}, err
+	}
+
+	secret.Keys, err = p.getSecretKeys(id)
+	if err != nil {
+		return ProjectSecret{}, err
+	}
+
+	return secret, nil
+}
+
+func (p *PostgresSecretRepository) getSecretKeys(id uuid.UUID) ([]string, error) {
+	query := `
+		SELECT key FROM project_secret_keys WHERE secret_id = $1 ORDER BY key
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// audit writes the entry in the same transaction as the change it records.
+// Changes of the Kubernetes Secret run as apply at the end of the
+// transaction, a failing one rolls the row and its audit entry back.
+func audit(ctx context.Context, tx *sql.Tx, secret ProjectSecret, action string, key *string) error {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		INSERT INTO project_secret_audit (project_id, user_id, secret_name, action, key)
+		VALUES ($1, (SELECT id FROM users WHERE email = $2), $3, $4, $5)
+	`
+
+	_, err := tx.ExecContext(ctx, query, secret.ProjectID, email, secret.Name, action, key)
+	return err
+}
+
+func (p *PostgresSecretRepository) CreateSecret(ctx context.Context, secret ProjectSecret, apply func() error) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO project_secrets (id, name, project_id, owner_id, created_at, updated_at)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $5)
+		`
+		_, err := tx.ExecContext(ctx, query, secret.ID, secret.Name, secret.ProjectID, secret.Owner.Email, secret.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range secret.Keys {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO project_secret_keys (secret_id, key) VALUES ($1, $2)`,
+				secret.ID,
+				key,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := audit(ctx, tx, secret, AuditCreated, nil); err != nil {
+			return err
+		}
+
+		return apply()
+	})
+}
+
+func (p *PostgresSecretRepository) SetSecretKey(ctx context.Context, secret ProjectSecret, key string, apply func() error) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO project_secret_keys (secret_id, key)
+			VALUES ($1, $2)
+			ON CONFLICT (secret_id, key) DO UPDATE SET updated_at = NOW()
+		`
+		_, err := tx.ExecContext(ctx, query, secret.ID, key)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE project_secrets SET updated_at = NOW() WHERE id = $1`, secret.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(ctx, tx, secret, AuditKeySet, &key); err != nil {
+			return err
+		}
+
+		return apply()
+	})
+}
+
+func (p *PostgresSecretRepository) RemoveSecretKey(ctx context.Context, secret ProjectSecret, key string, apply func() error) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM project_secret_keys WHERE secret_id = $1 AND key = $2`, secret.ID, key)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE project_secrets SET updated_at = NOW() WHERE id = $1`, secret.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(ctx, tx, secret, AuditKeyRemoved, &key); err != nil {
+			return err
+		}
+
+		return apply()
+	})
+}
+
+// DeleteSecret removes the row and enqueues the deletion of the Kubernetes
+// Secret, which is retried until it is gone.
+func (p *PostgresSecretRepository) DeleteSecret(ctx context.Context, secret ProjectSecret, msg outbox.Message) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM project_secrets WHERE id = $1`, secret.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(ctx, tx, secret, AuditDeleted, nil); err != nil {
+			return err
+		}
+
+		return outbox.Enqueue(ctx, tx, msg)
+	})
+}
+
+func (p *PostgresSecretRepository) GetSecretAudit(projectId uuid.UUID, limit int) ([]SecretAuditEntry, error) {
+	query := `
+		SELECT a.secret_name, a.action, a.key, u.name AS username, a.created_at
+		FROM project_secret_audit a
+		JOIN users u
+		ON u.id = a.user_id
+		WHERE a.project_id = $1
+		ORDER BY a.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []SecretAuditEntry
+	for rows.Next() {
+		var entry SecretAuditEntry
+		if err := rows.StructScan(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// IsSecretInUse reports whether a workspace imports the secret, stopped ones
+// included as they start again with it, a cron job, paused ones included, or
+// a job that has not finished.
+func (p *PostgresSecretRepository) IsSecretInUse(id uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM workspace_secrets WHERE secret_id = $1
+		) OR EXISTS (
+			SELECT 1 FROM cron_job_secrets WHERE secret_id = $1
+		) OR EXISTS (
+			SELECT 1 FROM job_secrets js
+			JOIN jobs j
+			ON j.id = js.job_id
+			WHERE js.secret_id = $1 AND j.status IN ('Pending', 'Running')
+		)
+	`
+
+	var inUse bool
+	err := p.uow.DB().QueryRowx(query, id).Scan(&inUse)
+
+	return inUse, err
+}
+
+func (p *PostgresSecretRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+// CanManageSecret allows the secret owner and the project owner.
+func (p *PostgresSecretRepository) CanManageSecret(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM project_secrets s
+		JOIN users secret_u
+		ON secret_u.id = s.owner_id
+		JOIN projects p
+		ON p.id = s.project_id
+		JOIN users project_u
+		ON project_u.id = p.owner_id
+		WHERE s.id = $1 AND (secret_u.email = $2 OR project_u.email = $2)
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresSecretRepository(uow storage.UnitOfWork) SecretRepository {
+	return NewPostgresSecretRepository(uow)
+}
@@ -0,0 +1,269 @@
+package secrets
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/consts"
+	"aispace/internal/outbox"
+	"aispace/internal/services"
+	"aispace/web/pages/secretsweb"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const auditLimit = 50
+
+type SecretService struct {
+	repository   SecretRepository
+	kuberService *services.KuberService
+}
+
+func NewSecretService(repository SecretRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *SecretService {
+	service := &SecretService{repository: repository, kuberService: kuberService}
+	service.registerOutboxHandlers(dispatcher)
+	return service
+}
+
+// serveSecrets renders the whole tab, every change also adds to the audit
+// list below the secrets.
+func (s *SecretService) serveSecrets(w http.ResponseWriter, r *http.Request, projectId uuid.UUID) http.HandlerFunc {
+	secrets, err := s.repository.GetProjectSecrets(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	entries, err := s.repository.GetSecretAudit(projectId, auditLimit)
+
+	if err != nil {
+		log.Printf("Error while fetching secret audit: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	email := r.Context().Value(consts.ContextEmail).(string)
+
+	var webSecretList []secretsweb.WebSecret
+	for _, secret := range secrets {
+		webSecretList = append(webSecretList, secret.ToWebSecret(email))
+	}
+
+	var webEntryList []secretsweb.WebSecretAuditEntry
+	for _, entry := range entries {
+		webEntryList = append(webEntryList, entry.ToWebSecretAuditEntry())
+	}
+
+	return base.Serve(secretsweb.Secrets(projectId, webSecretList, webEntryList), w)
+}
+
+func (s *SecretService) GetProjectSecrets(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	return s.serveSecrets(w, r, projectId)
+}
+
+func (s *SecretService) CreateSecret(w http.ResponseWriter, r *http.Request, command CreateSecretCommand) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	if !secretKeyPattern.MatchString(command.Key) {
+		return base.ErrorServe("Key must be a valid environment variable name", http.StatusBadRequest, w)
+	}
+
+	existing, err := s.repository.GetProjectSecrets(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	for _, secret := range existing {
+		if secret.Name == command.Name {
+			return base.ErrorServe("Secret name is already taken", http.StatusBadRequest, w)
+		}
+	}
+
+	secret := ProjectSecret{
+		ID:        uuid.New(),
+		Name:      command.Name,
+		ProjectID: projectId,
+		Owner: Owner{
+			Email: r.Context().Value(consts.ContextEmail).(string),
+		},
+		Keys:      []string{command.Key},
+		CreatedAt: time.Now(),
+	}
+
+	// the Secret is created last in the transaction of the row, it is only
+	// left behind when the commit itself fails
+	created := false
+	err = s.repository.CreateSecret(r.Context(), secret, func() error {
+		err := s.kuberService.CreateSecret(r.Context(), services.SecretSpec{
+			ID:        secret.ID.String(),
+			Name:      secret.GetSecretName(),
+			Namespace: secret.GetNamespace(),
+			ProjectID: projectId.String(),
+			Data:      map[string]string{command.Key: command.Value},
+		})
+		created = err == nil
+		return err
+	})
+
+	if err != nil {
+		if created {
+			if err := s.kuberService.DeleteSecret(r.Context(), secret.GetNamespace(), secret.GetSecretName()); err != nil {
+				log.Printf("Error while deleting kubernetes secret: %s", err)
+			}
+		}
+		log.Printf("Error while creating secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveSecrets(w, r, projectId)
+}
+
+func (s *SecretService) SetSecretKey(w http.ResponseWriter, r *http.Request, command SetSecretKeyCommand) http.HandlerFunc {
+	secretId, err := uuid.Parse(chi.URLParam(r, "secret_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageSecret(secretId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	if !secretKeyPattern.MatchString(command.Key) {
+		return base.ErrorServe("Key must be a valid environment variable name", http.StatusBadRequest, w)
+	}
+
+	secret, err := s.repository.GetSecretByID(secretId)
+
+	if err != nil {
+		log.Printf("Error while fetching secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.SetSecretKey(r.Context(), secret, command.Key, func() error {
+		return s.kuberService.UpdateSecretData(r.Context(), secret.GetNamespace(), secret.GetSecretName(), map[string]string{command.Key: command.Value}, nil)
+	})
+
+	if err != nil {
+		log.Printf("Error while updating secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveSecrets(w, r, secret.ProjectID)
+}
+
+func (s *SecretService) RemoveSecretKey(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	secretId, err := uuid.Parse(chi.URLParam(r, "secret_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageSecret(secretId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	secret, err := s.repository.GetSecretByID(secretId)
+
+	if err != nil {
+		log.Printf("Error while fetching secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	key := chi.URLParam(r, "key")
+
+	if !slices.Contains(secret.Keys, key) {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if len(secret.Keys) == 1 {
+		return base.ErrorServe("A secret needs at least one key", http.StatusBadRequest, w)
+	}
+
+	err = s.repository.RemoveSecretKey(r.Context(), secret, key, func() error {
+		return s.kuberService.UpdateSecretData(r.Context(), secret.GetNamespace(), secret.GetSecretName(), nil, []string{key})
+	})
+
+	if err != nil {
+		log.Printf("Error while updating secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveSecrets(w, r, secret.ProjectID)
+}
+
+func (s *SecretService) DeleteSecret(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	secretId, err := uuid.Parse(chi.URLParam(r, "secret_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageSecret(secretId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	secret, err := s.repository.GetSecretByID(secretId)
+
+	if err != nil {
+		log.Printf("Error while fetching secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	inUse, err := s.repository.IsSecretInUse(secretId)
+
+	if err != nil {
+		log.Printf("Error while checking secret usage: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if inUse {
+		return base.ErrorServe("Secret is used by a workspace, a cron job or a running job", http.StatusBadRequest, w)
+	}
+
+	msg, err := outbox.NewMessage(EventSecretDelete, secret.ID, DeleteSecretPayload{
+		Namespace:  secret.GetNamespace(),
+		SecretName: secret.GetSecretName(),
+	})
+
+	if err != nil {
+		log.Printf("Error while creating outbox message: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteSecret(r.Context(), secret, msg)
+
+	if err != nil {
+		log.Printf("Error while deleting secret: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return s.serveSecrets(w, r, secret.ProjectID)
+}
+
+func ProvideSecretService(repository SecretRepository, kuberService *services.KuberService, dispatcher *outbox.Dispatcher) *SecretService {
+	return NewSecretService(repository, kuberService, dispatcher)
+}
@@ -0,0 +1,22 @@
+package storageclasses
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+type CreateStorageClassCommand struct {
+	Name        string `validate:"required,min=3,max=100" form:"name"`
+	ClassName   string `validate:"required,max=253" form:"class_name"`
+	AllowShared bool   `form:"allow_shared"`
+}
+
+func (c *CreateStorageClassCommand) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
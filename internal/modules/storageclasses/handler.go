@@ -0,0 +1,66 @@
+package storageclasses
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type StorageClassHandler struct {
+	storageClassService *StorageClassService
+}
+
+func NewStorageClassHandler(storageClassService *StorageClassService) *StorageClassHandler {
+	return &StorageClassHandler{storageClassService: storageClassService}
+}
+
+func (h *StorageClassHandler) GetStorageClasses(w http.ResponseWriter, r *http.Request) {
+	handler := h.storageClassService.GetStorageClasses(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *StorageClassHandler) CreateStorageClass(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateStorageClassCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.storageClassService.CreateStorageClass(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *StorageClassHandler) DeleteStorageClass(w http.ResponseWriter, r *http.Request) {
+	handler := h.storageClassService.DeleteStorageClass(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideStorageClassHandler(storageClassService *StorageClassService) *StorageClassHandler {
+	return NewStorageClassHandler(storageClassService)
+}
@@ -0,0 +1,30 @@
+package storageclasses
+
+import (
+	"aispace/web/pages/adminweb"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StorageClass is a catalog entry mapping a human name to a cluster
+// StorageClass. AllowShared tells whether the class supports ReadWriteMany.
+type StorageClass struct {
+	ID          uuid.UUID `db:"id"`
+	Name        string    `db:"name"`
+	ClassName   string    `db:"class_name"`
+	AllowShared bool      `db:"allow_shared"`
+	DiskCount   int       `db:"disk_count"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (s *StorageClass) ToWebStorageClass() adminweb.WebStorageClass {
+	return adminweb.WebStorageClass{
+		ID:          s.ID,
+		Name:        s.Name,
+		ClassName:   s.ClassName,
+		AllowShared: s.AllowShared,
+		DiskCount:   s.DiskCount,
+		CreatedAt:   s.CreatedAt.Format("2006-01-02"),
+	}
+}
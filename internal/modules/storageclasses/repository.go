@@ -0,0 +1,105 @@
+package storageclasses
+
+import (
+	"aispace/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type StorageClassRepository interface {
+	GetStorageClasses() ([]StorageClass, error)
+	CreateStorageClass(storageClass StorageClass) error
+	DeleteStorageClass(id uuid.UUID) error
+	IsStorageClassUsed(id uuid.UUID) bool
+}
+
+type PostgresStorageClassRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresStorageClassRepository(uow storage.UnitOfWork) *PostgresStorageClassRepository {
+	return &PostgresStorageClassRepository{uow: uow}
+}
+
+func (p *PostgresStorageClassRepository) GetStorageClasses() ([]StorageClass, error) {
+	query := `
+		SELECT sc.id, sc.name, sc.class_name, sc.allow_shared, COUNT(d.id) AS disk_count, sc.created_at
+		FROM storage_classes sc
+		LEFT JOIN disks d
+		ON d.storage_class_id = sc.id
+		GROUP BY sc.id
+		ORDER BY sc.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var storageClasses []StorageClass
+	for rows.Next() {
+		var storageClass StorageClass
+		if err := rows.StructScan(&storageClass); err != nil {
+			return nil, err
+		}
+		storageClasses = append(storageClasses, storageClass)
+	}
+
+	return storageClasses, nil
+}
+
+func (p *PostgresStorageClassRepository) CreateStorageClass(storageClass StorageClass) error {
+	query := `
+		INSERT INTO storage_classes (id, name, class_name, allow_shared, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := p.uow.DB().Exec(
+		query,
+		storageClass.ID,
+		storageClass.Name,
+		storageClass.ClassName,
+		storageClass.AllowShared,
+		storageClass.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresStorageClassRepository) DeleteStorageClass(id uuid.UUID) error {
+	query := `
+		DELETE FROM storage_classes WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresStorageClassRepository) IsStorageClassUsed(id uuid.UUID) bool {
+	query := `
+		SELECT 1 FROM disks WHERE storage_class_id = $1
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+
+	if err != nil {
+		return true
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresStorageClassRepository(uow storage.UnitOfWork) StorageClassRepository {
+	return NewPostgresStorageClassRepository(uow)
+}
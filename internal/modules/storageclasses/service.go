@@ -0,0 +1,97 @@
+package storageclasses
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/services"
+	"aispace/web/pages/adminweb"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type StorageClassService struct {
+	repository   StorageClassRepository
+	kuberService *services.KuberService
+}
+
+func NewStorageClassService(repository StorageClassRepository, kuberService *services.KuberService) *StorageClassService {
+	return &StorageClassService{repository: repository, kuberService: kuberService}
+}
+
+func (s *StorageClassService) GetStorageClasses(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	storageClasses, err := s.repository.GetStorageClasses()
+
+	if err != nil {
+		log.Printf("Error while fetching storage classes: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webStorageClassList []adminweb.WebStorageClass
+
+	for _, storageClass := range storageClasses {
+		webStorageClassList = append(webStorageClassList, storageClass.ToWebStorageClass())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(adminweb.StorageClassesPartial(webStorageClassList), w)
+	}
+	return base.Serve(adminweb.StorageClassesFull(webStorageClassList), w)
+}
+
+func (s *StorageClassService) CreateStorageClass(w http.ResponseWriter, r *http.Request, command CreateStorageClassCommand) http.HandlerFunc {
+	exists, err := s.kuberService.StorageClassExists(r.Context(), command.ClassName)
+
+	if err != nil {
+		log.Printf("Error while fetching storage class: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if !exists {
+		return base.ErrorServe("StorageClass does not exist in the cluster", http.StatusBadRequest, w)
+	}
+
+	storageClass := StorageClass{
+		ID:          uuid.New(),
+		Name:        command.Name,
+		ClassName:   command.ClassName,
+		AllowShared: command.AllowShared,
+		CreatedAt:   time.Now(),
+	}
+
+	err = s.repository.CreateStorageClass(storageClass)
+
+	if err != nil {
+		log.Printf("Error while creating storage class: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.Serve(adminweb.StorageClassRow(storageClass.ToWebStorageClass()), w)
+}
+
+func (s *StorageClassService) DeleteStorageClass(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	storageClassId, err := uuid.Parse(chi.URLParam(r, "storage_class_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if s.repository.IsStorageClassUsed(storageClassId) {
+		return base.ErrorServe("Storage class is used by disks", http.StatusBadRequest, w)
+	}
+
+	err = s.repository.DeleteStorageClass(storageClassId)
+
+	if err != nil {
+		log.Printf("Error while deleting storage class: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func ProvideStorageClassService(repository StorageClassRepository, kuberService *services.KuberService) *StorageClassService {
+	return NewStorageClassService(repository, kuberService)
+}
@@ -0,0 +1,32 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate *validator.Validate
+
+func init() {
+	validate = validator.New()
+}
+
+// ProjectUsageQuery picks the chart range, 24h when empty.
+type ProjectUsageQuery struct {
+	Range string `validate:"omitempty,oneof=24h 7d" form:"range"`
+}
+
+func (c *ProjectUsageQuery) Validate() error {
+	err := validate.Struct(c)
+	return err
+}
+
+// window returns the charted duration and the bucket samples are averaged
+// into, about 300 points for either range.
+func (c *ProjectUsageQuery) window() (time.Duration, time.Duration) {
+	if c.Range == "7d" {
+		return 7 * 24 * time.Hour, 30 * time.Minute
+	}
+	return 24 * time.Hour, 5 * time.Minute
+}
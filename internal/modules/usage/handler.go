@@ -0,0 +1,44 @@
+package usage
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type UsageHandler struct {
+	usageService *UsageService
+}
+
+func NewUsageHandler(usageService *UsageService) *UsageHandler {
+	return &UsageHandler{usageService: usageService}
+}
+
+func (h *UsageHandler) GetProjectUsage(w http.ResponseWriter, r *http.Request) {
+	query := ProjectUsageQuery{}
+
+	if err := formDecoder.Decode(&query, r.URL.Query()); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.usageService.GetProjectUsage(w, r, query)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideUsageHandler(usageService *UsageService) *UsageHandler {
+	return NewUsageHandler(usageService)
+}
@@ -0,0 +1,100 @@
+package usage
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/usageweb"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	chartWidth  = 600
+	chartHeight = 150
+)
+
+// UsageSample is the usage of every pod of a project at one point in time.
+// CPU is in millicores, RAM in bytes.
+type UsageSample struct {
+	ProjectID uuid.UUID `db:"project_id"`
+	SampledAt time.Time `db:"sampled_at"`
+	CPU       int64     `db:"cpu_millicores"`
+	RAM       int64     `db:"ram_bytes"`
+	Pods      int       `db:"pods"`
+}
+
+// ProjectLimits are the project quota, CPU in cores and RAM in GiB.
+type ProjectLimits struct {
+	CPULimit int `db:"cpu_limit"`
+	RAMLimit int `db:"ram_limit"`
+}
+
+func (l ProjectLimits) cpuMillicores() int64 {
+	return int64(l.CPULimit) * 1000
+}
+
+func (l ProjectLimits) ramBytes() int64 {
+	return int64(l.RAMLimit) << 30
+}
+
+func percentOf(value, limit int64) int {
+	if limit <= 0 {
+		return 0
+	}
+	return int(value * 100 / limit)
+}
+
+func formatCPU(millicores int64) string {
+	return fmt.Sprintf("%.2f", float64(millicores)/1000)
+}
+
+func formatRAM(bytes int64) string {
+	return fmt.Sprintf("%.2f", float64(bytes)/(1<<30))
+}
+
+func toWebPodUsage(usage services.PodUsage, limits ProjectLimits) usageweb.WebPodUsage {
+	return usageweb.WebPodUsage{
+		Name:       usage.Name,
+		CPU:        formatCPU(usage.CPU),
+		RAM:        formatRAM(usage.RAM),
+		CPUPercent: percentOf(usage.CPU, limits.cpuMillicores()),
+		RAMPercent: percentOf(usage.RAM, limits.ramBytes()),
+	}
+}
+
+// toWebUsageChart plots the samples as an SVG polyline. The y axis goes up to
+// the limit, or the peak when usage went over it.
+func toWebUsageChart(title, unit string, samples []UsageSample, value func(UsageSample) int64, limit int64, format func(int64) string, since time.Time, window time.Duration) usageweb.WebUsageChart {
+	peak := limit
+	for _, sample := range samples {
+		peak = max(peak, value(sample))
+	}
+	if peak <= 0 {
+		peak = 1
+	}
+
+	y := func(v int64) float64 {
+		return chartHeight - float64(v)*chartHeight/float64(peak)
+	}
+
+	var points []string
+	var highest int64
+	for _, sample := range samples {
+		x := float64(sample.SampledAt.Sub(since)) * chartWidth / float64(window)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y(value(sample))))
+		highest = max(highest, value(sample))
+	}
+
+	return usageweb.WebUsageChart{
+		Title:  title,
+		Unit:   unit,
+		Width:  chartWidth,
+		Height: chartHeight,
+		LimitY: fmt.Sprintf("%.1f", y(limit)),
+		Points: strings.Join(points, " "),
+		Limit:  format(limit),
+		Peak:   format(highest),
+	}
+}
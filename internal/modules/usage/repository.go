@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UsageRepository interface {
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	GetProjectIDs() ([]uuid.UUID, error)
+	GetProjectLimits(projectId uuid.UUID) (ProjectLimits, error)
+	CreateUsageSamples(ctx context.Context, samples []UsageSample) error
+	DeleteUsageSamplesBefore(before time.Time) error
+	GetUsageSamples(projectId uuid.UUID, since time.Time, bucket time.Duration) ([]UsageSample, error)
+}
+
+type PostgresUsageRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresUsageRepository(uow storage.UnitOfWork) *PostgresUsageRepository {
+	return &PostgresUsageRepository{uow: uow}
+}
+
+func (p *PostgresUsageRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresUsageRepository) GetProjectIDs() ([]uuid.UUID, error) {
+	rows, err := p.uow.DB().Queryx(`SELECT id FROM projects`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (p *PostgresUsageRepository) GetProjectLimits(projectId uuid.UUID) (ProjectLimits, error) {
+	var limits ProjectLimits
+	err := p.uow.DB().QueryRowx(`SELECT cpu_limit, ram_limit FROM projects WHERE id = $1`, projectId).StructScan(&limits)
+	return limits, err
+}
+
+func (p *PostgresUsageRepository) CreateUsageSamples(ctx context.Context, samples []UsageSample) error {
+	query := `
+		INSERT INTO project_usage_samples (project_id, sampled_at, cpu_millicores, ram_bytes, pods)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id, sampled_at) DO NOTHING
+	`
+
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, sample := range samples {
+			if _, err := tx.Exec(query, sample.ProjectID, sample.SampledAt, sample.CPU, sample.RAM, sample.Pods); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *PostgresUsageRepository) DeleteUsageSamplesBefore(before time.Time) error {
+	_, err := p.uow.DB().Exec(`DELETE FROM project_usage_samples WHERE sampled_at < $1`, before)
+	return err
+}
+
+// GetUsageSamples averages the samples of a project into buckets, the pods
+// column keeps the highest count of a bucket.
+func (p *PostgresUsageRepository) GetUsageSamples(projectId uuid.UUID, since time.Time, bucket time.Duration) ([]UsageSample, error) {
+	query := `
+		SELECT
+			project_id,
+			date_bin($3::interval, sampled_at, TIMESTAMPTZ '2000-01-01') AS sampled_at,
+			AVG(cpu_millicores)::BIGINT AS cpu_millicores,
+			AVG(ram_bytes)::BIGINT AS ram_bytes,
+			MAX(pods) AS pods
+		FROM project_usage_samples
+		WHERE project_id = $1 AND sampled_at >= $2
+		GROUP BY 1, 2
+		ORDER BY 2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, since, fmt.Sprintf("%d seconds", int(bucket.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []UsageSample
+	for rows.Next() {
+		var sample UsageSample
+		if err := rows.StructScan(&sample); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+func ProvidePostgresUsageRepository(uow storage.UnitOfWork) UsageRepository {
+	return NewPostgresUsageRepository(uow)
+}
@@ -0,0 +1,194 @@
+package usage
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/services"
+	"aispace/internal/storage"
+	"aispace/web/pages/usageweb"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsageService samples the pod usage of every project from the metrics API
+// into project_usage_samples and charts it on the project page.
+type UsageService struct {
+	cfg          *config.Config
+	repository   UsageRepository
+	kuberService *services.KuberService
+	leader       *storage.Leader
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+func NewUsageService(cfg *config.Config, repository UsageRepository, kuberService *services.KuberService, uow storage.UnitOfWork) *UsageService {
+	return &UsageService{
+		cfg:          cfg,
+		repository:   repository,
+		kuberService: kuberService,
+		leader:       storage.NewLeader(uow, storage.LockUsageSampler),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+func (s *UsageService) Start() {
+	go s.run()
+}
+
+func (s *UsageService) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+	fmt.Println("Usage sampler: stopped.")
+}
+
+// run only works on the replica holding the lock, the others keep trying.
+func (s *UsageService) run() {
+	defer close(s.doneCh)
+	defer s.leader.Release()
+
+	ticker := time.NewTicker(s.cfg.Usage.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			if !s.leader.Lead(context.Background()) {
+				continue
+			}
+			if err := s.sample(context.Background()); err != nil {
+				log.Printf("Usage sampler: %s", err)
+			}
+		}
+	}
+}
+
+// sample stores one row per project, projects without pods get a zero row so
+// idle time shows up on the chart.
+func (s *UsageService) sample(ctx context.Context) error {
+	usages, err := s.kuberService.ListPodUsage(ctx, metav1.NamespaceAll)
+	if err != nil {
+		return fmt.Errorf("list pod metrics: %w", err)
+	}
+
+	projectIds, err := s.repository.GetProjectIDs()
+	if err != nil {
+		return fmt.Errorf("fetch projects: %w", err)
+	}
+
+	sampledAt := time.Now().Truncate(time.Second)
+	samples := make(map[string]*UsageSample, len(projectIds))
+	for _, projectId := range projectIds {
+		samples[projectNamespace(projectId)] = &UsageSample{ProjectID: projectId, SampledAt: sampledAt}
+	}
+
+	for _, usage := range usages {
+		if !strings.HasPrefix(usage.Namespace, services.ProjectNamespacePrefix) {
+			continue
+		}
+		sample, ok := samples[usage.Namespace]
+		if !ok {
+			continue
+		}
+		sample.CPU += usage.CPU
+		sample.RAM += usage.RAM
+		sample.Pods++
+	}
+
+	rows := make([]UsageSample, 0, len(samples))
+	for _, sample := range samples {
+		rows = append(rows, *sample)
+	}
+
+	if err := s.repository.CreateUsageSamples(ctx, rows); err != nil {
+		return fmt.Errorf("store samples: %w", err)
+	}
+
+	if err := s.repository.DeleteUsageSamplesBefore(sampledAt.Add(-s.cfg.Usage.Retention)); err != nil {
+		return fmt.Errorf("delete old samples: %w", err)
+	}
+
+	return nil
+}
+
+func projectNamespace(projectId uuid.UUID) string {
+	return services.ProjectNamespacePrefix + projectId.String()
+}
+
+// GetProjectUsage renders the live usage of the project pods against the
+// project limits, and the sampled history of the chosen range.
+func (s *UsageService) GetProjectUsage(w http.ResponseWriter, r *http.Request, query ProjectUsageQuery) http.HandlerFunc {
+	projectId, err := uuid.Parse(chi.URLParam(r, "project_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	limits, err := s.repository.GetProjectLimits(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching project limits: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	window, bucket := query.window()
+	since := time.Now().Add(-window)
+	samples, err := s.repository.GetUsageSamples(projectId, since, bucket)
+
+	if err != nil {
+		log.Printf("Error while fetching usage samples: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	usage := usageweb.WebProjectUsage{
+		ProjectID: projectId,
+		Range:     query.Range,
+		CPULimit:  formatCPU(limits.cpuMillicores()),
+		RAMLimit:  formatRAM(limits.ramBytes()),
+		CPUChart: toWebUsageChart("CPU", "cores", samples, func(s UsageSample) int64 { return s.CPU },
+			limits.cpuMillicores(), formatCPU, since, window),
+		RAMChart: toWebUsageChart("Memory", "GiB", samples, func(s UsageSample) int64 { return s.RAM },
+			limits.ramBytes(), formatRAM, since, window),
+	}
+	if usage.Range == "" {
+		usage.Range = "24h"
+	}
+
+	// metrics-server may be missing, the history is still worth showing
+	podUsages, err := s.kuberService.ListPodUsage(r.Context(), projectNamespace(projectId))
+	if err != nil {
+		log.Printf("Error while fetching pod metrics: %s", err)
+		usage.MetricsUnavailable = true
+	}
+
+	var cpu, ram int64
+	for _, podUsage := range podUsages {
+		cpu += podUsage.CPU
+		ram += podUsage.RAM
+		usage.Pods = append(usage.Pods, toWebPodUsage(podUsage, limits))
+	}
+	usage.CPU = formatCPU(cpu)
+	usage.RAM = formatRAM(ram)
+	usage.CPUPercent = percentOf(cpu, limits.cpuMillicores())
+	usage.RAMPercent = percentOf(ram, limits.ramBytes())
+
+	return base.Serve(usageweb.ProjectUsage(usage), w)
+}
+
+func ProvideUsageService(cfg *config.Config, repository UsageRepository, kuberService *services.KuberService, uow storage.UnitOfWork) *UsageService {
+	return NewUsageService(cfg, repository, kuberService, uow)
+}
@@ -0,0 +1,146 @@
+package workspaces
+
+import (
+	"net/http"
+
+	"github.com/go-playground/form/v4"
+)
+
+var formDecoder *form.Decoder
+
+func init() {
+	formDecoder = form.NewDecoder()
+}
+
+type WorkspaceHandler struct {
+	workspaceService *WorkspaceService
+}
+
+func NewWorkspaceHandler(workspaceService *WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{workspaceService: workspaceService}
+}
+
+func (h *WorkspaceHandler) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetWorkspaces(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) GetProjectsForWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetProjectsForWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) GetDisksForWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetDisksForWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) GetSecretsForWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetSecretsForWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) GetImagesForWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetImagesForWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := CreateWorkspaceCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.workspaceService.CreateWorkspace(w, r, command)
+
+	if handler != nil {
+		handler(w, r)
+	} else {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *WorkspaceHandler) StartWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.StartWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) StopWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.StopWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) SetKeepRunning(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	command := SetKeepRunningCommand{}
+
+	if err := formDecoder.Decode(&command, r.PostForm); err != nil {
+		http.Error(w, "Invalid input data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := command.Validate(); err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler := h.workspaceService.SetKeepRunning(w, r, command)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.DeleteWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) GetWorkspaceStatus(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.GetWorkspaceStatus(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func (h *WorkspaceHandler) ProxyWorkspace(w http.ResponseWriter, r *http.Request) {
+	handler := h.workspaceService.ProxyWorkspace(w, r)
+	if handler != nil {
+		handler(w, r)
+	}
+}
+
+func ProvideWorkspaceHandler(workspaceService *WorkspaceService) *WorkspaceHandler {
+	return NewWorkspaceHandler(workspaceService)
+}
@@ -0,0 +1,183 @@
+package workspaces
+
+import (
+	"aispace/internal/services"
+	"aispace/web/pages/workspacesweb"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+)
+
+type Workspace struct {
+	ID          uuid.UUID `db:"id"`
+	Name        string    `db:"name"`
+	Project     WorkspaceProject
+	Owner       Owner
+	ImageID     uuid.NullUUID `db:"image_id"`
+	Image       string        `db:"image"`
+	Command     string        `db:"command"`
+	Port        int           `db:"port"`
+	CPU         int           `db:"cpu"`
+	RAM         int           `db:"ram"`
+	State       string        `db:"state"`
+	KeepRunning bool          `db:"keep_running"`
+	Status      services.WorkloadStatus
+	Disks       []WorkspaceDisk
+	Secrets     []WorkspaceSecret
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type WorkspaceProject struct {
+	ID   uuid.UUID
+	Name string
+}
+
+type WorkspaceDisk struct {
+	ID   uuid.UUID `db:"id"`
+	Name string    `db:"name"`
+}
+
+// WorkspaceSecret is a project secret imported as environment variables or,
+// with AsFile, as files.
+type WorkspaceSecret struct {
+	ID     uuid.UUID `db:"id"`
+	Name   string    `db:"name"`
+	AsFile bool      `db:"as_file"`
+}
+
+// WorkspaceImage is a catalog entry the project offers.
+type WorkspaceImage struct {
+	ID             uuid.UUID `db:"id"`
+	Name           string    `db:"name"`
+	Image          string    `db:"image"`
+	Description    string    `db:"description"`
+	DefaultCommand string    `db:"default_command"`
+	Port           *int      `db:"port"`
+	CPU            int       `db:"cpu"`
+	RAM            int       `db:"ram"`
+	Pinned         bool      `db:"pinned"`
+}
+
+type Owner struct {
+	Username string `db:"name"`
+	Email    string `db:"email"`
+}
+
+func (w *Workspace) GetNamespace() string {
+	return fmt.Sprintf("project-%s", w.Project.ID.String())
+}
+
+func (w *Workspace) GetPodName() string {
+	return fmt.Sprintf("workspace-%s", w.ID.String())
+}
+
+func (w *Workspace) GetBaseURL() string {
+	return fmt.Sprintf("/workspaces/%s/lab/", w.ID.String())
+}
+
+func (d *WorkspaceDisk) GetPVCName() string {
+	return fmt.Sprintf("disk-%s", d.ID.String())
+}
+
+func (s *WorkspaceSecret) GetSecretName() string {
+	return fmt.Sprintf("secret-%s", s.ID.String())
+}
+
+func (w *Workspace) ToSpec(token string) services.WorkspaceSpec {
+	var mounts []services.DiskMount
+	for _, disk := range w.Disks {
+		mounts = append(mounts, services.DiskMount{
+			PVCName:   disk.GetPVCName(),
+			MountPath: path.Join("/home/jovyan/disks", services.MountDirName(disk.Name)),
+		})
+	}
+
+	var secretMounts []services.SecretMount
+	for _, secret := range w.Secrets {
+		secretMounts = append(secretMounts, services.SecretMount{
+			SecretName: secret.GetSecretName(),
+			MountPath:  path.Join("/mnt/secrets", services.MountDirName(secret.Name)),
+			AsFile:     secret.AsFile,
+		})
+	}
+
+	return services.WorkspaceSpec{
+		ID:         w.ID.String(),
+		Name:       w.GetPodName(),
+		Namespace:  w.GetNamespace(),
+		ProjectID:  w.Project.ID.String(),
+		OwnerEmail: w.Owner.Email,
+		Image:      w.Image,
+		Command:    w.Command,
+		Port:       w.Port,
+		CPU:        w.CPU,
+		RAM:        w.RAM,
+		BaseURL:    w.GetBaseURL(),
+		Token:      token,
+		Disks:      mounts,
+		Secrets:    secretMounts,
+	}
+}
+
+func (p *WorkspaceProject) ToWebWorkspaceProject(project WorkspaceProject) workspacesweb.WebWorkspaceProject {
+	return workspacesweb.WebWorkspaceProject{
+		ID:   project.ID,
+		Name: project.Name,
+	}
+}
+
+func (d *WorkspaceDisk) ToWebWorkspaceDisk(disk WorkspaceDisk) workspacesweb.WebWorkspaceDisk {
+	return workspacesweb.WebWorkspaceDisk{
+		ID:   disk.ID,
+		Name: disk.Name,
+	}
+}
+
+func (s *WorkspaceSecret) ToWebWorkspaceSecret(secret WorkspaceSecret) workspacesweb.WebWorkspaceSecret {
+	return workspacesweb.WebWorkspaceSecret{
+		ID:   secret.ID,
+		Name: secret.Name,
+	}
+}
+
+func (i *WorkspaceImage) ToWebWorkspaceImage(image WorkspaceImage) workspacesweb.WebWorkspaceImage {
+	return workspacesweb.WebWorkspaceImage{
+		ID:          image.ID,
+		Name:        image.Name,
+		Description: image.Description,
+		CPU:         image.CPU,
+		RAM:         image.RAM,
+		Pinned:      image.Pinned,
+	}
+}
+
+func (w *Workspace) ToWebWorkspace() workspacesweb.WebWorkspace {
+	var webDisks []workspacesweb.WebWorkspaceDisk
+	for _, disk := range w.Disks {
+		webDisks = append(webDisks, disk.ToWebWorkspaceDisk(disk))
+	}
+
+	return workspacesweb.WebWorkspace{
+		ID:            w.ID,
+		Name:          w.Name,
+		OwnerUsername: w.Owner.Username,
+		OwnerEmail:    w.Owner.Email,
+		Project:       w.Project.ToWebWorkspaceProject(w.Project),
+		Image:         w.Image,
+		CPU:           w.CPU,
+		RAM:           w.RAM,
+		State:         w.State,
+		KeepRunning:   w.KeepRunning,
+		Status:        w.Status.String(),
+		Disks:         webDisks,
+		URL:           w.GetBaseURL(),
+		CreatedAt:     w.CreatedAt.Format("2006-01-02"),
+	}
+}
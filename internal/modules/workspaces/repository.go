@@ -0,0 +1,476 @@
+package workspaces
+
+import (
+	"aispace/internal/consts"
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceRepository interface {
+	GetWorkspaces(ctx context.Context) ([]Workspace, error)
+	GetWorkspaceByID(id uuid.UUID) (Workspace, error)
+	CreateWorkspace(ctx context.Context, workspace Workspace) error
+	SetWorkspaceState(id uuid.UUID, state string) error
+	SetWorkspaceKeepRunning(id uuid.UUID, keepRunning bool) error
+	DeleteWorkspace(id uuid.UUID) error
+	GetProjectsByName(ctx context.Context, name string) ([]WorkspaceProject, error)
+	GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]WorkspaceDisk, error)
+	GetProjectSecrets(projectId uuid.UUID) ([]WorkspaceSecret, error)
+	GetProjectImages(projectId uuid.UUID) ([]WorkspaceImage, error)
+	CanUseProject(projectId uuid.UUID, ctx context.Context) bool
+	CanManageWorkspace(id uuid.UUID, ctx context.Context) bool
+}
+
+type PostgresWorkspaceRepository struct {
+	uow storage.UnitOfWork
+}
+
+func NewPostgresWorkspaceRepository(uow storage.UnitOfWork) *PostgresWorkspaceRepository {
+	return &PostgresWorkspaceRepository{uow: uow}
+}
+
+const workspaceColumns = `
+	w.id, w.name, w.image_id, w.image, w.command, w.port, w.cpu, w.ram, w.state, w.keep_running, w.created_at,
+	u.name, u.email, p.id, p.name
+`
+
+func scanWorkspace(scanner interface{ Scan(...any) error }) (Workspace, error) {
+	var workspace Workspace
+
+	err := scanner.Scan(
+		&workspace.ID,
+		&workspace.Name,
+		&workspace.ImageID,
+		&workspace.Image,
+		&workspace.Command,
+		&workspace.Port,
+		&workspace.CPU,
+		&workspace.RAM,
+		&workspace.State,
+		&workspace.KeepRunning,
+		&workspace.CreatedAt,
+		&workspace.Owner.Username,
+		&workspace.Owner.Email,
+		&workspace.Project.ID,
+		&workspace.Project.Name,
+	)
+
+	return workspace, err
+}
+
+func (p *PostgresWorkspaceRepository) GetWorkspaces(ctx context.Context) ([]Workspace, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT ` + workspaceColumns + `
+		FROM workspaces w
+		JOIN users u
+		ON u.id = w.owner_id
+		JOIN projects p
+		ON p.id = w.project_id
+		WHERE u.email = $1
+		ORDER BY w.created_at DESC
+	`
+
+	rows, err := p.uow.DB().Queryx(query, email)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaceList []Workspace
+
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaceList = append(workspaceList, workspace)
+	}
+
+	for i := range workspaceList {
+		disks, err := p.getWorkspaceDisks(workspaceList[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		workspaceList[i].Disks = disks
+	}
+
+	return workspaceList, nil
+}
+
+func (p *PostgresWorkspaceRepository) GetWorkspaceByID(id uuid.UUID) (Workspace, error) {
+	query := `
+		SELECT ` + workspaceColumns + `
+		FROM workspaces w
+		JOIN users u
+		ON u.id = w.owner_id
+		JOIN projects p
+		ON p.id = w.project_id
+		WHERE w.id = $1
+	`
+
+	workspace, err := scanWorkspace(p.uow.DB().QueryRowx(query, id))
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	workspace.Disks, err = p.getWorkspaceDisks(id)
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	workspace.Secrets, err = p.getWorkspaceSecrets(id)
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	return workspace, nil
+}
+
+func (p *PostgresWorkspaceRepository) getWorkspaceDisks(id uuid.UUID) ([]WorkspaceDisk, error) {
+	query := `
+		SELECT d.id, d.name
+		FROM workspace_disks wd
+		JOIN disks d
+		ON d.id = wd.disk_id
+		WHERE wd.workspace_id = $1
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []WorkspaceDisk
+	for rows.Next() {
+		var disk WorkspaceDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresWorkspaceRepository) getWorkspaceSecrets(id uuid.UUID) ([]WorkspaceSecret, error) {
+	query := `
+		SELECT s.id, s.name, ws.as_file
+		FROM workspace_secrets ws
+		JOIN project_secrets s
+		ON s.id = ws.secret_id
+		WHERE ws.workspace_id = $1
+		ORDER BY s.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []WorkspaceSecret
+	for rows.Next() {
+		var secret WorkspaceSecret
+		if err := rows.StructScan(&secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+func (p *PostgresWorkspaceRepository) CreateWorkspace(ctx context.Context, workspace Workspace) error {
+	return p.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO workspaces (id, name, project_id, owner_id, image_id, image, command, port, cpu, ram, state, created_at)
+			VALUES ($1, $2, $3, (SELECT id FROM users WHERE email = $4), $5, $6, $7, $8, $9, $10, $11, $12)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			workspace.ID,
+			workspace.Name,
+			workspace.Project.ID,
+			workspace.Owner.Email,
+			workspace.ImageID,
+			workspace.Image,
+			workspace.Command,
+			workspace.Port,
+			workspace.CPU,
+			workspace.RAM,
+			workspace.State,
+			workspace.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, disk := range workspace.Disks {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO workspace_disks (workspace_id, disk_id) VALUES ($1, $2)`,
+				workspace.ID,
+				disk.ID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, secret := range workspace.Secrets {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO workspace_secrets (workspace_id, secret_id, as_file) VALUES ($1, $2, $3)`,
+				workspace.ID,
+				secret.ID,
+				secret.AsFile,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetWorkspaceState also restarts the idle clock, a workspace that was just
+// started has not had the chance to be used yet.
+func (p *PostgresWorkspaceRepository) SetWorkspaceState(id uuid.UUID, state string) error {
+	query := `
+		UPDATE workspaces
+		SET state = $2, updated_at = NOW(), last_activity_at = NOW(), cull_warned_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, state)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresWorkspaceRepository) SetWorkspaceKeepRunning(id uuid.UUID, keepRunning bool) error {
+	query := `
+		UPDATE workspaces SET keep_running = $2, updated_at = NOW() WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id, keepRunning)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresWorkspaceRepository) DeleteWorkspace(id uuid.UUID) error {
+	query := `
+		DELETE FROM workspaces WHERE id = $1
+	`
+
+	_, err := p.uow.DB().Exec(query, id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresWorkspaceRepository) GetProjectsByName(ctx context.Context, name string) ([]WorkspaceProject, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT DISTINCT
+		    p.id,
+		    p.name
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.name ILIKE $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, "%"+name+"%")
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projectList []WorkspaceProject
+
+	for rows.Next() {
+		var project WorkspaceProject
+
+		err = rows.Scan(
+			&project.ID,
+			&project.Name,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		projectList = append(projectList, project)
+	}
+
+	return projectList, nil
+}
+
+// GetProjectDisks returns the disks of a project that the current user may
+// mount: their own disks and the shared ones.
+func (p *PostgresWorkspaceRepository) GetProjectDisks(ctx context.Context, projectId uuid.UUID) ([]WorkspaceDisk, error) {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT d.id, d.name
+		FROM disks d
+		JOIN users u
+		ON u.id = d.owner_id
+		WHERE d.project_id = $1
+		AND (u.email = $2 OR d.shared)
+		AND d.provision_status = 'provisioned'
+		ORDER BY d.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disks []WorkspaceDisk
+	for rows.Next() {
+		var disk WorkspaceDisk
+		if err := rows.StructScan(&disk); err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (p *PostgresWorkspaceRepository) GetProjectSecrets(projectId uuid.UUID) ([]WorkspaceSecret, error) {
+	query := `
+		SELECT id, name, FALSE AS as_file
+		FROM project_secrets
+		WHERE project_id = $1
+		ORDER BY name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []WorkspaceSecret
+	for rows.Next() {
+		var secret WorkspaceSecret
+		if err := rows.StructScan(&secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// GetProjectImages returns the catalog entries the project did not hide,
+// pinned ones first.
+func (p *PostgresWorkspaceRepository) GetProjectImages(projectId uuid.UUID) ([]WorkspaceImage, error) {
+	query := `
+		SELECT
+			i.id, i.name, i.image, i.description, i.default_command, i.port, i.cpu, i.ram,
+			COALESCE(pi.pinned, FALSE) AS pinned
+		FROM images i
+		LEFT JOIN project_images pi
+		ON pi.image_id = i.id AND pi.project_id = $1
+		WHERE NOT COALESCE(pi.hidden, FALSE)
+		ORDER BY pinned DESC, i.name
+	`
+
+	rows, err := p.uow.DB().Queryx(query, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []WorkspaceImage
+	for rows.Next() {
+		var image WorkspaceImage
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (p *PostgresWorkspaceRepository) CanUseProject(projectId uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1
+		FROM
+		    projects p
+		JOIN
+		    users owner_u ON p.owner_id = owner_u.id
+		LEFT JOIN
+		    project_user_rel pur ON p.id = pur.project_id
+		LEFT JOIN
+		    users rel_u ON pur.user_id = rel_u.id
+		WHERE
+			p.id = $2
+			AND
+		    (owner_u.email = $1 OR rel_u.email = $1)
+	`
+	rows, err := p.uow.DB().Queryx(query, email, projectId)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func (p *PostgresWorkspaceRepository) CanManageWorkspace(id uuid.UUID, ctx context.Context) bool {
+	email := ctx.Value(consts.ContextEmail).(string)
+	query := `
+		SELECT 1 FROM workspaces w
+		JOIN users u
+		ON u.id = w.owner_id
+		WHERE w.id = $1 AND u.email = $2
+	`
+
+	rows, err := p.uow.DB().Queryx(query, id, email)
+
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	return rows.Next()
+}
+
+func ProvidePostgresWorkspaceRepository(uow storage.UnitOfWork) WorkspaceRepository {
+	return NewPostgresWorkspaceRepository(uow)
+}
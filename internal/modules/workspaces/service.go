@@ -0,0 +1,533 @@
+package workspaces
+
+import (
+	"aispace/internal/base"
+	"aispace/internal/config"
+	"aispace/internal/consts"
+	"aispace/internal/services"
+	"aispace/web/pages/workspacesweb"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type WorkspaceService struct {
+	cfg          *config.Config
+	repository   WorkspaceRepository
+	kuberService *services.KuberService
+}
+
+func NewWorkspaceService(cfg *config.Config, repository WorkspaceRepository, kuberService *services.KuberService) *WorkspaceService {
+	return &WorkspaceService{cfg: cfg, repository: repository, kuberService: kuberService}
+}
+
+func (s *WorkspaceService) setStatus(workspace *Workspace) {
+	if workspace.State == StateStopped {
+		workspace.Status = services.WorkloadStopped
+		return
+	}
+	workspace.Status = s.kuberService.GetPodStatus(workspace.GetNamespace(), workspace.GetPodName())
+}
+
+func (s *WorkspaceService) GetWorkspaces(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaces, err := s.repository.GetWorkspaces(r.Context())
+
+	if err != nil {
+		log.Printf("Error while fetching workspaces: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webWorkspaceList []workspacesweb.WebWorkspace
+
+	for i := range workspaces {
+		s.setStatus(&workspaces[i])
+		webWorkspaceList = append(webWorkspaceList, workspaces[i].ToWebWorkspace())
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		return base.Serve(workspacesweb.WorkspacesPartial(webWorkspaceList), w)
+	}
+	return base.Serve(workspacesweb.WorkspacesFull(webWorkspaceList), w)
+}
+
+func (s *WorkspaceService) GetProjectsForWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	name := r.URL.Query().Get("project_name")
+	projects, err := s.repository.GetProjectsByName(r.Context(), name)
+
+	if err != nil {
+		log.Printf("Error while fetching projects: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webProjectList []workspacesweb.WebWorkspaceProject
+
+	for _, project := range projects {
+		webProjectList = append(webProjectList, project.ToWebWorkspaceProject(project))
+	}
+
+	return base.Serve(workspacesweb.WorkspaceProjects(webProjectList), w)
+}
+
+func (s *WorkspaceService) GetDisksForWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(workspacesweb.WorkspaceDisks(nil), w)
+	}
+
+	disks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webDiskList []workspacesweb.WebWorkspaceDisk
+
+	for _, disk := range disks {
+		webDiskList = append(webDiskList, disk.ToWebWorkspaceDisk(disk))
+	}
+
+	return base.Serve(workspacesweb.WorkspaceDisks(webDiskList), w)
+}
+
+func (s *WorkspaceService) GetSecretsForWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(workspacesweb.WorkspaceSecrets(nil), w)
+	}
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	secrets, err := s.repository.GetProjectSecrets(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webSecretList []workspacesweb.WebWorkspaceSecret
+
+	for _, secret := range secrets {
+		webSecretList = append(webSecretList, secret.ToWebWorkspaceSecret(secret))
+	}
+
+	return base.Serve(workspacesweb.WorkspaceSecrets(webSecretList), w)
+}
+
+func (s *WorkspaceService) GetImagesForWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	projectId, err := uuid.Parse(r.URL.Query().Get("project_id"))
+
+	if err != nil {
+		return base.Serve(workspacesweb.WorkspaceImages(nil, s.cfg.Workspace.DefaultImage), w)
+	}
+
+	images, err := s.repository.GetProjectImages(projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	var webImageList []workspacesweb.WebWorkspaceImage
+
+	for _, image := range images {
+		webImageList = append(webImageList, image.ToWebWorkspaceImage(image))
+	}
+
+	return base.Serve(workspacesweb.WorkspaceImages(webImageList, s.cfg.Workspace.DefaultImage), w)
+}
+
+var (
+	errImageRequired      = errors.New("image is required")
+	errImageNotAvailable  = errors.New("image is not available")
+	errSecretNotAvailable = errors.New("secret is not available")
+)
+
+// secretsForWorkspace resolves the "env:<id>" and "file:<id>" picks of the
+// form, empty picks import nothing.
+func (s *WorkspaceService) secretsForWorkspace(projectId uuid.UUID, command CreateWorkspaceCommand) ([]WorkspaceSecret, error) {
+	projectSecrets, err := s.repository.GetProjectSecrets(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedSecrets := make(map[string]WorkspaceSecret)
+	for _, secret := range projectSecrets {
+		allowedSecrets[secret.ID.String()] = secret
+	}
+
+	var secrets []WorkspaceSecret
+	for _, pick := range command.Secrets {
+		if pick == "" {
+			continue
+		}
+		mode, secretId, _ := strings.Cut(pick, ":")
+		secret, ok := allowedSecrets[secretId]
+		if !ok || (mode != "env" && mode != "file") {
+			return nil, errSecretNotAvailable
+		}
+		secret.AsFile = mode == "file"
+		secrets = append(secrets, secret)
+		delete(allowedSecrets, secretId)
+	}
+
+	return secrets, nil
+}
+
+// imageForWorkspace resolves the catalog entry picked in the form. A raw
+// image string is only accepted while the project has no catalog entries.
+func (s *WorkspaceService) imageForWorkspace(projectId uuid.UUID, command CreateWorkspaceCommand) (*WorkspaceImage, error) {
+	images, err := s.repository.GetProjectImages(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.ImageID == "" {
+		if len(images) > 0 || command.Image == "" {
+			return nil, errImageRequired
+		}
+		return nil, nil
+	}
+
+	imageId := uuid.MustParse(command.ImageID)
+	for _, image := range images {
+		if image.ID == imageId {
+			return &image, nil
+		}
+	}
+
+	return nil, errImageNotAvailable
+}
+
+func (s *WorkspaceService) CreateWorkspace(w http.ResponseWriter, r *http.Request, command CreateWorkspaceCommand) http.HandlerFunc {
+	projectId := uuid.MustParse(command.ProjectID)
+	ownerEmail := r.Context().Value(consts.ContextEmail).(string)
+	ownerUsername := r.Context().Value(consts.ContextUsername).(string)
+
+	if !s.repository.CanUseProject(projectId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	projectDisks, err := s.repository.GetProjectDisks(r.Context(), projectId)
+
+	if err != nil {
+		log.Printf("Error while fetching disks: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	allowedDisks := make(map[uuid.UUID]WorkspaceDisk)
+	for _, disk := range projectDisks {
+		allowedDisks[disk.ID] = disk
+	}
+
+	var disks []WorkspaceDisk
+	for _, diskId := range command.DiskIDs {
+		disk, ok := allowedDisks[uuid.MustParse(diskId)]
+		if !ok {
+			return base.ErrorServe("Disk is not available", http.StatusBadRequest, w)
+		}
+		disks = append(disks, disk)
+	}
+
+	secrets, err := s.secretsForWorkspace(projectId, command)
+
+	if errors.Is(err, errSecretNotAvailable) {
+		return base.ErrorServe("Secret is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching secrets: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	image, err := s.imageForWorkspace(projectId, command)
+
+	if errors.Is(err, errImageRequired) {
+		return base.ErrorServe("Select an image", http.StatusBadRequest, w)
+	}
+	if errors.Is(err, errImageNotAvailable) {
+		return base.ErrorServe("Image is not available", http.StatusBadRequest, w)
+	}
+	if err != nil {
+		log.Printf("Error while fetching images: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	workspace := Workspace{
+		ID:   uuid.New(),
+		Name: command.Name,
+		Project: WorkspaceProject{
+			ID: projectId,
+		},
+		Owner: Owner{
+			Username: ownerUsername,
+			Email:    ownerEmail,
+		},
+		Image:     command.Image,
+		Port:      services.WorkspacePort,
+		CPU:       command.CPU,
+		RAM:       command.RAM,
+		State:     StateRunning,
+		Disks:     disks,
+		Secrets:   secrets,
+		CreatedAt: time.Now(),
+	}
+
+	if image != nil {
+		workspace.ImageID = uuid.NullUUID{UUID: image.ID, Valid: true}
+		workspace.Image = image.Image
+		workspace.Command = image.DefaultCommand
+		if image.Port != nil {
+			workspace.Port = *image.Port
+		}
+	}
+
+	err = s.repository.CreateWorkspace(r.Context(), workspace)
+
+	if err != nil {
+		log.Printf("Error while creating workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.StartWorkspace(r.Context(), workspace.ToSpec(s.kuberService.WorkspaceToken(workspace.ID.String())))
+
+	if err != nil {
+		s.kuberService.DeleteWorkspace(r.Context(), workspace.GetNamespace(), workspace.GetPodName())
+		s.repository.DeleteWorkspace(workspace.ID)
+		log.Printf("Error while starting workspace: %s", err)
+		if apierrors.IsForbidden(err) {
+			return base.ErrorServe("Project quota exceeded", http.StatusBadRequest, w)
+		}
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	workspace, err = s.repository.GetWorkspaceByID(workspace.ID)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	s.setStatus(&workspace)
+
+	return base.Serve(workspacesweb.WorkspaceRow(workspace.ToWebWorkspace()), w)
+}
+
+func (s *WorkspaceService) StartWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	if workspace.State != StateRunning {
+		err = s.kuberService.StartWorkspace(r.Context(), workspace.ToSpec(s.kuberService.WorkspaceToken(workspace.ID.String())))
+
+		if err != nil {
+			log.Printf("Error while starting workspace: %s", err)
+			if apierrors.IsAlreadyExists(err) {
+				return base.ErrorServe("Workspace is still stopping", http.StatusBadRequest, w)
+			}
+			if apierrors.IsForbidden(err) {
+				return base.ErrorServe("Project quota exceeded", http.StatusBadRequest, w)
+			}
+			return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+		}
+
+		err = s.repository.SetWorkspaceState(workspaceId, StateRunning)
+
+		if err != nil {
+			log.Printf("Error while updating workspace: %s", err)
+			return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+		}
+		workspace.State = StateRunning
+	}
+
+	s.setStatus(&workspace)
+
+	return base.Serve(workspacesweb.WorkspaceRow(workspace.ToWebWorkspace()), w)
+}
+
+func (s *WorkspaceService) StopWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.StopWorkspace(r.Context(), workspace.GetNamespace(), workspace.GetPodName())
+
+	if err != nil {
+		log.Printf("Error while stopping workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.SetWorkspaceState(workspaceId, StateStopped)
+
+	if err != nil {
+		log.Printf("Error while updating workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	workspace.State = StateStopped
+	s.setStatus(&workspace)
+
+	return base.Serve(workspacesweb.WorkspaceRow(workspace.ToWebWorkspace()), w)
+}
+
+func (s *WorkspaceService) SetKeepRunning(w http.ResponseWriter, r *http.Request, command SetKeepRunningCommand) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	err = s.repository.SetWorkspaceKeepRunning(workspaceId, command.KeepRunning)
+
+	if err != nil {
+		log.Printf("Error while updating workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	s.setStatus(&workspace)
+
+	return base.Serve(workspacesweb.WorkspaceRow(workspace.ToWebWorkspace()), w)
+}
+
+func (s *WorkspaceService) DeleteWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.kuberService.DeleteWorkspace(r.Context(), workspace.GetNamespace(), workspace.GetPodName())
+
+	if err != nil {
+		log.Printf("Error while deleting workspace resources: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	err = s.repository.DeleteWorkspace(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while deleting workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	return base.ServeNoSwap(w)
+}
+
+func (s *WorkspaceService) GetWorkspaceStatus(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return base.ErrorServe("Bad request brother", http.StatusBadRequest, w)
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return base.ErrorServe("You can't brother", http.StatusBadRequest, w)
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil {
+		log.Printf("Error while fetching workspace: %s", err)
+		return base.ErrorServe("Something went wrong", http.StatusInternalServerError, w)
+	}
+
+	s.setStatus(&workspace)
+
+	return base.Serve(workspacesweb.WorkspaceStatus(workspace.ID, workspace.Status.String()), w)
+}
+
+// ProxyWorkspace forwards everything under the workspace base URL to the
+// workspace service. Only the workspace owner gets through.
+func (s *WorkspaceService) ProxyWorkspace(w http.ResponseWriter, r *http.Request) http.HandlerFunc {
+	workspaceId, err := uuid.Parse(chi.URLParam(r, "workspace_id"))
+
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+		}
+	}
+
+	if !s.repository.CanManageWorkspace(workspaceId, r.Context()) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+
+	workspace, err := s.repository.GetWorkspaceByID(workspaceId)
+
+	if err != nil || workspace.State != StateRunning {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Workspace is not running", http.StatusServiceUnavailable)
+		}
+	}
+
+	// a custom command doesn't know the token, it gets it as TokenEnv
+	token := ""
+	if workspace.Command == "" {
+		token = s.kuberService.WorkspaceToken(workspace.ID.String())
+	}
+
+	return s.kuberService.ServiceProxy(workspace.GetNamespace(), workspace.GetPodName(), workspace.Port, token).ServeHTTP
+}
+
+func ProvideWorkspaceService(cfg *config.Config, repository WorkspaceRepository, kuberService *services.KuberService) *WorkspaceService {
+	return NewWorkspaceService(cfg, repository, kuberService)
+}
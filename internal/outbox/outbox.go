@@ -0,0 +1,295 @@
+package outbox
+
+import (
+	"aispace/internal/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusProcessed = "processed"
+	StatusFailed    = "failed"
+
+	// Entities that are provisioned through the outbox carry one of these
+	// in their provision_status column.
+	ProvisionPending     = "pending"
+	ProvisionProvisioned = "provisioned"
+	ProvisionFailed      = "failed"
+	ProvisionDeleting    = "deleting"
+
+	pollInterval = 2 * time.Second
+	maxAttempts  = 8
+	maxBackoff   = 5 * time.Minute
+	// a claimed message is left alone by other replicas for claimLease, the
+	// handler is cancelled well before that
+	claimLease    = 5 * time.Minute
+	handleTimeout = 3 * time.Minute
+)
+
+type Message struct {
+	ID          uuid.UUID
+	EventType   string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	Attempts    int
+}
+
+// Handler performs the side effect of a message. Handle is retried with
+// backoff until it succeeds or the attempts run out, then OnFailure is
+// called once so the owner can mark its entity as failed.
+type Handler struct {
+	Handle    func(ctx context.Context, msg Message) error
+	OnFailure func(ctx context.Context, msg Message, err error) error
+}
+
+func NewMessage(eventType string, aggregateID uuid.UUID, payload any) (Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("marshal outbox payload: %w", err)
+	}
+
+	return Message{
+		ID:          uuid.New(),
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	}, nil
+}
+
+func (m Message) Decode(payload any) error {
+	return json.Unmarshal(m.Payload, payload)
+}
+
+// Enqueue writes a message inside the caller's transaction, so it is stored
+// if and only if the entity change is committed.
+func Enqueue(ctx context.Context, tx *sql.Tx, msg Message) error {
+	query := `
+		INSERT INTO outbox (id, event_type, aggregate_id, payload)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := tx.ExecContext(ctx, query, msg.ID, msg.EventType, msg.AggregateID, []byte(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("enqueue outbox message: %w", err)
+	}
+
+	return nil
+}
+
+type Dispatcher struct {
+	uow      storage.UnitOfWork
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func NewDispatcher(uow storage.UnitOfWork) *Dispatcher {
+	return &Dispatcher{
+		uow:      uow,
+		handlers: make(map[string]Handler),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Register(eventType string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[eventType] = handler
+}
+
+func (d *Dispatcher) handler(eventType string) (Handler, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	handler, ok := d.handlers[eventType]
+	return handler, ok
+}
+
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+func (d *Dispatcher) Stop() {
+	close(d.stopCh)
+	<-d.doneCh
+	fmt.Println("Outbox: dispatcher stopped.")
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.doneCh)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			d.drain()
+		}
+	}
+}
+
+func (d *Dispatcher) drain() {
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		default:
+		}
+
+		processed, err := d.processNext(context.Background())
+		if err != nil {
+			log.Printf("Outbox: %s", err)
+			return
+		}
+		if !processed {
+			return
+		}
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	delay := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// processNext claims one due message with SKIP LOCKED, so several mlspace
+// replicas can dispatch from the same table without stepping on each other.
+// The claim only pushes next_attempt_at by claimLease and commits, the handler
+// runs outside of the transaction so slow Kubernetes calls don't hold the row
+// lock. Messages wait while an earlier one of the same aggregate is still
+// undelivered, a provision in backoff is never overtaken by the deletion.
+func (d *Dispatcher) processNext(ctx context.Context) (bool, error) {
+	msg, claimed, err := d.claimNext(ctx)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	handler, ok := d.handler(msg.EventType)
+	if !ok {
+		return true, d.markFailed(ctx, msg, fmt.Errorf("no handler for %s", msg.EventType))
+	}
+
+	handleCtx, cancel := context.WithTimeout(ctx, handleTimeout)
+	handleErr := handler.Handle(handleCtx, msg)
+	cancel()
+
+	if handleErr == nil {
+		_, err = d.uow.DB().ExecContext(
+			ctx,
+			`UPDATE outbox SET status = $2, last_error = NULL, processed_at = NOW() WHERE id = $1`,
+			msg.ID,
+			StatusProcessed,
+		)
+		return true, err
+	}
+
+	log.Printf("Outbox: %s %s attempt %d failed: %s", msg.EventType, msg.AggregateID, msg.Attempts, handleErr)
+
+	if msg.Attempts >= maxAttempts {
+		if handler.OnFailure != nil {
+			if err := handler.OnFailure(ctx, msg, handleErr); err != nil {
+				log.Printf("Outbox: failure hook of %s %s: %s", msg.EventType, msg.AggregateID, err)
+			}
+		}
+		return true, d.markFailed(ctx, msg, handleErr)
+	}
+
+	_, err = d.uow.DB().ExecContext(
+		ctx,
+		`UPDATE outbox SET last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		msg.ID,
+		handleErr.Error(),
+		time.Now().Add(backoff(msg.Attempts)),
+	)
+	return true, err
+}
+
+// claimNext counts the attempt up front, a replica dying while handling a
+// message leaves it to be picked up again once the lease runs out.
+func (d *Dispatcher) claimNext(ctx context.Context) (Message, bool, error) {
+	var msg Message
+	claimed := false
+
+	err := d.uow.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT o.id, o.event_type, o.aggregate_id, o.payload, o.attempts
+			FROM outbox o
+			WHERE o.status = $1 AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox earlier
+				WHERE earlier.aggregate_id = o.aggregate_id
+				AND earlier.status = $1
+				AND (earlier.created_at, earlier.id) < (o.created_at, o.id)
+			)
+			ORDER BY o.created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`
+
+		var payload []byte
+		err := tx.QueryRowContext(ctx, query, StatusPending).Scan(
+			&msg.ID,
+			&msg.EventType,
+			&msg.AggregateID,
+			&payload,
+			&msg.Attempts,
+		)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch outbox message: %w", err)
+		}
+		msg.Payload = payload
+		msg.Attempts++
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE outbox SET attempts = $2, next_attempt_at = $3 WHERE id = $1`,
+			msg.ID,
+			msg.Attempts,
+			time.Now().Add(claimLease),
+		)
+		if err != nil {
+			return fmt.Errorf("claim outbox message: %w", err)
+		}
+
+		claimed = true
+		return nil
+	})
+
+	return msg, claimed, err
+}
+
+func (d *Dispatcher) markFailed(ctx context.Context, msg Message, cause error) error {
+	_, err := d.uow.DB().ExecContext(
+		ctx,
+		`UPDATE outbox SET status = $2, last_error = $3, processed_at = NOW() WHERE id = $1`,
+		msg.ID,
+		StatusFailed,
+		cause.Error(),
+	)
+	return err
+}
+
+func ProvideDispatcher(uow storage.UnitOfWork) *Dispatcher {
+	return NewDispatcher(uow)
+}
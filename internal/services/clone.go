@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+var referenceGrantResource = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1beta1",
+	Resource: "referencegrants",
+}
+
+// CreatePVCFromPVC provisions a claim as a clone of another claim. Cloning
+// across namespaces goes through a ReferenceGrant in the source namespace,
+// it only covers the source claim and is removed once the clone is bound.
+func (k *KuberService) CreatePVCFromPVC(
+	ctx context.Context,
+	namespace string,
+	pvcName string,
+	size string,
+	ownerEmail string,
+	storage PVCStorage,
+	sourceNamespace string,
+	sourcePVCName string,
+) (*corev1.PersistentVolumeClaim, error) {
+	dataSource := &corev1.TypedObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: sourcePVCName,
+	}
+
+	if sourceNamespace == namespace {
+		return k.createPVC(ctx, namespace, pvcName, size, ownerEmail, storage, dataSource)
+	}
+
+	if !k.cfg.Kuber.CrossNamespaceClone {
+		return nil, fmt.Errorf("cross namespace clone is disabled")
+	}
+	if err := k.ensureCloneReferenceGrant(ctx, sourceNamespace, sourcePVCName, namespace); err != nil {
+		return nil, err
+	}
+	dataSource.Namespace = &sourceNamespace
+
+	pvc, err := k.createPVC(ctx, namespace, pvcName, size, ownerEmail, storage, dataSource)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		if err := k.ReleaseCloneReferenceGrant(ctx, namespace, sourceNamespace, sourcePVCName); err != nil {
+			log.Printf("Error while deleting reference grant: %s", err)
+		}
+	}
+
+	return pvc, err
+}
+
+// cloneReferenceGrantName is unique per source claim and target namespace,
+// clones into other namespaces don't share a grant.
+func cloneReferenceGrantName(targetNamespace, sourcePVCName string) string {
+	return fmt.Sprintf("mlspace-clone-%s-%s", targetNamespace, sourcePVCName)
+}
+
+func (k *KuberService) ensureCloneReferenceGrant(ctx context.Context, sourceNamespace, sourcePVCName, targetNamespace string) error {
+	grant := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": referenceGrantResource.Group + "/" + referenceGrantResource.Version,
+			"kind":       "ReferenceGrant",
+			"metadata": map[string]any{
+				"name":      cloneReferenceGrantName(targetNamespace, sourcePVCName),
+				"namespace": sourceNamespace,
+				"labels": map[string]any{
+					ManagedByLabel: ManagedByValue,
+				},
+			},
+			"spec": map[string]any{
+				"from": []any{
+					map[string]any{
+						"group":     "",
+						"kind":      "PersistentVolumeClaim",
+						"namespace": targetNamespace,
+					},
+				},
+				"to": []any{
+					map[string]any{
+						"group": "",
+						"kind":  "PersistentVolumeClaim",
+						"name":  sourcePVCName,
+					},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// an existing grant has the same name and so the same spec, e.g. of a
+	// retried or a parallel clone
+	_, err := k.dynamicClient.Resource(referenceGrantResource).Namespace(sourceNamespace).Create(ctx, grant, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create reference grant: %w", err)
+	}
+
+	return nil
+}
+
+// pendingClone tells whether a claim of the namespace still waits for data
+// from the source claim.
+func (k *KuberService) pendingClone(namespace, sourceNamespace, sourcePVCName string) bool {
+	pending := false
+
+	cache.ListAllByNamespace(k.pvcLister, namespace, labels.Everything(), func(obj interface{}) {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		if !ok || pvc.DeletionTimestamp != nil || pvc.Status.Phase == corev1.ClaimBound {
+			return
+		}
+		if source, ok := cloneSource(pvc); ok && source.Namespace == sourceNamespace && source.Name == sourcePVCName {
+			pending = true
+		}
+	})
+
+	return pending
+}
+
+// ReleaseCloneReferenceGrant removes the grant of a clone unless another
+// clone of the same source into the namespace is still pending.
+func (k *KuberService) ReleaseCloneReferenceGrant(ctx context.Context, namespace, sourceNamespace, sourcePVCName string) error {
+	if k.pendingClone(namespace, sourceNamespace, sourcePVCName) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.dynamicClient.Resource(referenceGrantResource).Namespace(sourceNamespace).Delete(ctx, cloneReferenceGrantName(namespace, sourcePVCName), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete reference grant: %w", err)
+	}
+
+	return nil
+}
+
+// cloneSource returns the source of a claim cloned from another namespace.
+func cloneSource(pvc *corev1.PersistentVolumeClaim) (ClusterObject, bool) {
+	ref := pvc.Spec.DataSourceRef
+	if ref == nil || ref.Kind != "PersistentVolumeClaim" || ref.Namespace == nil || *ref.Namespace == pvc.Namespace {
+		return ClusterObject{}, false
+	}
+
+	return ClusterObject{Namespace: *ref.Namespace, Name: ref.Name}, true
+}
+
+// watchCloneReferenceGrants releases the grant of a cross namespace clone
+// once the claim is bound, the data is copied by then, or when the claim is
+// deleted before that.
+func (k *KuberService) watchCloneReferenceGrants() {
+	release := func(pvc *corev1.PersistentVolumeClaim) {
+		source, ok := cloneSource(pvc)
+		if !ok {
+			return
+		}
+		go func() {
+			if err := k.ReleaseCloneReferenceGrant(context.Background(), pvc.Namespace, source.Namespace, source.Name); err != nil {
+				log.Printf("Error while deleting reference grant: %s", err)
+			}
+		}()
+	}
+
+	k.pvcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok && pvc.Status.Phase == corev1.ClaimBound {
+				release(pvc)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPVC, ok := oldObj.(*corev1.PersistentVolumeClaim)
+			if !ok {
+				return
+			}
+			newPVC, ok := newObj.(*corev1.PersistentVolumeClaim)
+			if !ok {
+				return
+			}
+			if oldPVC.Status.Phase != corev1.ClaimBound && newPVC.Status.Phase == corev1.ClaimBound {
+				release(newPVC)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok && pvc.Status.Phase != corev1.ClaimBound {
+				release(pvc)
+			}
+		},
+	})
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+const CronJobIDLabel = "mlspace.io/cronjob-id"
+
+// manualRunAnnotation is what kubectl sets on Jobs created from a CronJob
+// by hand, kept so both kinds of manual runs look the same.
+const manualRunAnnotation = "cronjob.kubernetes.io/instantiate"
+
+type CronJobSpec struct {
+	ID                string
+	Name              string
+	Namespace         string
+	ProjectID         string
+	OwnerEmail        string
+	Image             string
+	Command           string
+	CPU               int
+	RAM               int
+	Disks             []DiskMount
+	Secrets           []SecretMount
+	Schedule          string
+	TimeZone          string
+	ConcurrencyPolicy string
+	SuccessfulHistory int
+	FailedHistory     int
+	Suspended         bool
+}
+
+// CronJobRun is one Job started by a CronJob, scheduled or manual.
+type CronJobRun struct {
+	Name      string
+	PodName   string
+	Manual    bool
+	CreatedAt time.Time
+	State     JobState
+}
+
+// labels carry no JobIDLabel, runs have no row in the jobs table and must
+// stay out of the job state updates.
+func (s CronJobSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel: ManagedByValue,
+		ProjectIDLabel: s.ProjectID,
+		CronJobIDLabel: s.ID,
+	}
+}
+
+func (s CronJobSpec) cronJob() *batchv1.CronJob {
+	backoffLimit := int32(0)
+	successfulHistory := int32(s.SuccessfulHistory)
+	failedHistory := int32(s.FailedHistory)
+	suspend := s.Suspended
+
+	jobSpec := JobSpec{
+		OwnerEmail: s.OwnerEmail,
+		Image:      s.Image,
+		Command:    s.Command,
+		CPU:        s.CPU,
+		RAM:        s.RAM,
+		Disks:      s.Disks,
+		Secrets:    s.Secrets,
+	}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   s.Schedule,
+			ConcurrencyPolicy:          batchv1.ConcurrencyPolicy(s.ConcurrencyPolicy),
+			SuccessfulJobsHistoryLimit: &successfulHistory,
+			FailedJobsHistoryLimit:     &failedHistory,
+			Suspend:                    &suspend,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: s.labels(),
+					Annotations: map[string]string{
+						OwnerEmailAnnotation: s.OwnerEmail,
+					},
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template:     jobSpec.podTemplate(s.labels()),
+				},
+			},
+		},
+	}
+
+	if s.TimeZone != "" {
+		timeZone := s.TimeZone
+		cronJob.Spec.TimeZone = &timeZone
+	}
+
+	return cronJob
+}
+
+func (k *KuberService) CreateCronJob(ctx context.Context, spec CronJobSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.BatchV1().CronJobs(spec.Namespace).Create(ctx, spec.cronJob(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create cronjob: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCronJob removes the CronJob together with the runs it still keeps.
+func (k *KuberService) DeleteCronJob(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().CronJobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete cronjob: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) SuspendCronJob(ctx context.Context, namespace, name string, suspend bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"suspend": suspend,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.BatchV1().CronJobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("patch cronjob: %w", err)
+	}
+
+	return nil
+}
+
+// RunCronJob starts a Job from the CronJob template right away, the same
+// way kubectl create job --from=cronjob does. The CronJob owns the run so
+// it is cleaned up with it.
+func (k *KuberService) RunCronJob(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cronJob, err := k.clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get cronjob: %w", err)
+	}
+
+	annotations := map[string]string{manualRunAnnotation: "manual"}
+	for key, value := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[key] = value
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-manual-%d", name, time.Now().Unix()),
+			Namespace:   namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+
+	_, err = k.clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) runPods(namespace, jobName string) []*corev1.Pod {
+	var pods []*corev1.Pod
+
+	selector := labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: jobName})
+	cache.ListAllByNamespace(k.podLister, namespace, selector, func(obj interface{}) {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	})
+
+	return pods
+}
+
+// GetCronJobRuns lists the runs the CronJob still keeps, newest first.
+func (k *KuberService) GetCronJobRuns(namespace, cronJobID string) []CronJobRun {
+	var runs []CronJobRun
+
+	selector := labels.SelectorFromSet(labels.Set{CronJobIDLabel: cronJobID})
+	cache.ListAllByNamespace(k.jobLister, namespace, selector, func(obj interface{}) {
+		job, ok := obj.(*batchv1.Job)
+		if !ok {
+			return
+		}
+
+		pods := k.runPods(namespace, job.Name)
+		run := CronJobRun{
+			Name:      job.Name,
+			Manual:    job.Annotations[manualRunAnnotation] != "",
+			CreatedAt: job.CreationTimestamp.Time,
+			State:     jobStateFromPods(job, pods),
+		}
+
+		var newest *corev1.Pod
+		for _, pod := range pods {
+			if newest == nil || pod.CreationTimestamp.After(newest.CreationTimestamp.Time) {
+				newest = pod
+			}
+		}
+		if newest != nil {
+			run.PodName = newest.Name
+		}
+
+		runs = append(runs, run)
+	})
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+
+	return runs
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	DeploymentIDLabel = "mlspace.io/deployment-id"
+	DeploymentPort    = 8080
+
+	// RevisionAnnotation carries the mlspace revision on the pod template,
+	// a rollback to equal settings still rolls the pods.
+	RevisionAnnotation = "mlspace.io/revision"
+
+	// ModelPathEnv points the serving command to the model on the disk.
+	ModelPathEnv   = "MODEL_PATH"
+	ModelMountPath = "/mnt/model"
+)
+
+type DeploymentSpec struct {
+	ID           string
+	Name         string
+	Namespace    string
+	ProjectID    string
+	OwnerEmail   string
+	Image        string
+	Command      string
+	Port         int
+	CPU          int
+	RAM          int
+	Replicas     int
+	Revision     int
+	ModelPVCName string
+	ModelPath    string
+	IngressHost  string
+	IngressClass string
+}
+
+// DeploymentState is what the informer knows about a Deployment rollout.
+type DeploymentState struct {
+	Replicas  int32
+	Ready     int32
+	Updated   int32
+	Available int32
+	Status    string
+	Reason    string
+}
+
+func (s DeploymentSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel:    ManagedByValue,
+		ProjectIDLabel:    s.ProjectID,
+		DeploymentIDLabel: s.ID,
+	}
+}
+
+func (s DeploymentSpec) port() int32 {
+	if s.Port == 0 {
+		return DeploymentPort
+	}
+	return int32(s.Port)
+}
+
+// podTemplate mounts the model disk read-only, the same claim can then
+// back every replica as long as its access mode allows it.
+func (s DeploymentSpec) podTemplate() corev1.PodTemplateSpec {
+	var mounts []DiskMount
+	env := []corev1.EnvVar{
+		{Name: "PORT", Value: strconv.Itoa(int(s.port()))},
+	}
+
+	if s.ModelPVCName != "" {
+		mounts = append(mounts, DiskMount{
+			PVCName:   s.ModelPVCName,
+			MountPath: ModelMountPath,
+			ReadOnly:  true,
+		})
+		env = append(env, corev1.EnvVar{Name: ModelPathEnv, Value: path.Join(ModelMountPath, s.ModelPath)})
+	}
+
+	volumes, volumeMounts := diskVolumes(mounts)
+
+	container := corev1.Container{
+		Name:  "model",
+		Image: s.Image,
+		Env:   env,
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: s.port()},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(s.port())},
+			},
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+		},
+		Resources:    resourceRequirements(s.CPU, s.RAM),
+		VolumeMounts: volumeMounts,
+	}
+
+	if s.Command != "" {
+		container.Command = []string{"/bin/sh", "-c", s.Command}
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+				RevisionAnnotation:   strconv.Itoa(s.Revision),
+			},
+		},
+		Spec: corev1.PodSpec{
+			Volumes:    volumes,
+			Containers: []corev1.Container{container},
+		},
+	}
+}
+
+// deployment replaces pods one at a time and only after the new one is
+// ready, so a broken revision never takes the serving pods down.
+func (s DeploymentSpec) deployment() *appsv1.Deployment {
+	replicas := int32(s.Replicas)
+	history := int32(2)
+	maxUnavailable := intstr.FromInt32(0)
+	maxSurge := intstr.FromInt32(1)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas:             &replicas,
+			RevisionHistoryLimit: &history,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{DeploymentIDLabel: s.ID},
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxUnavailable: &maxUnavailable,
+					MaxSurge:       &maxSurge,
+				},
+			},
+			Template: s.podTemplate(),
+		},
+	}
+}
+
+func (s DeploymentSpec) service() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{DeploymentIDLabel: s.ID},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       s.port(),
+					TargetPort: intstr.FromInt32(s.port()),
+				},
+			},
+		},
+	}
+}
+
+func (s DeploymentSpec) ingress() *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: s.IngressHost,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: s.Name,
+											Port: networkingv1.ServiceBackendPort{Number: s.port()},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if s.IngressClass != "" {
+		className := s.IngressClass
+		ingress.Spec.IngressClassName = &className
+	}
+
+	return ingress
+}
+
+// CreateDeployment creates the Deployment, its Service and, when a host is
+// set, an Ingress in front of it.
+func (k *KuberService) CreateDeployment(ctx context.Context, spec DeploymentSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.CoreV1().Services(spec.Namespace).Create(ctx, spec.service(), metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create deployment service: %w", err)
+	}
+
+	if spec.IngressHost != "" {
+		_, err = k.clientset.NetworkingV1().Ingresses(spec.Namespace).Create(ctx, spec.ingress(), metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("create deployment ingress: %w", err)
+		}
+	}
+
+	_, err = k.clientset.AppsV1().Deployments(spec.Namespace).Create(ctx, spec.deployment(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create deployment: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDeployment rolls the pods to a new revision. The pod template is
+// replaced as a whole, a merge would keep volumes the revision dropped.
+func (k *KuberService) UpdateDeployment(ctx context.Context, spec DeploymentSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	deployments := k.clientset.AppsV1().Deployments(spec.Namespace)
+	deployment := spec.deployment()
+
+	existing, err := deployments.Get(ctx, spec.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get deployment: %w", err)
+	}
+
+	existing.Spec.Replicas = deployment.Spec.Replicas
+	existing.Spec.Template = deployment.Spec.Template
+
+	_, err = deployments.Update(ctx, existing, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("update deployment: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) ScaleDeployment(ctx context.Context, namespace, name string, replicas int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"replicas": replicas,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("scale deployment: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) DeleteDeployment(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete deployment: %w", err)
+	}
+
+	err = k.clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete deployment ingress: %w", err)
+	}
+
+	err = k.clientset.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete deployment service: %w", err)
+	}
+
+	return nil
+}
+
+func deploymentCondition(deployment *appsv1.Deployment, conditionType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range deployment.Status.Conditions {
+		if deployment.Status.Conditions[i].Type == conditionType {
+			return &deployment.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// GetDeploymentState reads the rollout from the informer cache. A rollout
+// past its progress deadline is reported as failed, the old pods keep
+// serving in that case.
+func (k *KuberService) GetDeploymentState(namespace, name string) DeploymentState {
+	obj, exists, err := k.deploymentLister.GetByKey(fmt.Sprintf("%s/%s", namespace, name))
+	if err != nil || !exists {
+		return DeploymentState{Status: "Unknown"}
+	}
+
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return DeploymentState{Status: "Unknown"}
+	}
+
+	state := DeploymentState{
+		Ready:     deployment.Status.ReadyReplicas,
+		Updated:   deployment.Status.UpdatedReplicas,
+		Available: deployment.Status.AvailableReplicas,
+		Status:    "Updating",
+	}
+	if deployment.Spec.Replicas != nil {
+		state.Replicas = *deployment.Spec.Replicas
+	}
+
+	progressing := deploymentCondition(deployment, appsv1.DeploymentProgressing)
+
+	switch {
+	case state.Replicas == 0:
+		state.Status = "Stopped"
+	case progressing != nil && progressing.Reason == "ProgressDeadlineExceeded":
+		state.Status = "Failed"
+		state.Reason = progressing.Message
+	case deployment.Status.ObservedGeneration >= deployment.Generation &&
+		state.Updated == state.Replicas &&
+		state.Ready == state.Replicas &&
+		deployment.Status.Replicas == state.Replicas:
+		state.Status = "Ready"
+	}
+
+	return state
+}
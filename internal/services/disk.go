@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+var ErrExpansionNotAllowed = errors.New("storage class does not allow volume expansion")
+
+func (k *KuberService) DeletePVC(ctx context.Context, namespace, pvcName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete pvc: %w", err)
+	}
+
+	return nil
+}
+
+// PVCExists asks the API server directly, the informer cache may still hold a
+// claim that is already gone.
+func (k *KuberService) PVCExists(ctx context.Context, namespace, pvcName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get pvc: %w", err)
+	}
+
+	return true, nil
+}
+
+// PVCTerminating tells if the claim is deleted already and only waits for
+// its finalizers, e.g. while a pod still uses it.
+func (k *KuberService) PVCTerminating(ctx context.Context, namespace, pvcName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pvc, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get pvc: %w", err)
+	}
+
+	return pvc.DeletionTimestamp != nil, nil
+}
+
+// RetainPVCVolume switches the reclaim policy of the volume bound to the claim
+// to Retain, so the data survives the claim deletion. It returns the name of
+// the volume, or an empty string when the claim is not bound.
+func (k *KuberService) RetainPVCVolume(ctx context.Context, namespace, pvcName string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pvc, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get pvc: %w", err)
+	}
+	if pvc.Spec.VolumeName == "" {
+		return "", nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"persistentVolumeReclaimPolicy": corev1.PersistentVolumeReclaimRetain,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = k.clientset.CoreV1().PersistentVolumes().Patch(ctx, pvc.Spec.VolumeName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return "", fmt.Errorf("patch pv: %w", err)
+	}
+
+	return pvc.Spec.VolumeName, nil
+}
+
+// WorkloadsMountingPVC returns the managed pods that are not finished yet
+// and the Deployments and CronJobs whose pod template mounts the claim, a
+// Deployment scaled to 0 or a CronJob between runs still needs the disk. Disk
+// browser pods are left out, they are stopped along with the disk.
+func (k *KuberService) WorkloadsMountingPVC(ctx context.Context, namespace, pvcName string) ([]string, error) {
+	var workloads []string
+
+	for _, obj := range k.podLister.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Namespace != namespace || pod.Labels[DiskBrowserLabel] != "" {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if mountsPVC(pod.Spec, pvcName) {
+			workloads = append(workloads, "pod/"+pod.Name)
+		}
+	}
+
+	for _, obj := range k.deploymentLister.List() {
+		deployment, ok := obj.(*appsv1.Deployment)
+		if !ok || deployment.Namespace != namespace {
+			continue
+		}
+		if mountsPVC(deployment.Spec.Template.Spec, pvcName) {
+			workloads = append(workloads, "deployment/"+deployment.Name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cronJobs, err := k.clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list cron jobs: %w", err)
+	}
+	for _, cronJob := range cronJobs.Items {
+		if mountsPVC(cronJob.Spec.JobTemplate.Spec.Template.Spec, pvcName) {
+			workloads = append(workloads, "cronjob/"+cronJob.Name)
+		}
+	}
+
+	return workloads, nil
+}
+
+func mountsPVC(spec corev1.PodSpec, pvcName string) bool {
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvcName {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *KuberService) claimStorageClass(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+		return *pvc.Spec.StorageClassName, nil
+	}
+
+	classes, err := k.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("list storage classes: %w", err)
+	}
+	for _, class := range classes.Items {
+		if class.Annotations[defaultStorageClassAnnotation] == "true" {
+			return class.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("pvc %s/%s has no storage class", pvc.Namespace, pvc.Name)
+}
+
+// CanExpandPVC checks the allowVolumeExpansion flag of the claim's storage
+// class and returns ErrExpansionNotAllowed when it is not set.
+func (k *KuberService) CanExpandPVC(ctx context.Context, namespace, pvcName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pvc, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get pvc: %w", err)
+	}
+
+	className, err := k.claimStorageClass(ctx, pvc)
+	if err != nil {
+		return err
+	}
+
+	class, err := k.clientset.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get storage class: %w", err)
+	}
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return ErrExpansionNotAllowed
+	}
+
+	return nil
+}
+
+// ExpandPVC raises the storage request of the claim. Requests are never
+// lowered, so replaying an older resize is a no-op.
+func (k *KuberService) ExpandPVC(ctx context.Context, namespace, pvcName, size string) error {
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pvc, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get pvc: %w", err)
+	}
+
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if quantity.Cmp(current) <= 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"resources": map[string]any{
+				"requests": map[string]any{
+					string(corev1.ResourceStorage): quantity.String(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, pvcName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("patch pvc: %w", err)
+	}
+
+	return nil
+}
+
+// GetPVCConditions returns the true conditions of the claim from the informer
+// cache, e.g. Resizing or FileSystemResizePending while it is being expanded.
+func (k *KuberService) GetPVCConditions(namespace, pvcName string) []string {
+	obj, exists, err := k.pvcLister.GetByKey(fmt.Sprintf("%s/%s", namespace, pvcName))
+	if err != nil || !exists {
+		return nil
+	}
+
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return nil
+	}
+
+	var conditions []string
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			conditions = append(conditions, string(condition.Type))
+		}
+	}
+
+	return conditions
+}
+
+func (k *KuberService) StorageClassExists(ctx context.Context, className string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get storage class: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	DiskBrowserLabel = "mlspace.io/disk-browser-id"
+
+	diskBrowserContainer = "browser"
+	diskBrowserMountPath = "/mnt/disk"
+	// a pod closer to its end is not handed out, a new one is started
+	diskBrowserMinRemaining = 2 * time.Minute
+	// how long a request waits for a starting pod before it is told to retry
+	diskBrowserWait = 10 * time.Second
+	// a pod not running after this is given up and its Job deleted
+	diskBrowserStartTimeout = 2 * time.Minute
+)
+
+var (
+	ErrNotRegularFile        = errors.New("not a regular file")
+	ErrOutsideDisk           = errors.New("path is outside of the disk")
+	ErrDiskBrowserStarting   = errors.New("disk browser is still starting")
+	ErrDiskAttachedElsewhere = errors.New("disk is attached to another node")
+)
+
+// DiskBrowserSpec is a helper pod that mounts a disk for the file browser.
+// It runs as a Job that ends after Lifetime and is then removed by its TTL,
+// file operations are executed in it.
+type DiskBrowserSpec struct {
+	DiskID     string
+	Namespace  string
+	ProjectID  string
+	OwnerEmail string
+	Image      string
+	PVCName    string
+	Lifetime   time.Duration
+}
+
+// DiskFile is a directory entry of a disk, Path is relative to the disk
+// root.
+type DiskFile struct {
+	Name       string
+	Path       string
+	Dir        bool
+	Size       int64
+	ModifiedAt time.Time
+}
+
+func (s DiskBrowserSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel:   ManagedByValue,
+		ProjectIDLabel:   s.ProjectID,
+		DiskBrowserLabel: s.DiskID,
+	}
+}
+
+// jobName is the same for every request within one window of the browser
+// lifetime, so concurrent requests end up waiting for the same Job instead
+// of each starting one. A Job started in a window stays usable until the
+// window is over.
+func (s DiskBrowserSpec) jobName(now time.Time) string {
+	window := s.Lifetime - diskBrowserMinRemaining
+	if window < time.Minute {
+		window = time.Minute
+	}
+
+	return fmt.Sprintf("disk-browser-%s-%s", s.DiskID, strconv.FormatInt(now.Unix()/int64(window.Seconds()), 36))
+}
+
+func (s DiskBrowserSpec) job(name string) *batchv1.Job {
+	backoffLimit := int32(0)
+	ttl := int32(0)
+	seconds := int64(s.Lifetime.Seconds())
+	deadline := seconds + 60
+	automount := false
+
+	volumes, volumeMounts := diskVolumes([]DiskMount{
+		{PVCName: s.PVCName, MountPath: diskBrowserMountPath},
+	})
+
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			ActiveDeadlineSeconds:   &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: s.labels(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:                corev1.RestartPolicyNever,
+					AutomountServiceAccountToken: &automount,
+					Volumes:                      volumes,
+					Containers: []corev1.Container{
+						{
+							Name:    diskBrowserContainer,
+							Image:   s.Image,
+							Command: []string{"sleep", strconv.FormatInt(seconds, 10)},
+							Resources: corev1.ResourceRequirements{
+								Requests: resources,
+								Limits:   resources.DeepCopy(),
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runningDiskBrowser returns a ready browser pod of the disk that still has
+// some time left.
+func (k *KuberService) runningDiskBrowser(spec DiskBrowserSpec) (string, bool) {
+	var podName string
+
+	selector := labels.SelectorFromSet(labels.Set{DiskBrowserLabel: spec.DiskID})
+	cache.ListAllByNamespace(k.podLister, spec.Namespace, selector, func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || podName != "" || pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			return
+		}
+		if pod.Status.StartTime == nil || time.Since(pod.Status.StartTime.Time) > spec.Lifetime-diskBrowserMinRemaining {
+			return
+		}
+		podName = pod.Name
+	})
+
+	return podName, podName != ""
+}
+
+// EnsureDiskBrowser returns the name of a running browser pod, starting one
+// when there is none. It waits diskBrowserWait at most and then returns
+// ErrDiskBrowserStarting, the caller asks again later. A Job that can't start
+// its pod is deleted, with ErrDiskAttachedElsewhere when the volume is in use
+// on another node.
+func (k *KuberService) EnsureDiskBrowser(ctx context.Context, spec DiskBrowserSpec) (string, error) {
+	if podName, ok := k.runningDiskBrowser(spec); ok {
+		return podName, nil
+	}
+
+	name := spec.jobName(time.Now())
+
+	createCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.BatchV1().Jobs(spec.Namespace).Create(createCtx, spec.job(name), metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("create disk browser: %w", err)
+	}
+
+	var podName string
+	var startErr error
+	err = wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, diskBrowserWait, true, func(context.Context) (bool, error) {
+		var ok bool
+		podName, ok = k.runningDiskBrowser(spec)
+		if ok {
+			return true, nil
+		}
+		startErr = k.diskBrowserStartError(spec.Namespace, name)
+		return startErr != nil, nil
+	})
+	if err == nil && startErr == nil {
+		return podName, nil
+	}
+
+	if startErr == nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", ErrDiskBrowserStarting
+	}
+
+	if err := k.deleteDiskBrowserJob(ctx, spec.Namespace, name); err != nil {
+		return "", fmt.Errorf("%w (cleanup: %s)", startErr, err)
+	}
+
+	return "", startErr
+}
+
+// diskBrowserStartError looks at the Job and the events of its pod for a
+// reason it won't start.
+func (k *KuberService) diskBrowserStartError(namespace, jobName string) error {
+	obj, exists, err := k.jobLister.GetByKey(namespace + "/" + jobName)
+	if err != nil || !exists {
+		return nil
+	}
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return nil
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("disk browser failed: %s", condition.Message)
+		}
+	}
+
+	if reason := k.warningEvent(namespace, "Job", jobName, "FailedCreate"); reason != "" {
+		return fmt.Errorf("disk browser pod can't be created: %s", reason)
+	}
+
+	var startErr error
+	selector := labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: jobName})
+	cache.ListAllByNamespace(k.podLister, namespace, selector, func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || startErr != nil {
+			return
+		}
+		if note := k.warningEvent(namespace, "Pod", pod.Name, "FailedAttachVolume"); strings.Contains(note, "Multi-Attach") {
+			startErr = ErrDiskAttachedElsewhere
+		}
+	})
+	if startErr != nil {
+		return startErr
+	}
+
+	if time.Since(job.CreationTimestamp.Time) > diskBrowserStartTimeout {
+		return errors.New("disk browser did not start in time")
+	}
+
+	return nil
+}
+
+// warningEvent returns the note of the newest warning with the reason about
+// the object, or an empty string.
+func (k *KuberService) warningEvent(namespace, kind, name, reason string) string {
+	objs, err := k.eventLister.ByIndex(eventRegardingIndex, eventRegardingKey(namespace, kind, name))
+	if err != nil {
+		return ""
+	}
+
+	var newest *eventsv1.Event
+	for _, obj := range objs {
+		event, ok := obj.(*eventsv1.Event)
+		if !ok || event.Type != EventTypeWarning || event.Reason != reason {
+			continue
+		}
+		if newest == nil || lastSeen(event).After(lastSeen(newest)) {
+			newest = event
+		}
+	}
+
+	if newest == nil {
+		return ""
+	}
+	return newest.Note
+}
+
+func (k *KuberService) deleteDiskBrowserJob(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().Jobs(namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete disk browser: %w", err)
+	}
+
+	return nil
+}
+
+// StopDiskBrowsers removes the browser jobs of a disk, e.g. before the disk
+// is deleted.
+func (k *KuberService) StopDiskBrowsers(ctx context.Context, namespace, diskID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().Jobs(namespace).DeleteCollection(ctx, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{DiskBrowserLabel: diskID}).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("delete disk browsers: %w", err)
+	}
+
+	return nil
+}
+
+// diskBrowserPath maps a path relative to the disk root into the pod. The
+// path is expected to be cleaned already.
+func diskBrowserPath(file string) string {
+	return path.Join(diskBrowserMountPath, file)
+}
+
+// runDiskBrowser passes the arguments as positional parameters of the
+// script, file names never end up in the shell source.
+func (k *KuberService) runDiskBrowser(ctx context.Context, namespace, podName string, stdin io.Reader, stdout io.Writer, script string, args ...string) error {
+	return k.RunInPod(ctx, namespace, podName, RunOptions{
+		Container: diskBrowserContainer,
+		Command:   append([]string{"/bin/sh", "-c", script, "sh"}, args...),
+		Stdin:     stdin,
+		Stdout:    stdout,
+	})
+}
+
+// parseDiskFile reads a line of `stat -c '%F/%s/%Y/%n'`. The file name is
+// the last field and can't contain a slash itself.
+func parseDiskFile(dir, line string) (DiskFile, bool) {
+	fields := strings.SplitN(line, "/", 4)
+	if len(fields) != 4 {
+		return DiskFile{}, false
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return DiskFile{}, false
+	}
+	modified, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return DiskFile{}, false
+	}
+
+	name := path.Base(fields[3])
+
+	return DiskFile{
+		Name:       name,
+		Path:       path.Join(dir, name),
+		Dir:        fields[0] == "directory",
+		Size:       size,
+		ModifiedAt: time.Unix(modified, 0),
+	}, true
+}
+
+// ListDiskFiles lists a directory, directories first.
+func (k *KuberService) ListDiskFiles(ctx context.Context, namespace, podName, dir string) ([]DiskFile, error) {
+	var stdout bytes.Buffer
+
+	err := k.runDiskBrowser(ctx, namespace, podName, nil, &stdout,
+		diskBrowserResolve+`; cd "$file" && find . -mindepth 1 -maxdepth 1 -exec stat -c '%F/%s/%Y/%n' {} +`,
+		diskBrowserPath(dir),
+	)
+	if err != nil {
+		return nil, outsideDiskError(err, ErrOutsideDisk)
+	}
+
+	var files []DiskFile
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if file, ok := parseDiskFile(dir, line); ok {
+			files = append(files, file)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Dir != files[j].Dir {
+			return files[i].Dir
+		}
+		return files[i].Name < files[j].Name
+	})
+
+	return files, nil
+}
+
+// diskBrowserResolve sets $file to the target of $1 and fails with status 3
+// when a link points outside of the disk.
+var diskBrowserResolve = fmt.Sprintf(`file=$(realpath "$1") || exit 3; case "$file" in %[1]s|%[1]s/*) ;; *) exit 3 ;; esac`, diskBrowserMountPath)
+
+// diskBrowserResolveParent resolves only the directory of $1 into $file, for
+// operations on the entry itself: a link is replaced or removed, not its
+// target.
+var diskBrowserResolveParent = fmt.Sprintf(`dir=$(realpath "$(dirname "$1")") || exit 3; case "$dir" in %[1]s|%[1]s/*) ;; *) exit 3 ;; esac; file="$dir/$(basename "$1")"`, diskBrowserMountPath)
+
+// outsideDiskError maps the exit status 3 of the resolve scripts.
+func outsideDiskError(err error, mapped error) error {
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitStatus() == 3 {
+		return mapped
+	}
+	return err
+}
+
+// StatDiskFile follows links, the size is the one ReadDiskFile will copy.
+func (k *KuberService) StatDiskFile(ctx context.Context, namespace, podName, file string) (DiskFile, error) {
+	var stdout bytes.Buffer
+
+	err := k.runDiskBrowser(ctx, namespace, podName, nil, &stdout,
+		diskBrowserResolve+`; exec stat -L -c '%F/%s/%Y/%n' "$1"`,
+		diskBrowserPath(file),
+	)
+	if err != nil {
+		return DiskFile{}, outsideDiskError(err, ErrNotRegularFile)
+	}
+
+	stat, ok := parseDiskFile(path.Dir(file), strings.TrimSpace(stdout.String()))
+	if !ok {
+		return DiskFile{}, fmt.Errorf("stat %s: unexpected output", file)
+	}
+
+	return stat, nil
+}
+
+// ReadDiskFile copies a regular file to w, limit > 0 stops after that many
+// bytes. Links are followed like in StatDiskFile.
+func (k *KuberService) ReadDiskFile(ctx context.Context, namespace, podName, file string, limit int64, w io.Writer) error {
+	script := diskBrowserResolve + `; [ -f "$file" ] || exit 3; exec cat "$file"`
+	if limit > 0 {
+		script = diskBrowserResolve + `; [ -f "$file" ] || exit 3; exec head -c "$2" "$file"`
+	}
+
+	err := k.runDiskBrowser(ctx, namespace, podName, nil, w, script, diskBrowserPath(file), strconv.FormatInt(limit, 10))
+
+	return outsideDiskError(err, ErrNotRegularFile)
+}
+
+// WriteDiskFile replaces the file with what is read from r. A link in its
+// place is replaced by the file, the write never follows it.
+func (k *KuberService) WriteDiskFile(ctx context.Context, namespace, podName, file string, r io.Reader) error {
+	err := k.runDiskBrowser(ctx, namespace, podName, r, nil,
+		diskBrowserResolveParent+`; if [ -L "$file" ]; then rm -f "$file" || exit 1; fi; cat > "$file"`,
+		diskBrowserPath(file),
+	)
+
+	return outsideDiskError(err, ErrOutsideDisk)
+}
+
+// DeleteDiskFile removes a file or a whole directory, a link is removed
+// without its target.
+func (k *KuberService) DeleteDiskFile(ctx context.Context, namespace, podName, file string) error {
+	err := k.runDiskBrowser(ctx, namespace, podName, nil, nil,
+		diskBrowserResolveParent+`; exec rm -rf "$file"`,
+		diskBrowserPath(file),
+	)
+
+	return outsideDiskError(err, ErrOutsideDisk)
+}
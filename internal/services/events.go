@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+
+	// events of one object, keyed by namespace/kind/name of the regarding ref
+	eventRegardingIndex = "regarding"
+)
+
+type ProjectEvent struct {
+	Kind     string
+	Name     string
+	Type     string
+	Reason   string
+	Note     string
+	Count    int32
+	LastSeen time.Time
+}
+
+func eventRegardingKey(namespace, kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
+
+func eventRegardingIndexFunc(obj interface{}) ([]string, error) {
+	event, ok := obj.(*eventsv1.Event)
+	if !ok {
+		return nil, nil
+	}
+	return []string{eventRegardingKey(event.Namespace, event.Regarding.Kind, event.Regarding.Name)}, nil
+}
+
+// lastSeen picks the newest timestamp an event carries, series first since
+// repeated events only bump the series.
+func lastSeen(event *eventsv1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		return event.DeprecatedLastTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func toProjectEvent(event *eventsv1.Event) ProjectEvent {
+	count := int32(1)
+	if event.Series != nil {
+		count = event.Series.Count
+	} else if event.DeprecatedCount > 0 {
+		count = event.DeprecatedCount
+	}
+
+	return ProjectEvent{
+		Kind:     event.Regarding.Kind,
+		Name:     event.Regarding.Name,
+		Type:     event.Type,
+		Reason:   event.Reason,
+		Note:     event.Note,
+		Count:    count,
+		LastSeen: lastSeen(event),
+	}
+}
+
+// GetProjectEvents returns the cached events of a project namespace, newest
+// first.
+func (k *KuberService) GetProjectEvents(namespace string) []ProjectEvent {
+	var events []ProjectEvent
+
+	objs, err := k.eventLister.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return nil
+	}
+	for _, obj := range objs {
+		if event, ok := obj.(*eventsv1.Event); ok {
+			events = append(events, toProjectEvent(event))
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+
+	return events
+}
+
+// GetPVCEventReason returns the newest warning about the claim, e.g. a
+// ProvisioningFailed from the provisioner, or an empty string.
+func (k *KuberService) GetPVCEventReason(namespace, pvcName string) string {
+	objs, err := k.eventLister.ByIndex(eventRegardingIndex, eventRegardingKey(namespace, "PersistentVolumeClaim", pvcName))
+	if err != nil {
+		return ""
+	}
+
+	var newest *eventsv1.Event
+	for _, obj := range objs {
+		event, ok := obj.(*eventsv1.Event)
+		if !ok || event.Type != EventTypeWarning {
+			continue
+		}
+		if newest == nil || lastSeen(event).After(lastSeen(newest)) {
+			newest = event
+		}
+	}
+
+	if newest == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s", newest.Reason, newest.Note)
+}
+
+// onPVCEvent calls fn with the claim a new or repeated warning is about, so
+// the disk status picks up the reason without waiting for the claim to
+// change.
+func (k *KuberService) onPVCEvent(fn func(*corev1.PersistentVolumeClaim)) {
+	handle := func(obj interface{}) {
+		event, ok := obj.(*eventsv1.Event)
+		if !ok || event.Type != EventTypeWarning || event.Regarding.Kind != "PersistentVolumeClaim" {
+			return
+		}
+		if !strings.HasPrefix(event.Namespace, ProjectNamespacePrefix) {
+			return
+		}
+
+		item, exists, err := k.pvcLister.GetByKey(fmt.Sprintf("%s/%s", event.Namespace, event.Regarding.Name))
+		if err != nil || !exists {
+			return
+		}
+		if pvc, ok := item.(*corev1.PersistentVolumeClaim); ok {
+			fn(pvc)
+		}
+	}
+
+	k.eventInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: handle,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldEvent, ok := oldObj.(*eventsv1.Event)
+			if !ok {
+				return
+			}
+			newEvent, ok := newObj.(*eventsv1.Event)
+			if ok && oldEvent.ResourceVersion != newEvent.ResourceVersion {
+				handle(newObj)
+			}
+		},
+	})
+}
+
+// projectEventsListWatch lists and watches events of every namespace but only
+// lets the project-* ones into the cache, there is no server side selector
+// for a namespace prefix.
+func projectEventsListWatch(clientset kubernetes.Interface) *cache.ListWatch {
+	inProject := func(obj runtime.Object) bool {
+		event, ok := obj.(*eventsv1.Event)
+		return ok && strings.HasPrefix(event.Namespace, ProjectNamespacePrefix)
+	}
+
+	return &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			list, err := clientset.EventsV1().Events(metav1.NamespaceAll).List(ctx, options)
+			if err != nil {
+				return nil, err
+			}
+
+			items := list.Items[:0]
+			for i := range list.Items {
+				if inProject(&list.Items[i]) {
+					items = append(items, list.Items[i])
+				}
+			}
+			list.Items = items
+
+			return list, nil
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			w, err := clientset.EventsV1().Events(metav1.NamespaceAll).Watch(ctx, options)
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				return in, in.Type == watch.Bookmark || in.Type == watch.Error || inProject(in.Object)
+			}), nil
+		},
+	}
+}
+
+func newProjectEventInformer(clientset kubernetes.Interface) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		projectEventsListWatch(clientset),
+		&eventsv1.Event{},
+		time.Minute,
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			eventRegardingIndex:  eventRegardingIndexFunc,
+		},
+	)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// TerminalShell prefers bash and falls back to sh for slim images.
+var TerminalShell = []string{"/bin/sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
+
+type TerminalSize struct {
+	Width  uint16
+	Height uint16
+}
+
+// ExecOptions describes an interactive session in a pod container. Resize
+// delivers terminal size changes and is closed by the caller at the end.
+type ExecOptions struct {
+	Container string
+	Command   []string
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Resize    <-chan TerminalSize
+}
+
+type terminalSizeQueue <-chan TerminalSize
+
+func (q terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q
+	if !ok {
+		return nil
+	}
+	return &remotecommand.TerminalSize{Width: size.Width, Height: size.Height}
+}
+
+// podExecutor talks to the pods/exec subresource. The WebSocket protocol is
+// tried first, SPDY is used for older API servers.
+func (k *KuberService) podExecutor(namespace, podName string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	request := k.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(options, scheme.ParameterCodec)
+
+	spdyExecutor, err := remotecommand.NewSPDYExecutor(k.restConfig, "POST", request.URL())
+	if err != nil {
+		return nil, fmt.Errorf("create spdy executor: %w", err)
+	}
+
+	websocketExecutor, err := remotecommand.NewWebSocketExecutor(k.restConfig, "GET", request.URL().String())
+	if err != nil {
+		return nil, fmt.Errorf("create websocket executor: %w", err)
+	}
+
+	executor, err := remotecommand.NewFallbackExecutor(websocketExecutor, spdyExecutor, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create exec executor: %w", err)
+	}
+
+	return executor, nil
+}
+
+// ExecInPod runs a command with a TTY. It blocks until the command exits or
+// ctx is cancelled.
+func (k *KuberService) ExecInPod(ctx context.Context, namespace, podName string, opts ExecOptions) error {
+	executor, err := k.podExecutor(namespace, podName, &corev1.PodExecOptions{
+		Container: opts.Container,
+		Command:   opts.Command,
+		Stdin:     true,
+		Stdout:    true,
+		TTY:       true,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:             opts.Stdin,
+		Stdout:            opts.Stdout,
+		Tty:               true,
+		TerminalSizeQueue: terminalSizeQueue(opts.Resize),
+	})
+	if err != nil {
+		return fmt.Errorf("exec in pod: %w", err)
+	}
+
+	return nil
+}
+
+// RunOptions describes a command without a TTY. Stdin may be nil, what the
+// command writes to stderr ends up in the returned error.
+type RunOptions struct {
+	Container string
+	Command   []string
+	Stdin     io.Reader
+	Stdout    io.Writer
+}
+
+// RunInPod runs a command to completion and fails when it exits non-zero.
+func (k *KuberService) RunInPod(ctx context.Context, namespace, podName string, opts RunOptions) error {
+	executor, err := k.podExecutor(namespace, podName, &corev1.PodExecOptions{
+		Container: opts.Container,
+		Command:   opts.Command,
+		Stdin:     opts.Stdin != nil,
+		Stdout:    opts.Stdout != nil,
+		Stderr:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("run in pod: %w: %s", err, message)
+		}
+		return fmt.Errorf("run in pod: %w", err)
+	}
+
+	return nil
+}
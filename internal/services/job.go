@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const JobIDLabel = "mlspace.io/job-id"
+
+type JobSpec struct {
+	ID         string
+	Name       string
+	Namespace  string
+	ProjectID  string
+	OwnerEmail string
+	Image      string
+	Command    string
+	CPU        int
+	RAM        int
+	Disks      []DiskMount
+	Secrets    []SecretMount
+	// finished Jobs are removed with their pods after TTL
+	TTL time.Duration
+}
+
+// JobState is what the informers know about a Job and its pod.
+type JobState struct {
+	JobID      string
+	Status     WorkloadStatus
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	ExitCode   *int32
+}
+
+func (s JobSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel: ManagedByValue,
+		ProjectIDLabel: s.ProjectID,
+		JobIDLabel:     s.ID,
+	}
+}
+
+func (s JobSpec) podTemplate(labels map[string]string) corev1.PodTemplateSpec {
+	volumes, volumeMounts := diskVolumes(s.Disks)
+	secretVolumes, secretMounts, envFrom := secretSources(s.Secrets)
+	volumes = append(volumes, secretVolumes...)
+	volumeMounts = append(volumeMounts, secretMounts...)
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Volumes:       volumes,
+			Containers: []corev1.Container{
+				{
+					Name:         "job",
+					Image:        s.Image,
+					Command:      []string{"/bin/sh", "-c", s.Command},
+					Resources:    resourceRequirements(s.CPU, s.RAM),
+					EnvFrom:      envFrom,
+					VolumeMounts: volumeMounts,
+				},
+			},
+		},
+	}
+}
+
+func (s JobSpec) job() *batchv1.Job {
+	backoffLimit := int32(0)
+	ttl := int32(s.TTL.Seconds())
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template:                s.podTemplate(s.labels()),
+		},
+	}
+}
+
+func (k *KuberService) CreateJob(ctx context.Context, spec JobSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.BatchV1().Jobs(spec.Namespace).Create(ctx, spec.job(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) DeleteJob(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().Jobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete job: %w", err)
+	}
+
+	return nil
+}
+
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *KuberService) jobPods(namespace, jobID string) []*corev1.Pod {
+	var pods []*corev1.Pod
+
+	selector := labels.SelectorFromSet(labels.Set{JobIDLabel: jobID})
+	cache.ListAllByNamespace(k.podLister, namespace, selector, func(obj interface{}) {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	})
+
+	return pods
+}
+
+// GetJobPodName returns the newest pod of a job, the one worth reading logs
+// from after a retry.
+func (k *KuberService) GetJobPodName(namespace, jobID string) (string, bool) {
+	var newest *corev1.Pod
+	for _, pod := range k.jobPods(namespace, jobID) {
+		if newest == nil || pod.CreationTimestamp.After(newest.CreationTimestamp.Time) {
+			newest = pod
+		}
+	}
+
+	if newest == nil {
+		return "", false
+	}
+	return newest.Name, true
+}
+
+func (k *KuberService) jobState(job *batchv1.Job) JobState {
+	return jobStateFromPods(job, k.jobPods(job.Namespace, job.Labels[JobIDLabel]))
+}
+
+// jobStateFromPods combines the Job conditions with what its pods report.
+func jobStateFromPods(job *batchv1.Job, pods []*corev1.Pod) JobState {
+	state := JobState{
+		JobID:  job.Labels[JobIDLabel],
+		Status: WorkloadPending,
+	}
+
+	if job.Status.StartTime != nil {
+		startedAt := job.Status.StartTime.Time
+		state.StartedAt = &startedAt
+	}
+
+	switch {
+	case jobCondition(job, batchv1.JobComplete):
+		state.Status = WorkloadSucceeded
+	case jobCondition(job, batchv1.JobFailed):
+		state.Status = WorkloadFailed
+	case job.Status.Active > 0:
+		state.Status = WorkloadRunning
+	}
+
+	for _, pod := range pods {
+		if state.Status == WorkloadRunning && pod.Status.Phase == corev1.PodPending {
+			state.Status = WorkloadPending
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if terminated := containerStatus.State.Terminated; terminated != nil {
+				exitCode := terminated.ExitCode
+				finishedAt := terminated.FinishedAt.Time
+				state.ExitCode = &exitCode
+				state.FinishedAt = &finishedAt
+			}
+		}
+	}
+
+	if job.Status.CompletionTime != nil {
+		finishedAt := job.Status.CompletionTime.Time
+		state.FinishedAt = &finishedAt
+	}
+
+	return state
+}
+
+// GetJobStateFromCache reads the job phase from the informer cache.
+func (k *KuberService) GetJobStateFromCache(namespace, jobName string) (JobState, error) {
+	key := fmt.Sprintf("%s/%s", namespace, jobName)
+	obj, exists, err := k.jobLister.GetByKey(key)
+
+	if err != nil {
+		return JobState{Status: WorkloadUnknown}, err
+	}
+	if !exists {
+		return JobState{Status: WorkloadUnknown}, fmt.Errorf("job %s not found in cache", key)
+	}
+
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return JobState{Status: WorkloadUnknown}, fmt.Errorf("object is not a Job")
+	}
+
+	return k.jobState(job), nil
+}
+
+// OnJobStateChange calls fn whenever a managed Job or one of its pods
+// changes, so the caller can persist the latest state.
+func (k *KuberService) OnJobStateChange(fn func(JobState)) {
+	notifyJob := func(obj interface{}) {
+		if job, ok := obj.(*batchv1.Job); ok && job.Labels[JobIDLabel] != "" {
+			fn(k.jobState(job))
+		}
+	}
+	notifyPod := func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Labels[JobIDLabel] == "" {
+			return
+		}
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "Job" {
+			return
+		}
+		item, exists, err := k.jobLister.GetByKey(fmt.Sprintf("%s/%s", pod.Namespace, owner.Name))
+		if err != nil || !exists {
+			return
+		}
+		notifyJob(item)
+	}
+
+	k.jobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notifyJob,
+		UpdateFunc: func(_, obj interface{}) { notifyJob(obj) },
+	})
+	k.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) { notifyPod(obj) },
+	})
+}
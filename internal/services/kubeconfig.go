@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const kubeconfigCluster = "mlspace"
+
+// ErrKubeconfigDisabled is returned while no public client for kubelogin is
+// configured, the confidential client of mlspace can't be used without its
+// secret and the secret must not be handed out.
+var ErrKubeconfigDisabled = errors.New("no kubelogin client configured")
+
+// clusterEndpoint returns the API server address users should talk to and
+// the CA bundle to trust, the CA is taken from mlspace's own connection.
+func (k *KuberService) clusterEndpoint() (string, []byte, error) {
+	server := k.cfg.Kuber.APIServerURL
+	if server == "" {
+		server = k.restConfig.Host
+	}
+
+	caData := k.restConfig.CAData
+	if len(caData) == 0 && k.restConfig.CAFile != "" {
+		data, err := os.ReadFile(k.restConfig.CAFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("read cluster CA: %w", err)
+		}
+		caData = data
+	}
+
+	return server, caData, nil
+}
+
+// ProjectKubeconfig builds a kubeconfig for one project namespace that logs
+// in through the kubelogin exec plugin against the Keycloak realm. The API
+// server then authorizes the user with the project RoleBinding.
+func (k *KuberService) ProjectKubeconfig(namespace, email string) ([]byte, error) {
+	clientID := k.cfg.Kuber.OIDCClientID
+	if clientID == "" {
+		return nil, ErrKubeconfigDisabled
+	}
+
+	issuerURL := fmt.Sprintf("%s/realms/%s", strings.TrimSuffix(k.cfg.Auth.KeycloakURL, "/"), k.cfg.Auth.Realm)
+
+	server, caData, err := k.clusterEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	userName := fmt.Sprintf("oidc-%s", email)
+	contextName := fmt.Sprintf("%s-%s", kubeconfigCluster, namespace)
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[kubeconfigCluster] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: caData,
+	}
+	kubeconfig.AuthInfos[userName] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			APIVersion: "client.authentication.k8s.io/v1beta1",
+			Command:    "kubectl",
+			Args: []string{
+				"oidc-login",
+				"get-token",
+				"--oidc-issuer-url=" + issuerURL,
+				"--oidc-client-id=" + clientID,
+				"--oidc-extra-scope=email",
+			},
+			InstallHint:     "kubectl oidc-login is required, see https://github.com/int128/kubelogin",
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+		},
+	}
+	kubeconfig.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   kubeconfigCluster,
+		AuthInfo:  userName,
+		Namespace: namespace,
+	}
+	kubeconfig.CurrentContext = contextName
+
+	return clientcmd.Write(*kubeconfig)
+}
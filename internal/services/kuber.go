@@ -3,15 +3,20 @@ package services
 import (
 	"aispace/internal/config"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -30,6 +35,7 @@ const (
 	Succeeded
 	Failed
 	Unknown
+	Terminating
 )
 
 func (s PVCStatus) String() string {
@@ -42,38 +48,87 @@ func (s PVCStatus) String() string {
 		return "Failed"
 	case Unknown:
 		return "Unknown"
+	case Terminating:
+		return "Terminating"
 	default:
 		return "Unknown"
 	}
 }
 
 type KuberService struct {
-	cfg             *config.Config
-	clientset       *kubernetes.Clientset
-	informerFactory informers.SharedInformerFactory
-	pvcInformer     cache.SharedIndexInformer
-	pvcLister       cache.Indexer
-	stopCh          chan struct{}
+	cfg                    *config.Config
+	restConfig             *rest.Config
+	clientset              *kubernetes.Clientset
+	dynamicClient          dynamic.Interface
+	informerFactory        informers.SharedInformerFactory
+	managedInformerFactory informers.SharedInformerFactory
+	pvcInformer            cache.SharedIndexInformer
+	pvcLister              cache.Indexer
+	podInformer            cache.SharedIndexInformer
+	podLister              cache.Indexer
+	jobInformer            cache.SharedIndexInformer
+	jobLister              cache.Indexer
+	deploymentInformer     cache.SharedIndexInformer
+	deploymentLister       cache.Indexer
+	eventInformer          cache.SharedIndexInformer
+	eventLister            cache.Indexer
+	stopCh                 chan struct{}
 }
 
 func NewKuberService(cfg *config.Config) *KuberService {
 	config, _ := clientcmd.BuildConfigFromFlags("", cfg.Kuber.KubeConfigPath)
 	clientset, _ := kubernetes.NewForConfig(config)
+	// CRDs without typed clients in client-go, e.g. VolumeSnapshots
+	dynamicClient, _ := dynamic.NewForConfig(config)
 
 	factory := informers.NewSharedInformerFactory(clientset, time.Second*10)
 	pvcInformer := factory.Core().V1().PersistentVolumeClaims().Informer()
 
+	// workload objects are only watched when they carry the mlspace label
+	managedFactory := informers.NewSharedInformerFactoryWithOptions(
+		clientset,
+		time.Second*10,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("%s=%s", ManagedByLabel, ManagedByValue)
+		}),
+	)
+	podInformer := managedFactory.Core().V1().Pods().Informer()
+	jobInformer := managedFactory.Batch().V1().Jobs().Informer()
+	deploymentInformer := managedFactory.Apps().V1().Deployments().Informer()
+	eventInformer := newProjectEventInformer(clientset)
+
 	kService := &KuberService{
-		cfg:             cfg,
-		clientset:       clientset,
-		informerFactory: factory,
-		pvcInformer:     pvcInformer,
-		pvcLister:       pvcInformer.GetIndexer(),
-		stopCh:          make(chan struct{}),
+		cfg:                    cfg,
+		restConfig:             config,
+		clientset:              clientset,
+		dynamicClient:          dynamicClient,
+		informerFactory:        factory,
+		managedInformerFactory: managedFactory,
+		pvcInformer:            pvcInformer,
+		pvcLister:              pvcInformer.GetIndexer(),
+		podInformer:            podInformer,
+		podLister:              podInformer.GetIndexer(),
+		jobInformer:            jobInformer,
+		jobLister:              jobInformer.GetIndexer(),
+		deploymentInformer:     deploymentInformer,
+		deploymentLister:       deploymentInformer.GetIndexer(),
+		eventInformer:          eventInformer,
+		eventLister:            eventInformer.GetIndexer(),
+		stopCh:                 make(chan struct{}),
+	}
+
+	kService.watchCloneReferenceGrants()
+
+	if cfg.Kuber.Namespace == "" {
+		log.Println("KuberService: MLSPACE_NAMESPACE is unknown, the proxy and the culler can't reach project workloads")
 	}
 
 	kService.informerFactory.Start(kService.stopCh)
+	kService.managedInformerFactory.Start(kService.stopCh)
+	go kService.eventInformer.Run(kService.stopCh)
 	kService.informerFactory.WaitForCacheSync(kService.stopCh)
+	kService.managedInformerFactory.WaitForCacheSync(kService.stopCh)
+	cache.WaitForCacheSync(kService.stopCh, kService.eventInformer.HasSynced)
 
 	return kService
 }
@@ -81,27 +136,103 @@ func NewKuberService(cfg *config.Config) *KuberService {
 func (k *KuberService) CreateNamespace(ctx context.Context, name string, ownerEmail string) error {
 	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:   name,
+			Labels: managedLabels(),
 			Annotations: map[string]string{
-				"mlspace.io/onwer-email": ownerEmail,
+				OwnerEmailAnnotation: ownerEmail,
 			},
 		},
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := k.clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return k.labelNamespace(ctx, name)
+	}
 
 	return err
 }
 
+// AdoptProjectNamespace labels an existing project namespace created before
+// the managed-by label existed and tells whether the namespace exists.
+func (k *KuberService) AdoptProjectNamespace(ctx context.Context, name string) (bool, error) {
+	namespace, err := k.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get namespace: %w", err)
+	}
+
+	if namespace.Labels[ManagedByLabel] == ManagedByValue {
+		return true, nil
+	}
+
+	return true, k.labelNamespace(ctx, name)
+}
+
+// labelNamespace adds the managed-by label to a namespace created before it
+// existed, the reconciler only looks at labeled namespaces.
+func (k *KuberService) labelNamespace(ctx context.Context, name string) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": managedLabels(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.CoreV1().Namespaces().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("label namespace: %w", err)
+	}
+
+	return nil
+}
+
+// PVCStorage picks the StorageClass and the access mode of a claim. An empty
+// class name leaves the choice to the cluster default class.
+type PVCStorage struct {
+	ClassName string
+	Shared    bool
+}
+
+func (s PVCStorage) accessMode() corev1.PersistentVolumeAccessMode {
+	if s.Shared {
+		return corev1.ReadWriteMany
+	}
+	return corev1.ReadWriteOnce
+}
+
+func (s PVCStorage) className() *string {
+	if s.ClassName == "" {
+		return nil
+	}
+	return &s.ClassName
+}
+
 func (k *KuberService) CreatePVC(
 	ctx context.Context,
 	namespace string,
 	pvcName string,
 	size string,
 	ownerEmail string,
+	storage PVCStorage,
+) (*corev1.PersistentVolumeClaim, error) {
+	return k.createPVC(ctx, namespace, pvcName, size, ownerEmail, storage, nil)
+}
+
+func (k *KuberService) createPVC(
+	ctx context.Context,
+	namespace string,
+	pvcName string,
+	size string,
+	ownerEmail string,
+	storage PVCStorage,
+	dataSource *corev1.TypedObjectReference,
 ) (*corev1.PersistentVolumeClaim, error) {
 	quantity, err := resource.ParseQuantity(size)
 	if err != nil {
@@ -113,20 +244,22 @@ func (k *KuberService) CreatePVC(
 			Name:      pvcName,
 			Namespace: namespace,
 			Annotations: map[string]string{
-				"mlspace.io/onwer-email": ownerEmail,
+				OwnerEmailAnnotation: ownerEmail,
 			},
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			AccessModes:      []corev1.PersistentVolumeAccessMode{storage.accessMode()},
+			StorageClassName: storage.className(),
 			Resources: corev1.VolumeResourceRequirements{
 				Requests: corev1.ResourceList{
 					corev1.ResourceStorage: quantity,
 				},
 			},
+			DataSourceRef: dataSource,
 		},
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	createdPVC, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
@@ -163,6 +296,9 @@ func (k *KuberService) GetPVCStatusFromCache(ctx context.Context, namespace, pvc
 	}
 
 	if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+		if pvc.DeletionTimestamp != nil {
+			return Terminating, nil
+		}
 		status := k.mapK8sPVCPhaseToServiceStatus(pvc.Status.Phase)
 		return status, nil
 	}
@@ -192,6 +328,84 @@ func (k *KuberService) GetPVCStatus(ctx context.Context, namespace, pvcName stri
 	return k.mapK8sPVCPhaseToServiceStatus(pvc.Status.Phase), nil
 }
 
+func (k *KuberService) pvcStatusUpdate(pvc *corev1.PersistentVolumeClaim) PVCStatusUpdate {
+	update := PVCStatusUpdate{
+		Namespace: pvc.Namespace,
+		PVCName:   pvc.Name,
+		Status:    k.mapK8sPVCPhaseToServiceStatus(pvc.Status.Phase),
+	}
+
+	if pvc.DeletionTimestamp != nil {
+		update.Status = Terminating
+	}
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue && condition.Message != "" {
+			update.Reason = condition.Message
+		}
+	}
+	// a claim stuck in Pending only tells why through its events
+	if update.Reason == "" && (update.Status == Pending || update.Status == Failed) {
+		update.Reason = k.GetPVCEventReason(pvc.Namespace, pvc.Name)
+	}
+
+	return update
+}
+
+// GetPVCReason explains the state of the claim from its condition messages
+// or, while it is pending or lost, its newest warning event.
+func (k *KuberService) GetPVCReason(namespace, pvcName string) string {
+	obj, exists, err := k.pvcLister.GetByKey(fmt.Sprintf("%s/%s", namespace, pvcName))
+	if err != nil || !exists {
+		return ""
+	}
+
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return ""
+	}
+
+	return k.pvcStatusUpdate(pvc).Reason
+}
+
+// OnPVCStatusChange calls fn for every PVC the informer sees added, changed
+// or deleted, and again when a warning event about it comes in. Periodic
+// resyncs carry no change and are skipped.
+func (k *KuberService) OnPVCStatusChange(fn func(PVCStatusUpdate)) {
+	k.onPVCEvent(func(pvc *corev1.PersistentVolumeClaim) {
+		fn(k.pvcStatusUpdate(pvc))
+	})
+	k.pvcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+				fn(k.pvcStatusUpdate(pvc))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPVC, ok := oldObj.(*corev1.PersistentVolumeClaim)
+			if !ok {
+				return
+			}
+			newPVC, ok := newObj.(*corev1.PersistentVolumeClaim)
+			if !ok {
+				return
+			}
+			if oldPVC.ResourceVersion != newPVC.ResourceVersion {
+				fn(k.pvcStatusUpdate(newPVC))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+				update := k.pvcStatusUpdate(pvc)
+				update.Status = Terminating
+				fn(update)
+			}
+		},
+	})
+}
+
 func (k *KuberService) StopInformer() {
 	close(k.stopCh)
 	fmt.Println("KuberService: Informer stopped.")
@@ -199,6 +413,9 @@ func (k *KuberService) StopInformer() {
 
 func (k *KuberService) DeleteNamespace(ctx context.Context, name string) error {
 	err := k.clientset.CoreV1().Namespaces().Delete(ctx, fmt.Sprintf("project-%s", name), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	return err
 }
 
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PodLogOptions selects which part of a container log is read.
+type PodLogOptions struct {
+	Container string
+	Previous  bool
+	TailLines int64
+	Follow    bool
+}
+
+// PodSummary is what the UI shows about a workload pod.
+type PodSummary struct {
+	Name       string
+	Workload   string
+	Phase      string
+	Restarts   int32
+	Containers []string
+	CreatedAt  time.Time
+}
+
+func podWorkload(pod *corev1.Pod) string {
+	switch {
+	case pod.Labels[WorkspaceIDLabel] != "":
+		return "workspace"
+	case pod.Labels[JobIDLabel] != "":
+		return "job"
+	default:
+		return "other"
+	}
+}
+
+// PodOwner is the mlspace workload a pod belongs to, by its labels. Kind is
+// empty for pods mlspace didn't start.
+type PodOwner struct {
+	Kind string
+	ID   string
+}
+
+const (
+	PodOwnerWorkspace   = "workspace"
+	PodOwnerJob         = "job"
+	PodOwnerCronJob     = "cronjob"
+	PodOwnerDeployment  = "deployment"
+	PodOwnerDiskBrowser = "disk-browser"
+	PodOwnerTensorBoard = "tensorboard"
+)
+
+func podOwner(pod *corev1.Pod) PodOwner {
+	switch {
+	case pod.Labels[WorkspaceIDLabel] != "":
+		return PodOwner{Kind: PodOwnerWorkspace, ID: pod.Labels[WorkspaceIDLabel]}
+	case pod.Labels[JobIDLabel] != "":
+		return PodOwner{Kind: PodOwnerJob, ID: pod.Labels[JobIDLabel]}
+	case pod.Labels[CronJobIDLabel] != "":
+		return PodOwner{Kind: PodOwnerCronJob, ID: pod.Labels[CronJobIDLabel]}
+	case pod.Labels[DeploymentIDLabel] != "":
+		return PodOwner{Kind: PodOwnerDeployment, ID: pod.Labels[DeploymentIDLabel]}
+	case pod.Labels[DiskBrowserLabel] != "":
+		return PodOwner{Kind: PodOwnerDiskBrowser, ID: pod.Labels[DiskBrowserLabel]}
+	case pod.Labels[TensorBoardDiskLabel] != "":
+		return PodOwner{Kind: PodOwnerTensorBoard, ID: pod.Labels[TensorBoardDiskLabel]}
+	}
+	return PodOwner{}
+}
+
+// GetPodOwner reads the owning workload of a pod from the informer cache.
+func (k *KuberService) GetPodOwner(namespace, podName string) (PodOwner, error) {
+	pod, err := k.GetPodFromCache(namespace, podName)
+	if err != nil {
+		return PodOwner{}, err
+	}
+
+	return podOwner(pod), nil
+}
+
+// podContainers lists the regular containers before the init containers,
+// so the first one is a sensible default.
+func podContainers(pod *corev1.Pod) []string {
+	var containers []string
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, container.Name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, container.Name)
+	}
+	return containers
+}
+
+// GetProjectPods lists the managed pods of a project namespace from the
+// informer cache, newest first.
+func (k *KuberService) GetProjectPods(namespace string) []PodSummary {
+	var pods []PodSummary
+
+	cache.ListAllByNamespace(k.podLister, namespace, labels.Everything(), func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return
+		}
+
+		var restarts int32
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+
+		pods = append(pods, PodSummary{
+			Name:       pod.Name,
+			Workload:   podWorkload(pod),
+			Phase:      string(pod.Status.Phase),
+			Restarts:   restarts,
+			Containers: podContainers(pod),
+			CreatedAt:  pod.CreationTimestamp.Time,
+		})
+	})
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreatedAt.After(pods[j].CreatedAt)
+	})
+
+	return pods
+}
+
+// GetPodContainers returns the containers of a managed pod, see podContainers.
+func (k *KuberService) GetPodContainers(namespace, podName string) ([]string, error) {
+	pod, err := k.GetPodFromCache(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+
+	return podContainers(pod), nil
+}
+
+// StreamPodLogs opens the log of a container. With Follow the stream stays
+// open until the container stops or ctx is cancelled, the caller closes it.
+func (k *KuberService) StreamPodLogs(ctx context.Context, namespace, podName string, opts PodLogOptions) (io.ReadCloser, error) {
+	logOptions := &corev1.PodLogOptions{
+		Container: opts.Container,
+		Previous:  opts.Previous,
+		Follow:    opts.Follow,
+	}
+	if opts.TailLines > 0 {
+		logOptions.TailLines = &opts.TailLines
+	}
+
+	stream, err := k.clientset.CoreV1().Pods(namespace).GetLogs(podName, logOptions).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stream pod logs: %w", err)
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PodMetrics of metrics-server, read through the dynamic client since
+// client-go has no typed client for the metrics API
+var podMetricsResource = schema.GroupVersionResource{
+	Group:    "metrics.k8s.io",
+	Version:  "v1beta1",
+	Resource: "pods",
+}
+
+// PodUsage is the summed usage of the containers of a pod. CPU is in
+// millicores, RAM in bytes.
+type PodUsage struct {
+	Namespace string
+	Name      string
+	CPU       int64
+	RAM       int64
+}
+
+func podUsage(item unstructured.Unstructured) PodUsage {
+	usage := PodUsage{Namespace: item.GetNamespace(), Name: item.GetName()}
+
+	containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+	for _, container := range containers {
+		containerMap, ok := container.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, found, _ := unstructured.NestedString(containerMap, "usage", "cpu"); found {
+			if quantity, err := resource.ParseQuantity(value); err == nil {
+				usage.CPU += quantity.MilliValue()
+			}
+		}
+		if value, found, _ := unstructured.NestedString(containerMap, "usage", "memory"); found {
+			if quantity, err := resource.ParseQuantity(value); err == nil {
+				usage.RAM += quantity.Value()
+			}
+		}
+	}
+
+	return usage
+}
+
+// ListPodUsage reads the current pod usage of a namespace, or of every
+// namespace for metav1.NamespaceAll.
+func (k *KuberService) ListPodUsage(ctx context.Context, namespace string) ([]PodUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	list, err := k.dynamicClient.Resource(podMetricsResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]PodUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		usages = append(usages, podUsage(item))
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultDenyPolicy   = "mlspace-default-deny"
+	sameProjectPolicy   = "mlspace-allow-same-project"
+	dnsPolicy           = "mlspace-allow-dns"
+	ingressPolicy       = "mlspace-allow-ingress"
+	egressPolicy        = "mlspace-allow-egress"
+	namespaceNameLabel  = "kubernetes.io/metadata.name"
+	dnsNamespace        = "kube-system"
+	networkPolicyPrefix = "mlspace-"
+)
+
+// EgressRule opens outgoing traffic from every project to a CIDR. Port 0
+// allows all ports of the protocol.
+type EgressRule struct {
+	CIDR     string
+	Protocol string
+	Port     int
+}
+
+// NetworkPolicySummary is a readable form of one policy of a namespace.
+type NetworkPolicySummary struct {
+	Name    string
+	Ingress []string
+	Egress  []string
+}
+
+func namespacePeer(name string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: name},
+		},
+	}
+}
+
+func policyPort(protocol string, port int) networkingv1.NetworkPolicyPort {
+	proto := corev1.Protocol(strings.ToUpper(protocol))
+	policyPort := networkingv1.NetworkPolicyPort{Protocol: &proto}
+	if port > 0 {
+		value := intstr.FromInt32(int32(port))
+		policyPort.Port = &value
+	}
+	return policyPort
+}
+
+func networkPolicy(namespace, name string, types []networkingv1.PolicyType) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    managedLabels(),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: types,
+		},
+	}
+}
+
+// projectNetworkPolicies denies everything by default and then opens traffic
+// inside the project, DNS, the mlspace and ingress namespaces and the egress
+// rules configured by admins.
+func (k *KuberService) projectNetworkPolicies(namespace string, rules []EgressRule) []*networkingv1.NetworkPolicy {
+	isolated := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+
+	sameProject := networkPolicy(namespace, sameProjectPolicy, isolated)
+	sameProject.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{
+		{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+	}
+	sameProject.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{
+		{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+	}
+
+	dns := networkPolicy(namespace, dnsPolicy, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress})
+	dns.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{
+		{
+			To:    []networkingv1.NetworkPolicyPeer{namespacePeer(dnsNamespace)},
+			Ports: []networkingv1.NetworkPolicyPort{policyPort("UDP", 53), policyPort("TCP", 53)},
+		},
+	}
+
+	policies := []*networkingv1.NetworkPolicy{
+		networkPolicy(namespace, defaultDenyPolicy, isolated),
+		sameProject,
+		dns,
+	}
+
+	if peers := k.ingressPeers(); len(peers) > 0 {
+		ingress := networkPolicy(namespace, ingressPolicy, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress})
+		ingress.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+		policies = append(policies, ingress)
+	}
+
+	if len(rules) > 0 {
+		egress := networkPolicy(namespace, egressPolicy, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress})
+		for _, rule := range rules {
+			egressRule := networkingv1.NetworkPolicyEgressRule{
+				To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: rule.CIDR}}},
+			}
+			if rule.Protocol != "" {
+				egressRule.Ports = []networkingv1.NetworkPolicyPort{policyPort(rule.Protocol, rule.Port)}
+			}
+			egress.Spec.Egress = append(egress.Spec.Egress, egressRule)
+		}
+		policies = append(policies, egress)
+	}
+
+	return policies
+}
+
+// ingressPeers are the namespaces allowed into every project: the one of
+// mlspace itself and KUBE_INGRESS_NAMESPACES.
+func (k *KuberService) ingressPeers() []networkingv1.NetworkPolicyPeer {
+	var peers []networkingv1.NetworkPolicyPeer
+	seen := make(map[string]bool)
+
+	for _, name := range append([]string{k.cfg.Kuber.Namespace}, k.cfg.Kuber.IngressNamespaces...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		peers = append(peers, namespacePeer(name))
+	}
+
+	return peers
+}
+
+// ApplyNetworkPolicies creates or updates the managed NetworkPolicies of a
+// project namespace and removes managed policies that are no longer wanted,
+// e.g. the egress policy once the last rule is deleted.
+func (k *KuberService) ApplyNetworkPolicies(ctx context.Context, namespace string, rules []EgressRule) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client := k.clientset.NetworkingV1().NetworkPolicies(namespace)
+	wanted := make(map[string]bool)
+
+	for _, policy := range k.projectNetworkPolicies(namespace, rules) {
+		wanted[policy.Name] = true
+
+		existing, err := client.Get(ctx, policy.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			_, err = client.Create(ctx, policy, metav1.CreateOptions{})
+		case err == nil:
+			existing.Labels = policy.Labels
+			existing.Spec = policy.Spec
+			_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("apply network policy %s in %s: %w", policy.Name, namespace, err)
+		}
+	}
+
+	list, err := client.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", ManagedByLabel, ManagedByValue),
+	})
+	if err != nil {
+		return fmt.Errorf("list network policies in %s: %w", namespace, err)
+	}
+	for _, policy := range list.Items {
+		if wanted[policy.Name] || !strings.HasPrefix(policy.Name, networkPolicyPrefix) {
+			continue
+		}
+		err := client.Delete(ctx, policy.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete network policy %s in %s: %w", policy.Name, namespace, err)
+		}
+	}
+
+	return nil
+}
+
+func describePeer(peer networkingv1.NetworkPolicyPeer) string {
+	switch {
+	case peer.IPBlock != nil:
+		return peer.IPBlock.CIDR
+	case peer.NamespaceSelector != nil:
+		if name, ok := peer.NamespaceSelector.MatchLabels[namespaceNameLabel]; ok {
+			return fmt.Sprintf("namespace %s", name)
+		}
+		return "selected namespaces"
+	case peer.PodSelector != nil:
+		return "pods of the project"
+	}
+	return "any"
+}
+
+func describePorts(ports []networkingv1.NetworkPolicyPort) string {
+	if len(ports) == 0 {
+		return "all ports"
+	}
+
+	var described []string
+	for _, port := range ports {
+		protocol := "TCP"
+		if port.Protocol != nil {
+			protocol = string(*port.Protocol)
+		}
+		if port.Port == nil {
+			described = append(described, protocol)
+		} else {
+			described = append(described, fmt.Sprintf("%s/%s", protocol, port.Port.String()))
+		}
+	}
+	return strings.Join(described, ", ")
+}
+
+func describeRule(peers []networkingv1.NetworkPolicyPeer, ports []networkingv1.NetworkPolicyPort) string {
+	var described []string
+	for _, peer := range peers {
+		described = append(described, describePeer(peer))
+	}
+	if len(described) == 0 {
+		described = append(described, "any")
+	}
+	return fmt.Sprintf("%s on %s", strings.Join(described, ", "), describePorts(ports))
+}
+
+// GetNetworkPolicies describes every NetworkPolicy of the namespace, managed
+// or not, since all of them add up to the effective policy.
+func (k *KuberService) GetNetworkPolicies(ctx context.Context, namespace string) ([]NetworkPolicySummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	list, err := k.clientset.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list network policies in %s: %w", namespace, err)
+	}
+
+	var summaries []NetworkPolicySummary
+	for _, policy := range list.Items {
+		summary := NetworkPolicySummary{Name: policy.Name}
+
+		for _, policyType := range policy.Spec.PolicyTypes {
+			switch policyType {
+			case networkingv1.PolicyTypeIngress:
+				if len(policy.Spec.Ingress) == 0 {
+					summary.Ingress = append(summary.Ingress, "deny all")
+				}
+				for _, rule := range policy.Spec.Ingress {
+					summary.Ingress = append(summary.Ingress, describeRule(rule.From, rule.Ports))
+				}
+			case networkingv1.PolicyTypeEgress:
+				if len(policy.Spec.Egress) == 0 {
+					summary.Egress = append(summary.Egress, "deny all")
+				}
+				for _, rule := range policy.Spec.Egress {
+					summary.Egress = append(summary.Egress, describeRule(rule.To, rule.Ports))
+				}
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "mlspace"
+
+	projectQuotaName      = "mlspace-quota"
+	projectLimitRangeName = "mlspace-limits"
+)
+
+// ProjectLimits mirrors the limits stored on a project row. CPU is in cores,
+// RAM and storage are in GiB.
+type ProjectLimits struct {
+	CPU     int
+	RAM     int
+	Storage int
+}
+
+func managedLabels() map[string]string {
+	return map[string]string{ManagedByLabel: ManagedByValue}
+}
+
+func (l ProjectLimits) resourceQuota(namespace string) *corev1.ResourceQuota {
+	cpu := resource.MustParse(fmt.Sprintf("%d", l.CPU))
+	ram := resource.MustParse(fmt.Sprintf("%dGi", l.RAM))
+	storage := resource.MustParse(fmt.Sprintf("%dGi", l.Storage))
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      projectQuotaName,
+			Namespace: namespace,
+			Labels:    managedLabels(),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:     cpu,
+				corev1.ResourceLimitsCPU:       cpu,
+				corev1.ResourceRequestsMemory:  ram,
+				corev1.ResourceLimitsMemory:    ram,
+				corev1.ResourceRequestsStorage: storage,
+			},
+		},
+	}
+}
+
+// limitRange gives containers without explicit resources a small default so
+// they are still admitted by the quota, and caps a single container at the
+// whole project budget.
+func (l ProjectLimits) limitRange(namespace string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      projectLimitRangeName,
+			Namespace: namespace,
+			Labels:    managedLabels(),
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type: corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+					DefaultRequest: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+					Max: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%d", l.CPU)),
+						corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dGi", l.RAM)),
+					},
+				},
+			},
+		},
+	}
+}
+
+// ApplyProjectLimits creates or updates the managed ResourceQuota and
+// LimitRange of a project namespace.
+func (k *KuberService) ApplyProjectLimits(ctx context.Context, namespace string, limits ProjectLimits) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	quotas := k.clientset.CoreV1().ResourceQuotas(namespace)
+	quota := limits.resourceQuota(namespace)
+
+	existingQuota, err := quotas.Get(ctx, quota.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = quotas.Create(ctx, quota, metav1.CreateOptions{})
+	case err == nil:
+		existingQuota.Labels = quota.Labels
+		existingQuota.Spec = quota.Spec
+		_, err = quotas.Update(ctx, existingQuota, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("apply resource quota in %s: %w", namespace, err)
+	}
+
+	limitRanges := k.clientset.CoreV1().LimitRanges(namespace)
+	limitRange := limits.limitRange(namespace)
+
+	existingLimitRange, err := limitRanges.Get(ctx, limitRange.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = limitRanges.Create(ctx, limitRange, metav1.CreateOptions{})
+	case err == nil:
+		existingLimitRange.Labels = limitRange.Labels
+		existingLimitRange.Spec = limitRange.Spec
+		_, err = limitRanges.Update(ctx, existingLimitRange, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("apply limit range in %s: %w", namespace, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ProjectOwnerRole  = "mlspace-project-owner"
+	ProjectMemberRole = "mlspace-project-member"
+
+	UserIDLabel = "mlspace.io/user-id"
+)
+
+var (
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+)
+
+// memberRules let participants watch the project: workloads, their logs,
+// claims, events and quotas are read only. Creating pods (directly or through
+// a controller) or exec into them would expose every Secret of the namespace,
+// members start workloads through mlspace instead.
+func memberRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "services", "configmaps", "persistentvolumeclaims", "events", "resourcequotas", "limitranges"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/log"},
+			Verbs:     []string{"get"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "replicasets", "statefulsets"},
+			Verbs:     readVerbs,
+		},
+	}
+}
+
+// ownerRules extend the member rules with running and debugging workloads and
+// managing secrets and claims through the API, quotas and bindings stay read
+// only since mlspace owns them.
+func ownerRules() []rbacv1.PolicyRule {
+	return append(memberRules(),
+		rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "services", "configmaps", "secrets", "persistentvolumeclaims"},
+			Verbs:     writeVerbs,
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods/exec", "pods/portforward", "pods/attach"},
+			Verbs:     []string{"get", "create"},
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     writeVerbs,
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "replicasets", "statefulsets"},
+			Verbs:     writeVerbs,
+		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"rbac.authorization.k8s.io"},
+			Resources: []string{"roles", "rolebindings"},
+			Verbs:     readVerbs,
+		},
+	)
+}
+
+func projectRoles(namespace string) []*rbacv1.Role {
+	return []*rbacv1.Role{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: ProjectOwnerRole, Namespace: namespace, Labels: managedLabels()},
+			Rules:      ownerRules(),
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: ProjectMemberRole, Namespace: namespace, Labels: managedLabels()},
+			Rules:      memberRules(),
+		},
+	}
+}
+
+// ProjectRoleBindingName is the RoleBinding of one user, emails are not valid
+// object names so the user ID is used.
+func ProjectRoleBindingName(userID string) string {
+	return fmt.Sprintf("mlspace-user-%s", userID)
+}
+
+// EnsureProjectRoles creates or updates the managed owner and member Roles of
+// a project namespace.
+func (k *KuberService) EnsureProjectRoles(ctx context.Context, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	roles := k.clientset.RbacV1().Roles(namespace)
+
+	for _, role := range projectRoles(namespace) {
+		existing, err := roles.Get(ctx, role.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			_, err = roles.Create(ctx, role, metav1.CreateOptions{})
+		case err == nil:
+			existing.Labels = role.Labels
+			existing.Rules = role.Rules
+			_, err = roles.Update(ctx, existing, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("apply role %s in %s: %w", role.Name, namespace, err)
+		}
+	}
+
+	return nil
+}
+
+// BindProjectUser maps the OIDC identity of a user to one of the project
+// Roles. The role of a binding can't change, so it is recreated when needed.
+func (k *KuberService) BindProjectUser(ctx context.Context, namespace, userID, email, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	labels := managedLabels()
+	labels[UserIDLabel] = userID
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProjectRoleBindingName(userID),
+			Namespace: namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: email,
+			},
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:     rbacv1.UserKind,
+				APIGroup: rbacv1.GroupName,
+				Name:     k.cfg.Kuber.OIDCUsernamePrefix + email,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role,
+		},
+	}
+
+	bindings := k.clientset.RbacV1().RoleBindings(namespace)
+
+	existing, err := bindings.Get(ctx, binding.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = bindings.Create(ctx, binding, metav1.CreateOptions{})
+	case err == nil && existing.RoleRef != binding.RoleRef:
+		if err = bindings.Delete(ctx, binding.Name, metav1.DeleteOptions{}); err == nil {
+			_, err = bindings.Create(ctx, binding, metav1.CreateOptions{})
+		}
+	case err == nil:
+		existing.Labels = binding.Labels
+		existing.Annotations = binding.Annotations
+		existing.Subjects = binding.Subjects
+		_, err = bindings.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("bind user %s in %s: %w", userID, namespace, err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) UnbindProjectUser(ctx context.Context, namespace, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.RbacV1().RoleBindings(namespace).Delete(ctx, ProjectRoleBindingName(userID), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unbind user %s in %s: %w", userID, namespace, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ProjectNamespacePrefix = "project-"
+	DiskPVCPrefix          = "disk-"
+)
+
+// ClusterObject is a namespace or a PVC owned by mlspace. Claims are found by
+// name prefix since the ones created before the managed-by label existed don't
+// carry it, namespaces get the label when the reconciler repairs them.
+type ClusterObject struct {
+	Namespace string
+	Name      string
+}
+
+// IsProjectNamespace tells whether the name is project-<project ID>, other
+// namespaces with the prefix are not mlspace's to touch.
+func IsProjectNamespace(name string) bool {
+	projectID, ok := strings.CutPrefix(name, ProjectNamespacePrefix)
+	if !ok {
+		return false
+	}
+
+	_, err := uuid.Parse(projectID)
+	return err == nil
+}
+
+// ListProjectNamespaces returns the managed project namespaces that are not
+// already terminating.
+func (k *KuberService) ListProjectNamespaces(ctx context.Context) ([]ClusterObject, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	list, err := k.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", ManagedByLabel, ManagedByValue),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list namespaces: %w", err)
+	}
+
+	var namespaces []ClusterObject
+	for _, namespace := range list.Items {
+		if !IsProjectNamespace(namespace.Name) || namespace.DeletionTimestamp != nil {
+			continue
+		}
+		namespaces = append(namespaces, ClusterObject{Name: namespace.Name})
+	}
+
+	return namespaces, nil
+}
+
+// ListDiskPVCs returns the disk-* claims of project namespaces from the
+// informer cache.
+func (k *KuberService) ListDiskPVCs() []ClusterObject {
+	var pvcs []ClusterObject
+
+	for _, obj := range k.pvcLister.List() {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		if !ok || pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if !strings.HasPrefix(pvc.Namespace, ProjectNamespacePrefix) || !strings.HasPrefix(pvc.Name, DiskPVCPrefix) {
+			continue
+		}
+		pvcs = append(pvcs, ClusterObject{Namespace: pvc.Namespace, Name: pvc.Name})
+	}
+
+	return pvcs
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const SecretIDLabel = "mlspace.io/secret-id"
+
+type SecretSpec struct {
+	ID        string
+	Name      string
+	Namespace string
+	ProjectID string
+	Data      map[string]string
+}
+
+// SecretMount imports a project secret into a workload, every key becomes
+// an environment variable or a file below MountPath.
+type SecretMount struct {
+	SecretName string
+	MountPath  string
+	AsFile     bool
+}
+
+func (s SecretSpec) secret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels: map[string]string{
+				ManagedByLabel: ManagedByValue,
+				ProjectIDLabel: s.ProjectID,
+				SecretIDLabel:  s.ID,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: s.Data,
+	}
+}
+
+func secretSources(mounts []SecretMount) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvFromSource) {
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	var envFrom []corev1.EnvFromSource
+
+	for _, mount := range mounts {
+		if !mount.AsFile {
+			envFrom = append(envFrom, corev1.EnvFromSource{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: mount.SecretName},
+				},
+			})
+			continue
+		}
+
+		volumes = append(volumes, corev1.Volume{
+			Name: mount.SecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: mount.SecretName,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      mount.SecretName,
+			MountPath: mount.MountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, volumeMounts, envFrom
+}
+
+func (k *KuberService) CreateSecret(ctx context.Context, spec SecretSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.CoreV1().Secrets(spec.Namespace).Create(ctx, spec.secret(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create secret: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSecretData sets the given keys and drops the removed ones. Values
+// are only ever written, mlspace never reads them back.
+func (k *KuberService) UpdateSecretData(ctx context.Context, namespace, name string, set map[string]string, removed []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	data := map[string]any{}
+	for _, key := range removed {
+		data[key] = nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"data":       data,
+		"stringData": set,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.CoreV1().Secrets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("patch secret: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) DeleteSecret(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete secret: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	SnapshotIDLabel = "mlspace.io/snapshot-id"
+	DiskIDLabel     = "mlspace.io/disk-id"
+	snapshotGroup   = "snapshot.storage.k8s.io"
+)
+
+var volumeSnapshotResource = schema.GroupVersionResource{
+	Group:    snapshotGroup,
+	Version:  "v1",
+	Resource: "volumesnapshots",
+}
+
+type SnapshotSpec struct {
+	ID         string
+	Name       string
+	Namespace  string
+	ProjectID  string
+	DiskID     string
+	PVCName    string
+	OwnerEmail string
+}
+
+// SnapshotState is the part of the VolumeSnapshot status mlspace cares about.
+type SnapshotState struct {
+	Exists      bool
+	ReadyToUse  bool
+	RestoreSize string
+	Error       string
+}
+
+func (k *KuberService) CreateVolumeSnapshot(ctx context.Context, spec SnapshotSpec) error {
+	snapshotSpec := map[string]any{
+		"source": map[string]any{
+			"persistentVolumeClaimName": spec.PVCName,
+		},
+	}
+	if k.cfg.Kuber.VolumeSnapshotClass != "" {
+		snapshotSpec["volumeSnapshotClassName"] = k.cfg.Kuber.VolumeSnapshotClass
+	}
+
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": snapshotGroup + "/v1",
+			"kind":       "VolumeSnapshot",
+			"metadata": map[string]any{
+				"name":      spec.Name,
+				"namespace": spec.Namespace,
+				"labels": map[string]any{
+					ManagedByLabel:  ManagedByValue,
+					ProjectIDLabel:  spec.ProjectID,
+					DiskIDLabel:     spec.DiskID,
+					SnapshotIDLabel: spec.ID,
+				},
+				"annotations": map[string]any{
+					OwnerEmailAnnotation: spec.OwnerEmail,
+				},
+			},
+			"spec": snapshotSpec,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.dynamicClient.Resource(volumeSnapshotResource).Namespace(spec.Namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create volume snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) GetVolumeSnapshotState(ctx context.Context, namespace, name string) (SnapshotState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	snapshot, err := k.dynamicClient.Resource(volumeSnapshotResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return SnapshotState{}, nil
+	}
+	if err != nil {
+		return SnapshotState{}, fmt.Errorf("get volume snapshot: %w", err)
+	}
+
+	state := SnapshotState{Exists: true}
+	state.ReadyToUse, _, _ = unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	state.RestoreSize, _, _ = unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	state.Error, _, _ = unstructured.NestedString(snapshot.Object, "status", "error", "message")
+
+	return state, nil
+}
+
+func (k *KuberService) DeleteVolumeSnapshot(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.dynamicClient.Resource(volumeSnapshotResource).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete volume snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePVCFromSnapshot provisions a claim pre-filled with the snapshot data,
+// the size must be at least the restore size of the snapshot.
+func (k *KuberService) CreatePVCFromSnapshot(
+	ctx context.Context,
+	namespace string,
+	pvcName string,
+	size string,
+	ownerEmail string,
+	storage PVCStorage,
+	snapshotName string,
+) (*corev1.PersistentVolumeClaim, error) {
+	apiGroup := snapshotGroup
+
+	return k.createPVC(ctx, namespace, pvcName, size, ownerEmail, storage, &corev1.TypedObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     "VolumeSnapshot",
+		Name:     snapshotName,
+	})
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	TensorBoardDiskLabel = "mlspace.io/tensorboard-disk-id"
+	TensorBoardPort      = 6006
+
+	tensorBoardMountPath = "/mnt/disk"
+)
+
+// TensorBoardSpec serves the event files below LogDir of a disk. The disk
+// is mounted read-only, TensorBoard never writes to it.
+type TensorBoardSpec struct {
+	DiskID     string
+	Name       string
+	Namespace  string
+	ProjectID  string
+	OwnerEmail string
+	Image      string
+	PVCName    string
+	LogDir     string
+	BaseURL    string
+}
+
+func (s TensorBoardSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel:       ManagedByValue,
+		ProjectIDLabel:       s.ProjectID,
+		TensorBoardDiskLabel: s.DiskID,
+	}
+}
+
+// pod serves under the mlspace route through --path_prefix, the proxy
+// keeps the request path as is.
+func (s TensorBoardSpec) pod() *corev1.Pod {
+	volumes, volumeMounts := diskVolumes([]DiskMount{
+		{PVCName: s.PVCName, MountPath: tensorBoardMountPath, ReadOnly: true},
+	})
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Volumes:       volumes,
+			Containers: []corev1.Container{
+				{
+					Name:  "tensorboard",
+					Image: s.Image,
+					Command: []string{
+						"tensorboard",
+						"--logdir", path.Join(tensorBoardMountPath, s.LogDir),
+						"--host", "0.0.0.0",
+						"--port", fmt.Sprint(TensorBoardPort),
+						"--path_prefix", strings.TrimSuffix(s.BaseURL, "/"),
+					},
+					Ports: []corev1.ContainerPort{
+						{Name: "http", ContainerPort: TensorBoardPort},
+					},
+					Resources:    resourceRequirements(1, 1),
+					VolumeMounts: volumeMounts,
+				},
+			},
+		},
+	}
+}
+
+func (s TensorBoardSpec) service() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{TensorBoardDiskLabel: s.DiskID},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       TensorBoardPort,
+					TargetPort: intstr.FromInt32(TensorBoardPort),
+				},
+			},
+		},
+	}
+}
+
+func (k *KuberService) StartTensorBoard(ctx context.Context, spec TensorBoardSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.CoreV1().Services(spec.Namespace).Create(ctx, spec.service(), metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create tensorboard service: %w", err)
+	}
+
+	_, err = k.clientset.CoreV1().Pods(spec.Namespace).Create(ctx, spec.pod(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create tensorboard pod: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) StopTensorBoard(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete tensorboard pod: %w", err)
+	}
+
+	err = k.clientset.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete tensorboard service: %w", err)
+	}
+
+	return nil
+}
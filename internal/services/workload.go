@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	OwnerEmailAnnotation = "mlspace.io/onwer-email"
+	ProjectIDLabel       = "mlspace.io/project-id"
+)
+
+type WorkloadStatus int
+
+const (
+	WorkloadPending WorkloadStatus = iota
+	WorkloadRunning
+	WorkloadSucceeded
+	WorkloadFailed
+	WorkloadStopped
+	WorkloadUnknown
+)
+
+func (s WorkloadStatus) String() string {
+	switch s {
+	case WorkloadPending:
+		return "Pending"
+	case WorkloadRunning:
+		return "Running"
+	case WorkloadSucceeded:
+		return "Succeeded"
+	case WorkloadFailed:
+		return "Failed"
+	case WorkloadStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// DiskMount describes a project disk PVC mounted into a workload container.
+type DiskMount struct {
+	PVCName   string
+	MountPath string
+	ReadOnly  bool
+}
+
+var mountNameSanitizer = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// MountDirName turns a user supplied disk name into a safe directory name.
+func MountDirName(name string) string {
+	dir := mountNameSanitizer.ReplaceAllString(strings.ToLower(name), "-")
+	dir = strings.Trim(dir, "-.")
+	if dir == "" {
+		return "disk"
+	}
+	return dir
+}
+
+func diskVolumes(mounts []DiskMount) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+
+	for _, mount := range mounts {
+		volumes = append(volumes, corev1.Volume{
+			Name: mount.PVCName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: mount.PVCName,
+					ReadOnly:  mount.ReadOnly,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      mount.PVCName,
+			MountPath: mount.MountPath,
+			ReadOnly:  mount.ReadOnly,
+		})
+	}
+
+	return volumes, volumeMounts
+}
+
+// resourceRequirements builds equal requests and limits from CPU cores and
+// RAM in GiB, so workloads are accounted exactly against the project quota.
+func resourceRequirements(cpu int, ram int) corev1.ResourceRequirements {
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%d", cpu)),
+		corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dGi", ram)),
+	}
+
+	return corev1.ResourceRequirements{
+		Requests: resources,
+		Limits:   resources.DeepCopy(),
+	}
+}
+
+func mapK8sPodPhaseToWorkloadStatus(phase corev1.PodPhase) WorkloadStatus {
+	switch phase {
+	case corev1.PodPending:
+		return WorkloadPending
+	case corev1.PodRunning:
+		return WorkloadRunning
+	case corev1.PodSucceeded:
+		return WorkloadSucceeded
+	case corev1.PodFailed:
+		return WorkloadFailed
+	default:
+		return WorkloadUnknown
+	}
+}
+
+// GetPodFromCache looks up an mlspace managed pod in the informer cache.
+func (k *KuberService) GetPodFromCache(namespace, podName string) (*corev1.Pod, error) {
+	key := fmt.Sprintf("%s/%s", namespace, podName)
+	obj, exists, err := k.podLister.GetByKey(key)
+
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("pod %s not found in cache", key)
+	}
+
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("object is not a Pod")
+	}
+
+	return pod, nil
+}
+
+func (k *KuberService) GetPodStatus(namespace, podName string) WorkloadStatus {
+	pod, err := k.GetPodFromCache(namespace, podName)
+	if err != nil {
+		return WorkloadUnknown
+	}
+
+	if pod.DeletionTimestamp != nil {
+		return WorkloadStopped
+	}
+
+	return mapK8sPodPhaseToWorkloadStatus(pod.Status.Phase)
+}
+
+// ServiceURL is the in-cluster address of a service port.
+func (k *KuberService) ServiceURL(namespace, service string, port int) *url.URL {
+	return &url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("%s.%s.svc.%s:%d", service, namespace, k.cfg.Kuber.ClusterDomain, port),
+	}
+}
+
+// ServiceProxy forwards requests to a service inside a project namespace.
+// The request path is kept as is, so the workload must serve under the same
+// base path as the mlspace route in front of it. Workloads run user code:
+// they never see the cookies or credentials of the request and can't set
+// cookies, a token is sent the way Jupyter expects it. Without a proxy
+// origin the pages are sandboxed, they would share the mlspace origin.
+func (k *KuberService) ServiceProxy(namespace, service string, port int, token string) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(k.ServiceURL(namespace, service, port))
+
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Header.Del("Cookie")
+		r.Header.Del("Authorization")
+		if token != "" {
+			r.Header.Set("Authorization", "token "+token)
+		}
+	}
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		resp.Header.Del("Set-Cookie")
+		if k.cfg.Proxy.Origin == "" {
+			resp.Header.Set("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups allow-modals allow-downloads")
+		}
+		return nil
+	}
+
+	return proxy
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	WorkspaceIDLabel = "mlspace.io/workspace-id"
+	WorkspacePort    = 8888
+
+	// BaseURLEnv tells a custom workspace command the path it is proxied
+	// under, the proxy does not strip it.
+	BaseURLEnv = "MLSPACE_BASE_URL"
+	// TokenEnv holds the token the proxy sends, Jupyter reads it on its own
+	TokenEnv = "JUPYTER_TOKEN"
+
+	workspaceTokenKey = "token"
+)
+
+type WorkspaceSpec struct {
+	ID         string
+	Name       string
+	Namespace  string
+	ProjectID  string
+	OwnerEmail string
+	Image      string
+	Command    string
+	Port       int
+	CPU        int
+	RAM        int
+	BaseURL    string
+	Token      string
+	Disks      []DiskMount
+	Secrets    []SecretMount
+}
+
+// WorkspaceToken derives the token of a workspace, every replica knows it
+// without storing it.
+func (k *KuberService) WorkspaceToken(workspaceID string) string {
+	mac := hmac.New(sha256.New, []byte(k.cfg.Proxy.Secret))
+	mac.Write([]byte("workspace:" + workspaceID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s WorkspaceSpec) labels() map[string]string {
+	return map[string]string{
+		ManagedByLabel:   ManagedByValue,
+		ProjectIDLabel:   s.ProjectID,
+		WorkspaceIDLabel: s.ID,
+	}
+}
+
+func (s WorkspaceSpec) port() int32 {
+	if s.Port == 0 {
+		return WorkspacePort
+	}
+	return int32(s.Port)
+}
+
+// container starts JupyterLab unless the catalog entry brings its own
+// command, which then runs through a shell.
+func (s WorkspaceSpec) container(volumeMounts []corev1.VolumeMount, envFrom []corev1.EnvFromSource) corev1.Container {
+	container := corev1.Container{
+		Name:  "notebook",
+		Image: s.Image,
+		Args: []string{
+			"start-notebook.py",
+			"--ServerApp.base_url=" + s.BaseURL,
+		},
+		Env: []corev1.EnvVar{
+			{Name: BaseURLEnv, Value: s.BaseURL},
+			{
+				Name: TokenEnv,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: s.tokenSecretName()},
+						Key:                  workspaceTokenKey,
+					},
+				},
+			},
+		},
+		EnvFrom: envFrom,
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: s.port()},
+		},
+		Resources:    resourceRequirements(s.CPU, s.RAM),
+		VolumeMounts: volumeMounts,
+	}
+
+	if s.Command != "" {
+		container.Command = []string{"/bin/sh", "-c", s.Command}
+		container.Args = nil
+	}
+
+	return container
+}
+
+// tokenSecretName keeps the token out of the pod spec, members can read pods
+// but not Secrets.
+func (s WorkspaceSpec) tokenSecretName() string {
+	return s.Name + "-token"
+}
+
+func (s WorkspaceSpec) tokenSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.tokenSecretName(),
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+		},
+		StringData: map[string]string{workspaceTokenKey: s.Token},
+	}
+}
+
+func (s WorkspaceSpec) pod() *corev1.Pod {
+	volumes, volumeMounts := diskVolumes(s.Disks)
+	secretVolumes, secretMounts, envFrom := secretSources(s.Secrets)
+	volumes = append(volumes, secretVolumes...)
+	volumeMounts = append(volumeMounts, secretMounts...)
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+			Annotations: map[string]string{
+				OwnerEmailAnnotation: s.OwnerEmail,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Volumes:       volumes,
+			Containers:    []corev1.Container{s.container(volumeMounts, envFrom)},
+		},
+	}
+}
+
+func (s WorkspaceSpec) service() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Labels:    s.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{WorkspaceIDLabel: s.ID},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       s.port(),
+					TargetPort: intstr.FromInt32(s.port()),
+				},
+			},
+		},
+	}
+}
+
+// StartWorkspace creates the notebook pod and its service. An existing
+// service is kept, so a stopped workspace keeps its address.
+func (k *KuberService) StartWorkspace(ctx context.Context, spec WorkspaceSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := k.clientset.CoreV1().Services(spec.Namespace).Create(ctx, spec.service(), metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create workspace service: %w", err)
+	}
+
+	secrets := k.clientset.CoreV1().Secrets(spec.Namespace)
+	_, err = secrets.Create(ctx, spec.tokenSecret(), metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, spec.tokenSecret(), metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("apply workspace token: %w", err)
+	}
+
+	_, err = k.clientset.CoreV1().Pods(spec.Namespace).Create(ctx, spec.pod(), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create workspace pod: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) StopWorkspace(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete workspace pod: %w", err)
+	}
+
+	return nil
+}
+
+func (k *KuberService) DeleteWorkspace(ctx context.Context, namespace, name string) error {
+	if err := k.StopWorkspace(ctx, namespace, name); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := k.clientset.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete workspace service: %w", err)
+	}
+
+	err = k.clientset.CoreV1().Secrets(namespace).Delete(ctx, WorkspaceSpec{Name: name}.tokenSecretName(), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete workspace token: %w", err)
+	}
+
+	return nil
+}
+
+// jupyterStatus is the part of the Jupyter Server /api/status answer the
+// culler needs.
+type jupyterStatus struct {
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// WorkspaceLastActivity asks the Jupyter server of a workspace when it last
+// saw a kernel or a client do something.
+func (k *KuberService) WorkspaceLastActivity(ctx context.Context, namespace, name string, port int, baseURL, token string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	statusURL := k.ServiceURL(namespace, name, port).JoinPath(baseURL, "api", "status")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("jupyter status: %s", resp.Status)
+	}
+
+	var status jupyterStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return time.Time{}, fmt.Errorf("decode jupyter status: %w", err)
+	}
+
+	return status.LastActivity, nil
+}
@@ -0,0 +1,98 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Broker fans out published values to every subscriber. Slow subscribers
+// miss values instead of blocking the publisher, which is usually an
+// informer event handler.
+type Broker[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[chan T]struct{}
+}
+
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{subscribers: make(map[chan T]struct{})}
+}
+
+func (b *Broker[T]) Subscribe() (<-chan T, func()) {
+	ch := make(chan T, 16)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *Broker[T]) Publish(value T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- value:
+		default:
+		}
+	}
+}
+
+// Stream prepares the response for an event stream and returns the flusher
+// used after every event.
+func Stream(w http.ResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return flusher, true
+}
+
+// WriteEvent writes a named event, every line of data gets its own data field.
+func WriteEvent(w http.ResponseWriter, flusher http.Flusher, event, data string) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	return nil
+}
+
+// WriteComment keeps idle connections open through proxies.
+func WriteComment(w http.ResponseWriter, flusher http.Flusher, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// Keys of the advisory locks electing the replica that runs a background
+// loop.
+const (
+	LockUsageSampler int64 = iota + 1
+	LockCuller
+)
+
+// Leader holds a session level advisory lock on its own connection, so only
+// one replica runs a background loop. The lock goes away with the connection
+// and another replica takes over on its next try.
+type Leader struct {
+	uow  UnitOfWork
+	key  int64
+	conn *sql.Conn
+}
+
+func NewLeader(uow UnitOfWork, key int64) *Leader {
+	return &Leader{uow: uow, key: key}
+}
+
+// Lead tells whether this replica holds the lock, it tries to take the lock
+// when it doesn't. It is meant to be called from a single goroutine.
+func (l *Leader) Lead(ctx context.Context) bool {
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true
+		}
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.uow.DB().Conn(ctx)
+	if err != nil {
+		log.Printf("Leader: open connection: %s", err)
+		return false
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&locked)
+	if err != nil {
+		log.Printf("Leader: take lock %d: %s", l.key, err)
+	}
+	if err != nil || !locked {
+		conn.Close()
+		return false
+	}
+
+	l.conn = conn
+	return true
+}
+
+// Release gives the lock up, e.g. on shutdown.
+func (l *Leader) Release() {
+	if l.conn == nil {
+		return
+	}
+
+	if _, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		log.Printf("Leader: release lock %d: %s", l.key, err)
+	}
+	l.conn.Close()
+	l.conn = nil
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workspace_disks;
+DROP INDEX IF EXISTS idx_workspaces_project_id;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,24 @@
+CREATE TABLE workspaces (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    image TEXT NOT NULL,
+    cpu INTEGER NOT NULL,
+    ram INTEGER NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'running',
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workspaces_project_id ON workspaces(project_id);
+
+CREATE TABLE workspace_disks (
+    workspace_id UUID NOT NULL,
+    disk_id UUID NOT NULL,
+    FOREIGN KEY(workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY(disk_id) REFERENCES disks(id) ON DELETE CASCADE,
+    PRIMARY KEY(workspace_id, disk_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS job_disks;
+DROP INDEX IF EXISTS idx_jobs_project_id;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,28 @@
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    image TEXT NOT NULL,
+    command TEXT NOT NULL,
+    cpu INTEGER NOT NULL,
+    ram INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'Pending',
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    exit_code INTEGER,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_project_id ON jobs(project_id);
+
+CREATE TABLE job_disks (
+    job_id UUID NOT NULL,
+    disk_id UUID NOT NULL,
+    FOREIGN KEY(job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY(disk_id) REFERENCES disks(id) ON DELETE CASCADE,
+    PRIMARY KEY(job_id, disk_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE disks DROP COLUMN IF EXISTS provision_status;
+ALTER TABLE projects DROP COLUMN IF EXISTS provision_status;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,17 @@
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(next_attempt_at) WHERE status = 'pending';
+
+ALTER TABLE projects ADD COLUMN provision_status VARCHAR(20) NOT NULL DEFAULT 'provisioned';
+ALTER TABLE disks ADD COLUMN provision_status VARCHAR(20) NOT NULL DEFAULT 'provisioned';
@@ -0,0 +1,11 @@
+CREATE TABLE retained_volumes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    disk_id UUID NOT NULL UNIQUE,
+    disk_name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    pv_name VARCHAR(253) NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
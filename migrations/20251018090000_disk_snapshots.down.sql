@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_disk_snapshots_disk_id;
+DROP TABLE IF EXISTS disk_snapshots;
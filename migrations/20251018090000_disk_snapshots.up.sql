@@ -0,0 +1,17 @@
+CREATE TABLE disk_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    disk_id UUID,
+    FOREIGN KEY(disk_id) REFERENCES disks(id) ON DELETE SET NULL,
+    disk_name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    size INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_disk_snapshots_disk_id ON disk_snapshots(disk_id);
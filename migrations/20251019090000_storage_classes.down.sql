@@ -0,0 +1,4 @@
+ALTER TABLE disk_snapshots DROP COLUMN IF EXISTS storage_class_id;
+ALTER TABLE disks DROP COLUMN IF EXISTS storage_class_id;
+
+DROP TABLE IF EXISTS storage_classes;
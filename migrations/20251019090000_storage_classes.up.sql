@@ -0,0 +1,11 @@
+CREATE TABLE storage_classes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    class_name VARCHAR(253) NOT NULL UNIQUE,
+    allow_shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE disks ADD COLUMN storage_class_id UUID REFERENCES storage_classes(id);
+ALTER TABLE disk_snapshots ADD COLUMN storage_class_id UUID REFERENCES storage_classes(id) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE egress_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cidr VARCHAR(64) NOT NULL,
+    protocol VARCHAR(8) NOT NULL DEFAULT '',
+    port INTEGER NOT NULL DEFAULT 0,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
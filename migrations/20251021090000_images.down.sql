@@ -0,0 +1,7 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS image_id;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS port;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS command;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS image_id;
+
+DROP TABLE IF EXISTS project_images;
+DROP TABLE IF EXISTS images;
@@ -0,0 +1,27 @@
+CREATE TABLE images (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    image TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    default_command TEXT NOT NULL DEFAULT '',
+    port INTEGER,
+    cpu INTEGER NOT NULL DEFAULT 1,
+    ram INTEGER NOT NULL DEFAULT 2,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE project_images (
+    project_id UUID NOT NULL,
+    image_id UUID NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(image_id) REFERENCES images(id) ON DELETE CASCADE,
+    PRIMARY KEY(project_id, image_id)
+);
+
+ALTER TABLE workspaces ADD COLUMN image_id UUID REFERENCES images(id) ON DELETE SET NULL;
+ALTER TABLE workspaces ADD COLUMN command TEXT NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN port INTEGER NOT NULL DEFAULT 8888;
+ALTER TABLE jobs ADD COLUMN image_id UUID REFERENCES images(id) ON DELETE SET NULL;
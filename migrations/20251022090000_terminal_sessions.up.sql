@@ -0,0 +1,13 @@
+CREATE TABLE terminal_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    pod_name VARCHAR(253) NOT NULL,
+    container VARCHAR(253) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_terminal_sessions_started_at ON terminal_sessions(started_at);
@@ -0,0 +1,11 @@
+CREATE TABLE project_usage_samples (
+    project_id UUID NOT NULL,
+    sampled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cpu_millicores BIGINT NOT NULL,
+    ram_bytes BIGINT NOT NULL,
+    pods INTEGER NOT NULL,
+    PRIMARY KEY(project_id, sampled_at),
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_project_usage_samples_sampled_at ON project_usage_samples(sampled_at);
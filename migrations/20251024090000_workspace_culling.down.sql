@@ -0,0 +1,6 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS cull_warned_at;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS last_activity_at;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS keep_running;
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS shutdown_schedules;
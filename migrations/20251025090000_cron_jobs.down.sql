@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cron_job_disks;
+DROP INDEX IF EXISTS idx_cron_jobs_project_id;
+DROP TABLE IF EXISTS cron_jobs;
@@ -0,0 +1,31 @@
+CREATE TABLE cron_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    image_id UUID REFERENCES images(id) ON DELETE SET NULL,
+    image TEXT NOT NULL,
+    command TEXT NOT NULL,
+    cpu INTEGER NOT NULL,
+    ram INTEGER NOT NULL,
+    schedule VARCHAR(100) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL,
+    concurrency_policy VARCHAR(10) NOT NULL DEFAULT 'Forbid',
+    successful_history INTEGER NOT NULL DEFAULT 3,
+    failed_history INTEGER NOT NULL DEFAULT 1,
+    suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_cron_jobs_project_id ON cron_jobs(project_id);
+
+CREATE TABLE cron_job_disks (
+    cron_job_id UUID NOT NULL,
+    disk_id UUID NOT NULL,
+    FOREIGN KEY(cron_job_id) REFERENCES cron_jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY(disk_id) REFERENCES disks(id) ON DELETE CASCADE,
+    PRIMARY KEY(cron_job_id, disk_id)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS job_secrets;
+DROP TABLE IF EXISTS workspace_secrets;
+DROP INDEX IF EXISTS idx_project_secret_audit_project_id;
+DROP TABLE IF EXISTS project_secret_audit;
+DROP TABLE IF EXISTS project_secret_keys;
+DROP TABLE IF EXISTS project_secrets;
@@ -0,0 +1,51 @@
+CREATE TABLE project_secrets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(63) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(project_id, name)
+);
+
+CREATE TABLE project_secret_keys (
+    secret_id UUID NOT NULL,
+    key VARCHAR(253) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY(secret_id) REFERENCES project_secrets(id) ON DELETE CASCADE,
+    PRIMARY KEY(secret_id, key)
+);
+
+CREATE TABLE project_secret_audit (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    secret_name VARCHAR(63) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    key VARCHAR(253),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_project_secret_audit_project_id ON project_secret_audit(project_id, created_at);
+
+CREATE TABLE workspace_secrets (
+    workspace_id UUID NOT NULL,
+    secret_id UUID NOT NULL,
+    as_file BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY(secret_id) REFERENCES project_secrets(id) ON DELETE CASCADE,
+    PRIMARY KEY(workspace_id, secret_id)
+);
+
+CREATE TABLE job_secrets (
+    job_id UUID NOT NULL,
+    secret_id UUID NOT NULL,
+    as_file BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY(secret_id) REFERENCES project_secrets(id) ON DELETE CASCADE,
+    PRIMARY KEY(job_id, secret_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS deployment_revisions;
+DROP INDEX IF EXISTS idx_deployments_project_id;
+DROP TABLE IF EXISTS deployments;
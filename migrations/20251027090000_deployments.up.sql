@@ -0,0 +1,32 @@
+CREATE TABLE deployments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    replicas INTEGER NOT NULL DEFAULT 1,
+    revision INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_deployments_project_id ON deployments(project_id);
+
+CREATE TABLE deployment_revisions (
+    deployment_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    image_id UUID REFERENCES images(id) ON DELETE SET NULL,
+    image TEXT NOT NULL,
+    command TEXT NOT NULL DEFAULT '',
+    port INTEGER NOT NULL,
+    disk_id UUID REFERENCES disks(id) ON DELETE SET NULL,
+    model_path TEXT NOT NULL DEFAULT '',
+    cpu INTEGER NOT NULL,
+    ram INTEGER NOT NULL,
+    created_by UUID NOT NULL,
+    FOREIGN KEY(deployment_id) REFERENCES deployments(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(deployment_id, revision)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE tensorboards (
+    disk_id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    log_dir TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY(disk_id) REFERENCES disks(id) ON DELETE CASCADE,
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(owner_id) REFERENCES users(id)
+);
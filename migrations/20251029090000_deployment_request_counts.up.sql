@@ -0,0 +1,2 @@
+ALTER TABLE deployments ADD COLUMN requests BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE deployments ADD COLUMN errors BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE disks DROP COLUMN IF EXISTS source_snapshot_id;
@@ -0,0 +1,2 @@
+ALTER TABLE disks ADD COLUMN source_snapshot_id UUID;
+ALTER TABLE disks ADD FOREIGN KEY(source_snapshot_id) REFERENCES disk_snapshots(id) ON DELETE SET NULL;
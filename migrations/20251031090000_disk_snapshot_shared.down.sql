@@ -0,0 +1 @@
+ALTER TABLE disk_snapshots DROP COLUMN IF EXISTS shared;
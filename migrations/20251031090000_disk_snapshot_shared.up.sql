@@ -0,0 +1,2 @@
+ALTER TABLE disk_snapshots ADD COLUMN shared BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE disk_snapshots s SET shared = d.shared FROM disks d WHERE d.id = s.disk_id;
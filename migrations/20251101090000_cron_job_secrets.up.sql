@@ -0,0 +1,8 @@
+CREATE TABLE cron_job_secrets (
+    cron_job_id UUID NOT NULL,
+    secret_id UUID NOT NULL,
+    as_file BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY(cron_job_id) REFERENCES cron_jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY(secret_id) REFERENCES project_secrets(id) ON DELETE CASCADE,
+    PRIMARY KEY(cron_job_id, secret_id)
+);
@@ -29,7 +29,7 @@ func Base() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\" data-theme=\"dark\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><link href=\"https://cdn.jsdelivr.net/npm/daisyui@5\" rel=\"stylesheet\" type=\"text/css\"><script src=\"https://cdn.jsdelivr.net/npm/@tailwindcss/browser@4\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><meta name=\"htmx-config\" content='{\n\t\t\t        \"responseHandling\":[\n\t\t\t            {\"code\":\"204\", \"swap\": false},\n\t\t\t            {\"code\":\"[23]..\", \"swap\": true},\n\t\t\t            {\"code\":\"[4]..\", \"swap\": true},\n\t\t\t            {\"code\":\"...\", \"swap\": false, \"error\": true}\n\t\t\t        ]\n\t\t\t    }'><title>MLspace</title></head><body class=\"bg-base-100\"><main id=\"main\"><div id=\"toast-message\" class=\"toast toast-top toast-center hidden fixed z-50\"><div id=\"toast-alert\" class=\"alert alert-error\"><span></span></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\" data-theme=\"dark\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><link href=\"https://cdn.jsdelivr.net/npm/daisyui@5\" rel=\"stylesheet\" type=\"text/css\"><script src=\"https://cdn.jsdelivr.net/npm/@tailwindcss/browser@4\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10/dist/ext/sse.js\"></script><meta name=\"htmx-config\" content='{\n\t\t\t        \"responseHandling\":[\n\t\t\t            {\"code\":\"204\", \"swap\": false},\n\t\t\t            {\"code\":\"[23]..\", \"swap\": true},\n\t\t\t            {\"code\":\"[4]..\", \"swap\": true},\n\t\t\t            {\"code\":\"...\", \"swap\": false, \"error\": true}\n\t\t\t        ]\n\t\t\t    }'><title>MLspace</title></head><body class=\"bg-base-100\"><main id=\"main\"><div id=\"toast-message\" class=\"toast toast-top toast-center hidden fixed z-50\"><div id=\"toast-alert\" class=\"alert alert-error\"><span></span></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
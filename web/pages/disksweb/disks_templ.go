@@ -18,14 +18,71 @@ type WebDisk struct {
 	ID            uuid.UUID
 	Name          string
 	Status        string
+	Conditions    []string
+	Reason        string
 	OwnerUsername string
 	OwnerEmail    string
 	Size          int
 	Shared        bool
+	StorageClass  string
 	Project       WebDiskProject
 	CreatedAt     string
 }
 
+type WebDiskStorageClass struct {
+	ID          uuid.UUID
+	Name        string
+	AllowShared bool
+}
+
+type WebSnapshot struct {
+	ID            uuid.UUID
+	Name          string
+	DiskName      string
+	OwnerUsername string
+	OwnerEmail    string
+	Size          int
+	Status        string
+	CreatedAt     string
+}
+
+type WebTensorBoard struct {
+	DiskID        uuid.UUID
+	LogDir        string
+	URL           string
+	OwnerUsername string
+	Status        string
+	StartedAt     string
+}
+
+type WebDiskFile struct {
+	Name       string
+	Path       string
+	Dir        bool
+	Size       string
+	ModifiedAt string
+}
+
+// WebDiskFiles is a directory listing, Crumbs lead from the disk root to it.
+type WebDiskFiles struct {
+	DiskID   uuid.UUID
+	DiskName string
+	Path     string
+	Crumbs   []WebDiskFile
+	Files    []WebDiskFile
+}
+
+// WebDiskFilePreview holds the start of a file. Kind is text, csv, image,
+// binary or large.
+type WebDiskFilePreview struct {
+	DiskID    uuid.UUID
+	File      WebDiskFile
+	Kind      string
+	Text      string
+	Rows      [][]string
+	Truncated bool
+}
+
 type WebDiskProject struct {
 	ID   uuid.UUID
 	Name string
@@ -148,7 +205,7 @@ func DisksPartial(disk_list []WebDisk) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div><div class=\"projects-container mt-4 p-4\"><div class=\"overflow-x-auto rounded-box border border-base-200 bg-base-100\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div><div class=\"projects-container mt-4 p-4\" hx-ext=\"sse\" sse-connect=\"/disks/events\"><div class=\"overflow-x-auto rounded-box border border-base-200 bg-base-100\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -156,7 +213,23 @@ func DisksPartial(disk_list []WebDisk) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = SnapshotModal().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CloneModal().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = TensorBoardModal().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -13,15 +13,16 @@ import "aispace/web/layouts"
 import "github.com/google/uuid"
 
 type WebProject struct {
-	ID            uuid.UUID
-	Name          string
-	Description   string
-	OwnerUsername string
-	OwnerEmail    string
-	CPULimit      int
-	RAMLimit      int
-	StorageLimit  int
-	CreatedAt     string
+	ID              uuid.UUID
+	Name            string
+	Description     string
+	OwnerUsername   string
+	OwnerEmail      string
+	CPULimit        int
+	RAMLimit        int
+	StorageLimit    int
+	ProvisionStatus string
+	CreatedAt       string
 }
 
 func ProjectModal() templ.Component {